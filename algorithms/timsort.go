@@ -0,0 +1,198 @@
+package algorithms
+
+import (
+	"simpyl/object"
+)
+
+// minMerge is the shortest run TimSort will merge; shorter slices are sorted by
+// binary insertion alone
+const minMerge = 32
+
+// run is a sorted stretch of the slice awaiting a merge
+type run struct {
+	start  int
+	length int
+}
+
+// TimSort sorts arr in place with a stable, TimSort-like algorithm. less reports
+// whether a sorts strictly before b, and equal elements keep their input order.
+// Ascending and descending stretches already in the input are detected and merged
+// rather than sorted again, so presorted data costs a single pass
+func TimSort(arr []object.Object, less func(a, b object.Object) bool) {
+	n := len(arr)
+	if n < 2 {
+		return
+	}
+
+	if n < minMerge {
+		initRun := countRunAndMakeAscending(arr, 0, n, less)
+		binaryInsertionSort(arr, 0, n, initRun, less)
+		return
+	}
+
+	ts := &timSort{arr: arr, less: less}
+	minRun := minRunLength(n)
+	for lo := 0; lo < n; {
+		runLen := countRunAndMakeAscending(arr, lo, n, less)
+
+		// extend short runs to minRun elements
+		if runLen < minRun {
+			force := min(minRun, n-lo)
+			binaryInsertionSort(arr, lo, lo+force, lo+runLen, less)
+			runLen = force
+		}
+
+		ts.runs = append(ts.runs, run{start: lo, length: runLen})
+		ts.mergeCollapse()
+		lo += runLen
+	}
+	ts.mergeForceCollapse()
+}
+
+type timSort struct {
+	arr  []object.Object
+	less func(a, b object.Object) bool
+	runs []run
+	tmp  []object.Object
+}
+
+// minRunLength picks a run length in [minMerge/2, minMerge] such that n/minRun
+// is close to, but no more than, a power of two
+func minRunLength(n int) int {
+	r := 0
+	for n >= minMerge {
+		r |= n & 1
+		n >>= 1
+	}
+	return n + r
+}
+
+// countRunAndMakeAscending returns the length of the run starting at lo. A
+// strictly descending run is reversed in place; requiring strictness keeps equal
+// elements from being reordered
+func countRunAndMakeAscending(arr []object.Object, lo, hi int, less func(a, b object.Object) bool) int {
+	runHi := lo + 1
+	if runHi == hi {
+		return 1
+	}
+
+	if less(arr[runHi], arr[lo]) {
+		runHi++
+		for runHi < hi && less(arr[runHi], arr[runHi-1]) {
+			runHi++
+		}
+		reverseRange(arr, lo, runHi)
+	} else {
+		runHi++
+		for runHi < hi && !less(arr[runHi], arr[runHi-1]) {
+			runHi++
+		}
+	}
+
+	return runHi - lo
+}
+
+func reverseRange(arr []object.Object, lo, hi int) {
+	for hi--; lo < hi; lo, hi = lo+1, hi-1 {
+		arr[lo], arr[hi] = arr[hi], arr[lo]
+	}
+}
+
+// binaryInsertionSort sorts arr[lo:hi], given that arr[lo:start] is already
+// sorted. Each element is inserted after any equal elements, keeping it stable
+func binaryInsertionSort(arr []object.Object, lo, hi, start int, less func(a, b object.Object) bool) {
+	if start == lo {
+		start++
+	}
+
+	for ; start < hi; start++ {
+		pivot := arr[start]
+
+		left, right := lo, start
+		for left < right {
+			mid := int(uint(left+right) >> 1)
+			if less(pivot, arr[mid]) {
+				right = mid
+			} else {
+				left = mid + 1
+			}
+		}
+
+		copy(arr[left+1:start+1], arr[left:start])
+		arr[left] = pivot
+	}
+}
+
+// mergeCollapse merges adjacent runs until the run lengths on the stack shrink
+// faster than the Fibonacci numbers, which bounds the stack depth and keeps
+// merges balanced
+func (ts *timSort) mergeCollapse() {
+	for len(ts.runs) > 1 {
+		n := len(ts.runs) - 2
+		switch {
+		case n > 0 && ts.runs[n-1].length <= ts.runs[n].length+ts.runs[n+1].length,
+			n > 1 && ts.runs[n-2].length <= ts.runs[n-1].length+ts.runs[n].length:
+			if ts.runs[n-1].length < ts.runs[n+1].length {
+				n--
+			}
+		case ts.runs[n].length > ts.runs[n+1].length:
+			return
+		}
+		ts.mergeAt(n)
+	}
+}
+
+// mergeForceCollapse merges every remaining run, leaving the slice sorted
+func (ts *timSort) mergeForceCollapse() {
+	for len(ts.runs) > 1 {
+		n := len(ts.runs) - 2
+		if n > 0 && ts.runs[n-1].length < ts.runs[n+1].length {
+			n--
+		}
+		ts.mergeAt(n)
+	}
+}
+
+// mergeAt merges the runs at stack indices i and i+1
+func (ts *timSort) mergeAt(i int) {
+	a, b := ts.runs[i], ts.runs[i+1]
+	ts.runs[i].length = a.length + b.length
+	ts.runs = append(ts.runs[:i+1], ts.runs[i+2:]...)
+
+	arr := ts.arr
+	less := ts.less
+
+	// elements of a that already sort before b[0] are in place
+	lo := a.start
+	end := a.start + a.length
+	for lo < end && !less(arr[b.start], arr[lo]) {
+		lo++
+	}
+	if lo == end {
+		return
+	}
+
+	// elements of b that sort at or after a's last element are in place
+	hi := b.start + b.length
+	for hi > b.start && !less(arr[hi-1], arr[end-1]) {
+		hi--
+	}
+
+	ts.tmp = append(ts.tmp[:0], arr[lo:end]...)
+	left := ts.tmp
+	right := arr[b.start:hi]
+
+	dest := lo
+	for len(left) > 0 && len(right) > 0 {
+		// take from the right only when strictly smaller, keeping the merge stable
+		if less(right[0], left[0]) {
+			arr[dest] = right[0]
+			right = right[1:]
+		} else {
+			arr[dest] = left[0]
+			left = left[1:]
+		}
+		dest++
+	}
+	copy(arr[dest:], left)
+}
@@ -0,0 +1,82 @@
+package algorithms
+
+import (
+	"math/rand"
+	"simpyl/object"
+	"sort"
+	"testing"
+	"time"
+)
+
+func TestTimSortMatchesSortStable(t *testing.T) {
+	random := rand.New(rand.NewSource(time.Now().UnixNano()))
+	less := func(a, b object.Object) bool {
+		return a.(*object.Integer).Value < b.(*object.Integer).Value
+	}
+
+	// Cover inputs shorter than a run, presorted and reversed stretches
+	for _, size := range []int{0, 1, 2, 31, 32, 33, 100, 1000, 5000} {
+		array1 := make([]object.Object, size)
+		for i := range array1 {
+			switch {
+			case i < size/4:
+				array1[i] = &object.Integer{Value: int64(i)}
+			case i < size/2:
+				array1[i] = &object.Integer{Value: int64(size - i)}
+			default:
+				array1[i] = &object.Integer{Value: int64(random.Intn(100))}
+			}
+		}
+		array2 := make([]object.Object, len(array1))
+		copy(array2, array1)
+
+		TimSort(array1, less)
+		sort.SliceStable(array2, func(i, j int) bool { return less(array2[i], array2[j]) })
+
+		for i := range array1 {
+			if array1[i].(*object.Integer).Value != array2[i].(*object.Integer).Value {
+				t.Fatalf("size %d: element %d wrong. got=%d, want=%d", size, i,
+					array1[i].(*object.Integer).Value, array2[i].(*object.Integer).Value)
+			}
+		}
+	}
+}
+
+func TestTimSortIsStable(t *testing.T) {
+	random := rand.New(rand.NewSource(time.Now().UnixNano()))
+
+	// Tag each key with its input position so ties can be checked afterwards
+	array := make([]object.Object, 2000)
+	for i := range array {
+		array[i] = &object.Tuple{Elements: []object.Object{
+			&object.Integer{Value: int64(random.Intn(10))},
+			&object.Integer{Value: int64(i)},
+		}}
+	}
+
+	TimSort(array, func(a, b object.Object) bool {
+		return a.(*object.Tuple).Elements[0].(*object.Integer).Value <
+			b.(*object.Tuple).Elements[0].(*object.Integer).Value
+	})
+
+	for i := 1; i < len(array); i++ {
+		prev, cur := array[i-1].(*object.Tuple).Elements, array[i].(*object.Tuple).Elements
+		prevKey, curKey := prev[0].(*object.Integer).Value, cur[0].(*object.Integer).Value
+		if prevKey > curKey || (prevKey == curKey && prev[1].(*object.Integer).Value > cur[1].(*object.Integer).Value) {
+			t.Fatalf("order broken at %d: %s before %s", i, array[i-1].Inspect(), array[i].Inspect())
+		}
+	}
+}
+
+func BenchmarkTimSort(b *testing.B) {
+	random := rand.New(rand.NewSource(time.Now().UnixNano()))
+	array := make([]object.Object, 1000000)
+	for i := range array {
+		array[i] = &object.Integer{Value: int64(random.Intn(100))}
+	}
+	b.ResetTimer()
+
+	TimSort(array, func(a, b object.Object) bool {
+		return a.(*object.Integer).Value < b.(*object.Integer).Value
+	})
+}
@@ -167,6 +167,102 @@ func (ws *WhileStatement) String() string {
 	return out.String()
 }
 
+type ClassStatement struct {
+	Token token.Token // The 'CLASS' token
+	Name  string      // Identifier
+	Bases []Expression
+	Body  *BlockStatement
+}
+
+func (cs *ClassStatement) statementNode()       {}
+func (cs *ClassStatement) TokenLiteral() string { return cs.Token.Literal }
+func (cs *ClassStatement) String() string {
+	var out bytes.Buffer
+
+	bases := []string{}
+	for _, b := range cs.Bases {
+		bases = append(bases, b.String())
+	}
+
+	out.WriteString("class ")
+	out.WriteString(cs.Name)
+	if len(bases) > 0 {
+		out.WriteString("(")
+		out.WriteString(strings.Join(bases, ", "))
+		out.WriteString(")")
+	}
+	out.WriteString(":\n\t")
+	out.WriteString(cs.Body.String())
+
+	return out.String()
+}
+
+type ImportStatement struct {
+	Token  token.Token // The 'IMPORT' token
+	Module *Identifier
+	Alias  *Identifier // nil unless the module is renamed with 'as'
+}
+
+func (is *ImportStatement) statementNode()       {}
+func (is *ImportStatement) TokenLiteral() string { return is.Token.Literal }
+func (is *ImportStatement) String() string {
+	var out bytes.Buffer
+
+	out.WriteString("import ")
+	out.WriteString(is.Module.String())
+	if is.Alias != nil {
+		out.WriteString(" as ")
+		out.WriteString(is.Alias.String())
+	}
+
+	return out.String()
+}
+
+type DelStatement struct {
+	Token   token.Token // The 'DEL' token
+	Targets []Expression
+}
+
+func (ds *DelStatement) statementNode()       {}
+func (ds *DelStatement) TokenLiteral() string { return ds.Token.Literal }
+func (ds *DelStatement) String() string {
+	targets := []string{}
+	for _, target := range ds.Targets {
+		targets = append(targets, target.String())
+	}
+
+	return "del " + strings.Join(targets, ", ")
+}
+
+type FromImportStatement struct {
+	Token   token.Token // The 'FROM' token
+	Module  *Identifier
+	Names   []*Identifier
+	Aliases []*Identifier // Aliases[i] is nil unless Names[i] is renamed with 'as'
+}
+
+func (fs *FromImportStatement) statementNode()       {}
+func (fs *FromImportStatement) TokenLiteral() string { return fs.Token.Literal }
+func (fs *FromImportStatement) String() string {
+	var out bytes.Buffer
+
+	names := []string{}
+	for i, name := range fs.Names {
+		if fs.Aliases[i] != nil {
+			names = append(names, name.String()+" as "+fs.Aliases[i].String())
+		} else {
+			names = append(names, name.String())
+		}
+	}
+
+	out.WriteString("from ")
+	out.WriteString(fs.Module.String())
+	out.WriteString(" import ")
+	out.WriteString(strings.Join(names, ", "))
+
+	return out.String()
+}
+
 /*
 Expressions
 */
@@ -216,12 +312,78 @@ func (sl *StringLiteral) expressionNode()      {}
 func (sl *StringLiteral) TokenLiteral() string { return sl.Token.Literal }
 func (sl *StringLiteral) String() string       { return sl.Token.Literal }
 
+type BytesLiteral struct {
+	Token token.Token
+	Value []byte
+}
+
+func (bl *BytesLiteral) expressionNode()      {}
+func (bl *BytesLiteral) TokenLiteral() string { return bl.Token.Literal }
+func (bl *BytesLiteral) String() string       { return bl.Token.Literal }
+
+// FormattedString is an f-string, made of StringLiteral and FormattedValue parts
+type FormattedString struct {
+	Token token.Token
+	Parts []Expression
+}
+
+func (fs *FormattedString) expressionNode()      {}
+func (fs *FormattedString) TokenLiteral() string { return fs.Token.Literal }
+func (fs *FormattedString) String() string       { return fs.Token.Literal }
+
+// FormattedValue is a replacement field such as {price!r:>10.2f} in an f-string
+type FormattedValue struct {
+	Token      token.Token
+	Value      Expression
+	Conversion byte             // 's', 'r' or 0 for none
+	FormatSpec *FormattedString // May itself contain replacement fields, nil if absent
+}
+
+func (fv *FormattedValue) expressionNode()      {}
+func (fv *FormattedValue) TokenLiteral() string { return fv.Token.Literal }
+func (fv *FormattedValue) String() string {
+	var out bytes.Buffer
+
+	out.WriteString("{")
+	out.WriteString(fv.Value.String())
+	if fv.Conversion != 0 {
+		out.WriteString("!" + string(fv.Conversion))
+	}
+	if fv.FormatSpec != nil {
+		out.WriteString(":")
+		for _, part := range fv.FormatSpec.Parts {
+			out.WriteString(part.String())
+		}
+	}
+	out.WriteString("}")
+
+	return out.String()
+}
+
 type PrefixExpression struct {
 	Token    token.Token // The prefix token, e.g. !
 	Operator string
 	Right    Expression
 }
 
+type TupleLiteral struct {
+	Token    token.Token // the '(' token
+	Elements []Expression
+}
+
+func (tl *TupleLiteral) expressionNode()      {}
+func (tl *TupleLiteral) TokenLiteral() string { return tl.Token.Literal }
+func (tl *TupleLiteral) String() string {
+	elements := []string{}
+	for _, el := range tl.Elements {
+		elements = append(elements, el.String())
+	}
+	if len(elements) == 1 {
+		return "(" + elements[0] + ",)"
+	}
+	return "(" + strings.Join(elements, ", ") + ")"
+}
+
 type ListLiteral struct {
 	Token    token.Token // the '[' token
 	Elements []Expression
@@ -244,6 +406,7 @@ func (ll *ListLiteral) String() string {
 type DictLiteral struct {
 	Token token.Token // the '{' token
 	Pairs map[Expression]Expression
+	Keys  []Expression // The keys of Pairs in source order
 }
 
 func (dl *DictLiteral) expressionNode()      {}
@@ -251,8 +414,8 @@ func (dl *DictLiteral) TokenLiteral() string { return dl.Token.Literal }
 func (dl *DictLiteral) String() string {
 	var out bytes.Buffer
 	pairs := []string{}
-	for key, value := range dl.Pairs {
-		pairs = append(pairs, key.String()+":"+value.String())
+	for _, key := range dl.Keys {
+		pairs = append(pairs, key.String()+":"+dl.Pairs[key].String())
 	}
 	out.WriteString("{")
 	out.WriteString(strings.Join(pairs, ", "))
@@ -260,12 +423,70 @@ func (dl *DictLiteral) String() string {
 	return out.String()
 }
 
+type SetLiteral struct {
+	Token    token.Token // the '{' token
+	Elements []Expression
+}
+
+func (sl *SetLiteral) expressionNode()      {}
+func (sl *SetLiteral) TokenLiteral() string { return sl.Token.Literal }
+func (sl *SetLiteral) String() string {
+	elements := []string{}
+	for _, el := range sl.Elements {
+		elements = append(elements, el.String())
+	}
+	return "{" + strings.Join(elements, ", ") + "}"
+}
+
+// ComprehensionClause is one "for x in iterable" of a comprehension, together with
+// the "if" conditions that follow it
+type ComprehensionClause struct {
+	Token      token.Token // The 'FOR' token
+	Iterator   *Identifier
+	Iterable   Expression
+	Conditions []Expression
+}
+
+func (cc *ComprehensionClause) String() string {
+	var out bytes.Buffer
+
+	out.WriteString("for ")
+	out.WriteString(cc.Iterator.String())
+	out.WriteString(" in ")
+	out.WriteString(cc.Iterable.String())
+	for _, cond := range cc.Conditions {
+		out.WriteString(" if ")
+		out.WriteString(cond.String())
+	}
+
+	return out.String()
+}
+
+type SetComprehension struct {
+	Token   token.Token // the '{' token
+	Element Expression
+	Clauses []*ComprehensionClause
+}
+
+func (sc *SetComprehension) expressionNode()      {}
+func (sc *SetComprehension) TokenLiteral() string { return sc.Token.Literal }
+func (sc *SetComprehension) String() string {
+	clauses := []string{sc.Element.String()}
+	for _, clause := range sc.Clauses {
+		clauses = append(clauses, clause.String())
+	}
+	return "{" + strings.Join(clauses, " ") + "}"
+}
+
+// IndexExpression is a subscript or, when Colon is set, a slice. The bounds of a
+// slice are nil when omitted
 type IndexExpression struct {
 	Token    token.Token // The [ token
 	Left     Expression
 	Index    Expression
 	Colon    bool
 	EndIndex Expression
+	Step     Expression
 }
 
 func (ie *IndexExpression) expressionNode()      {}
@@ -275,11 +496,7 @@ func (ie *IndexExpression) String() string {
 	out.WriteString("(")
 	out.WriteString(ie.Left.String())
 	out.WriteString("[")
-	out.WriteString(ie.Index.String())
-	if ie.Colon {
-		out.WriteString(":")
-		out.WriteString(ie.EndIndex.String())
-	}
+	writeSubscript(&out, ie.Index, ie.Colon, ie.EndIndex, ie.Step)
 	out.WriteString("])")
 	return out.String()
 }
@@ -290,6 +507,7 @@ type IndexAssignExpression struct {
 	Index    Expression
 	Colon    bool
 	EndIndex Expression
+	Step     Expression
 	Value    Expression
 }
 
@@ -299,11 +517,7 @@ func (ie *IndexAssignExpression) String() string {
 	var out bytes.Buffer
 	out.WriteString(ie.Left.String())
 	out.WriteString("[")
-	out.WriteString(ie.Index.String())
-	if ie.Colon {
-		out.WriteString(":")
-		out.WriteString(ie.EndIndex.String())
-	}
+	writeSubscript(&out, ie.Index, ie.Colon, ie.EndIndex, ie.Step)
 	out.WriteString("] = ")
 	if ie.Value != nil {
 		out.WriteString(ie.Value.String())
@@ -311,6 +525,24 @@ func (ie *IndexAssignExpression) String() string {
 	return out.String()
 }
 
+// writeSubscript writes the contents of the brackets of an index or slice, leaving
+// out omitted bounds
+func writeSubscript(out *bytes.Buffer, index Expression, colon bool, end, step Expression) {
+	if index != nil {
+		out.WriteString(index.String())
+	}
+	if colon {
+		out.WriteString(":")
+		if end != nil {
+			out.WriteString(end.String())
+		}
+	}
+	if step != nil {
+		out.WriteString(":")
+		out.WriteString(step.String())
+	}
+}
+
 type ObjectMethod struct {
 	Obj    Expression  // Identifier for object
 	Token  token.Token // token.DOT
@@ -329,6 +561,47 @@ func (om *ObjectMethod) String() string {
 	return out.String()
 }
 
+type AttributeExpression struct {
+	Obj       Expression  // Object the attribute is read from
+	Token     token.Token // token.DOT
+	Attribute *Identifier
+}
+
+func (ae *AttributeExpression) expressionNode()      {}
+func (ae *AttributeExpression) TokenLiteral() string { return ae.Token.Literal }
+func (ae *AttributeExpression) String() string {
+	var out bytes.Buffer
+
+	out.WriteString(ae.Obj.String())
+	out.WriteString(ae.TokenLiteral())
+	out.WriteString(ae.Attribute.String())
+
+	return out.String()
+}
+
+type AttributeAssignExpression struct {
+	Obj       Expression  // Object the attribute is set on
+	Token     token.Token // token.DOT
+	Attribute *Identifier
+	Value     Expression
+}
+
+func (ae *AttributeAssignExpression) expressionNode()      {}
+func (ae *AttributeAssignExpression) TokenLiteral() string { return ae.Token.Literal }
+func (ae *AttributeAssignExpression) String() string {
+	var out bytes.Buffer
+
+	out.WriteString(ae.Obj.String())
+	out.WriteString(ae.TokenLiteral())
+	out.WriteString(ae.Attribute.String())
+	out.WriteString(" = ")
+	if ae.Value != nil {
+		out.WriteString(ae.Value.String())
+	}
+
+	return out.String()
+}
+
 func (pe *PrefixExpression) expressionNode()      {}
 func (pe *PrefixExpression) TokenLiteral() string { return pe.Token.Literal }
 func (pe *PrefixExpression) String() string {
@@ -404,6 +677,7 @@ type CallExpression struct {
 	Token     token.Token // The '(' token
 	Function  Expression  // Identifier or FunctionLiteral
 	Arguments []Expression
+	Keywords  []*KeywordArgument
 }
 
 func (ce *CallExpression) expressionNode()      {}
@@ -415,6 +689,9 @@ func (ce *CallExpression) String() string {
 	for _, a := range ce.Arguments {
 		args = append(args, a.String())
 	}
+	for _, k := range ce.Keywords {
+		args = append(args, k.String())
+	}
 
 	out.WriteString(ce.Function.String())
 	out.WriteString("(")
@@ -424,6 +701,16 @@ func (ce *CallExpression) String() string {
 	return out.String()
 }
 
+// KeywordArgument is a name=value argument in a call
+type KeywordArgument struct {
+	Token token.Token // The IDENT token
+	Name  string
+	Value Expression
+}
+
+func (ka *KeywordArgument) TokenLiteral() string { return ka.Token.Literal }
+func (ka *KeywordArgument) String() string       { return ka.Name + "=" + ka.Value.String() }
+
 type InExpression struct {
 	Token token.Token // The IN token
 	Left  Expression
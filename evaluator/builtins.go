@@ -2,897 +2,1490 @@ package evaluator
 
 import (
 	"fmt"
+	"io"
+	"maps"
 	"math"
+	"os"
 	"simpyl/algorithms"
 	"simpyl/object"
 	"slices"
+	"sort"
 	"strings"
 	"unicode"
+	"unicode/utf8"
 )
 
-var builtins = map[string]*object.Builtin{
-	"print": {
-		Fn: func(args ...object.Object) object.Object {
-			for _, arg := range args {
-				fmt.Println(arg.Inspect())
-			}
+// builtins are only written by init, so scripts can read them in parallel.
+// Builtins with state of their own, such as print, are bound to each runtime
+var builtins map[string]*object.Builtin
+
+// BuiltinNames returns the names of the builtin functions in sorted order
+func BuiltinNames() []string {
+	names := make([]string, 0, len(builtins))
+	for name := range builtins {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
 
-			return NULL
-		},
-	},
-	"len": {
-		Fn: func(args ...object.Object) object.Object {
-			if len(args) != 1 {
-				return newError("wrong number of arguments. got=%d, want=1",
-					len(args))
-			}
+// NewRuntime returns interpreter state whose input and print builtins read from
+// stdin and write to stdout. Recursion is limited to DefaultMaxDepth calls
+func NewRuntime(stdin io.Reader, stdout, stderr io.Writer) *object.Runtime {
+	return &object.Runtime{
+		Stdin:    stdin,
+		Stdout:   stdout,
+		Stderr:   stderr,
+		MaxDepth: DefaultMaxDepth,
+		Modules:  map[string]*object.Module{},
+		Builtins: ioBuiltins(stdin, stdout),
+	}
+}
 
-			switch arg := args[0].(type) {
-			case *object.List:
-				return &object.Integer{Value: int64(len(arg.Elements))}
-			case *object.String:
-				return &object.Integer{Value: int64(len(arg.Value))}
-			default:
-				return newError("argument to `len` not supported, got %s",
-					args[0].Type())
-			}
+// ioBuiltins returns the builtins that use the standard streams. The global
+// builtins use the process streams, and each runtime gets its own copies
+func ioBuiltins(stdin io.Reader, stdout io.Writer) map[string]*object.Builtin {
+	return map[string]*object.Builtin{
+		"print": {
+			Fn: func(args ...object.Object) object.Object {
+				for _, arg := range args {
+					str, err := objectString(arg)
+					if err != nil {
+						return err
+					}
+					fmt.Fprintln(stdout, str)
+				}
+
+				return NULL
+			},
 		},
-	},
-	"range": {
-		Fn: func(args ...object.Object) object.Object {
-			if len(args) != 1 {
-				return newError("wrong number of arguments. got=%d, want=1",
-					len(args))
-			}
-			if args[0].Type() != object.INTEGER_OBJ {
-				return newError("range function takes integer type, got=%T", args[0].Type())
-			}
-			r := args[0].(*object.Integer).Value
-			elements := make([]object.Object, r)
-			for i := range r {
-				elements[i] = &object.Integer{Value: i}
-			}
+		"input": {
+			Fn: func(args ...object.Object) object.Object {
+				if err := checkArgs("input", args, 0, 1); err != nil {
+					return err
+				}
+				if len(args) == 1 {
+					prompt, err := objectString(args[0])
+					if err != nil {
+						return err
+					}
+					fmt.Fprint(stdout, prompt)
+				}
 
-			return &object.List{Elements: elements}
+				line, err := readLine(stdin)
+				if err != nil && line == "" {
+					return newError("EOFError: EOF when reading a line")
+				}
+				return &object.String{Value: line}
+			},
 		},
-	},
-	"min": {
-		Fn: func(args ...object.Object) object.Object {
-			if len(args) != 1 {
-				return newError("wrong number of arguments. got=%d, want=1",
-					len(args))
-			}
-			if args[0].Type() != object.LIST_OBJ {
-				return newError("min function takes list type, got=%T", args[0].Type())
-			}
+	}
+}
 
-			vals := args[0].(*object.List).Elements
-			if len(vals) == 0 {
-				return newError("cannot take min of empty list")
-			}
+// readLine reads a line without its line ending. It reads a byte at a time so
+// that nothing after the line is consumed from a shared input
+func readLine(r io.Reader) (string, error) {
+	var line []byte
+	buf := make([]byte, 1)
+	for {
+		n, err := r.Read(buf)
+		if n == 1 {
+			if buf[0] == '\n' {
+				break
+			}
+			line = append(line, buf[0])
+			continue
+		}
+		if err != nil {
+			return strings.TrimSuffix(string(line), "\r"), err
+		}
+	}
+	return strings.TrimSuffix(string(line), "\r"), nil
+}
 
-			m := float64(0)
-			v := float64(0)
-			floatFlag := false
+// The builtin tables are populated in init because many entries call back into
+// the evaluator, which would otherwise form an initialization cycle through Eval
+func init() {
+	builtins = map[string]*object.Builtin{
+		"len": {
+			Fn: func(args ...object.Object) object.Object {
+				if len(args) != 1 {
+					return newError("wrong number of arguments. got=%d, want=1",
+						len(args))
+				}
 
-			if vals[0].Type() == object.INTEGER_OBJ {
-				m = float64(vals[0].(*object.Integer).Value)
-			} else {
-				m = vals[0].(*object.Float).Value
-				floatFlag = true
-			}
+				switch arg := args[0].(type) {
+				case *object.Instance:
+					result, ok := callMethod(arg, "__len__")
+					if !ok {
+						return newError("object of type '%s' has no len()", arg.Class.Name)
+					}
+					if !isError(result) && result.Type() != object.INTEGER_OBJ {
+						return newError("__len__ should return an integer, returned %s", typeName(result))
+					}
+					return result
+				case *object.List:
+					return &object.Integer{Value: int64(len(arg.Elements))}
+				case *object.String:
+					return &object.Integer{Value: int64(arg.Len())}
+				case *object.Bytes:
+					return &object.Integer{Value: int64(len(arg.Value))}
+				case *object.Tuple:
+					return &object.Integer{Value: int64(len(arg.Elements))}
+				case *object.Dict:
+					return &object.Integer{Value: int64(len(arg.Pairs))}
+				case *object.Set:
+					return &object.Integer{Value: int64(len(arg.Values))}
+				default:
+					return newError("argument to `len` not supported, got %s",
+						args[0].Type())
+				}
+			},
+		},
+		// range builds a list, so ranges index and slice like lists
+		"range": {
+			Fn: func(args ...object.Object) object.Object {
+				if err := checkArgs("range", args, 1, 3); err != nil {
+					return err
+				}
+				bounds := make([]int64, len(args))
+				for i, arg := range args {
+					val, err := intArg("range", arg, 0)
+					if err != nil {
+						return err
+					}
+					bounds[i] = val
+				}
 
-			for i := range vals {
-				if vals[i].Type() == object.INTEGER_OBJ {
-					v = float64(vals[i].(*object.Integer).Value)
-				} else if vals[i].Type() == object.FLOAT_OBJ {
-					v = vals[i].(*object.Float).Value
-					floatFlag = true
-				} else {
-					return newError("min function requires Integer or Float type, got=%T", vals[i].Type())
+				start, stop, step := int64(0), bounds[0], int64(1)
+				if len(bounds) > 1 {
+					start, stop = bounds[0], bounds[1]
+				}
+				if len(bounds) > 2 {
+					step = bounds[2]
+				}
+				if step == 0 {
+					return newError("range() arg 3 must not be zero")
 				}
-				m = min(m, v)
-			}
 
-			if floatFlag {
-				return &object.Float{Value: m}
-			}
-			return &object.Integer{Value: int64(m)}
-		},
-	},
-	"max": {
-		Fn: func(args ...object.Object) object.Object {
-			if len(args) != 1 {
-				return newError("wrong number of arguments. got=%d, want=1",
-					len(args))
-			}
-			if args[0].Type() != object.LIST_OBJ {
-				return newError("max function takes list type, got=%T", args[0].Type())
-			}
-			vals := args[0].(*object.List).Elements
-			if len(vals) == 0 {
-				return newError("cannot take max of empty list")
-			}
+				elements := []object.Object{}
+				for i := start; (step > 0 && i < stop) || (step < 0 && i > stop); i += step {
+					elements = append(elements, &object.Integer{Value: i})
+				}
+				return &object.List{Elements: elements}
+			},
+		},
+		"min": {
+			Fn: func(args ...object.Object) object.Object {
+				if len(args) != 1 {
+					return newError("wrong number of arguments. got=%d, want=1",
+						len(args))
+				}
+				if args[0].Type() != object.LIST_OBJ {
+					return newError("min function takes list type, got=%T", args[0].Type())
+				}
 
-			m := float64(0)
-			v := float64(0)
-			floatFlag := false
+				vals := args[0].(*object.List).Elements
+				if len(vals) == 0 {
+					return newError("cannot take min of empty list")
+				}
 
-			if vals[0].Type() == object.INTEGER_OBJ {
-				m = float64(vals[0].(*object.Integer).Value)
-			} else {
-				m = vals[0].(*object.Float).Value
-				floatFlag = true
-			}
+				m := float64(0)
+				v := float64(0)
+				floatFlag := false
 
-			for i := range vals {
-				if vals[i].Type() == object.INTEGER_OBJ {
-					v = float64(vals[i].(*object.Integer).Value)
-				} else if vals[i].Type() == object.FLOAT_OBJ {
-					v = vals[i].(*object.Float).Value
-					floatFlag = true
+				if vals[0].Type() == object.INTEGER_OBJ {
+					m = float64(vals[0].(*object.Integer).Value)
 				} else {
-					return newError("min function requires Integer or Float type, got=%T", vals[i].Type())
+					m = vals[0].(*object.Float).Value
+					floatFlag = true
 				}
-				m = max(m, v)
-			}
-
-			if floatFlag {
-				return &object.Float{Value: m}
-			}
-			return &object.Integer{Value: int64(m)}
-		},
-	},
-	"abs": {
-		Fn: func(args ...object.Object) object.Object {
-			if len(args) != 1 {
-				return newError("wrong number of arguments. got=%d, want=1",
-					len(args))
-			}
-			if args[0].Type() == object.INTEGER_OBJ {
-				n := args[0].(*object.Integer).Value
-				y := n >> 63
 
-				return &object.Integer{Value: (n ^ y) - y}
-			}
-			if args[0].Type() == object.FLOAT_OBJ {
-				n := args[0].(*object.Float).Value
-				if n < 0 {
-					n = -n
+				for i := range vals {
+					if vals[i].Type() == object.INTEGER_OBJ {
+						v = float64(vals[i].(*object.Integer).Value)
+					} else if vals[i].Type() == object.FLOAT_OBJ {
+						v = vals[i].(*object.Float).Value
+						floatFlag = true
+					} else {
+						return newError("min function requires Integer or Float type, got=%T", vals[i].Type())
+					}
+					m = min(m, v)
 				}
 
-				return &object.Float{Value: n}
-			}
-			return newError("abs function takes Integer or Float type, got=%T", args[0].Type())
-		},
-	},
-	"sum": {
-		Fn: func(args ...object.Object) object.Object {
-			if len(args) != 1 {
-				return newError("wrong number of arguments. got=%d, want=1",
-					len(args))
-			}
-			if args[0].Type() != object.LIST_OBJ {
-				return newError("sum function takes list type, got=%T", args[0].Type())
-			}
-			vals := args[0].(*object.List).Elements
-			if len(vals) == 0 {
-				return newError("cannot take sum of empty list")
-			}
+				if floatFlag {
+					return &object.Float{Value: m}
+				}
+				return &object.Integer{Value: int64(m)}
+			},
+		},
+		"max": {
+			Fn: func(args ...object.Object) object.Object {
+				if len(args) != 1 {
+					return newError("wrong number of arguments. got=%d, want=1",
+						len(args))
+				}
+				if args[0].Type() != object.LIST_OBJ {
+					return newError("max function takes list type, got=%T", args[0].Type())
+				}
+				vals := args[0].(*object.List).Elements
+				if len(vals) == 0 {
+					return newError("cannot take max of empty list")
+				}
 
-			n := float64(0)
-			v := float64(0)
-			floatFlag := false
+				m := float64(0)
+				v := float64(0)
+				floatFlag := false
 
-			for i := range vals {
-				if vals[i].Type() == object.INTEGER_OBJ {
-					v = float64(vals[i].(*object.Integer).Value)
-				} else if vals[i].Type() == object.FLOAT_OBJ {
-					v = vals[i].(*object.Float).Value
-					floatFlag = true
+				if vals[0].Type() == object.INTEGER_OBJ {
+					m = float64(vals[0].(*object.Integer).Value)
 				} else {
-					return newError("max function requires Integer or Float type, got=%T", vals[i].Type())
+					m = vals[0].(*object.Float).Value
+					floatFlag = true
 				}
-				n += v
-			}
 
-			if floatFlag {
-				return &object.Float{Value: n}
-			}
-			return &object.Integer{Value: int64(n)}
-		},
-	},
-	"str": {
-		Fn: func(args ...object.Object) object.Object {
-			if len(args) != 1 {
-				return newError("str() takes 1 argument. got=%d, want=1",
-					len(args))
-			}
+				for i := range vals {
+					if vals[i].Type() == object.INTEGER_OBJ {
+						v = float64(vals[i].(*object.Integer).Value)
+					} else if vals[i].Type() == object.FLOAT_OBJ {
+						v = vals[i].(*object.Float).Value
+						floatFlag = true
+					} else {
+						return newError("min function requires Integer or Float type, got=%T", vals[i].Type())
+					}
+					m = max(m, v)
+				}
 
-			return &object.String{Value: args[0].Inspect()}
+				if floatFlag {
+					return &object.Float{Value: m}
+				}
+				return &object.Integer{Value: int64(m)}
+			},
 		},
-	},
-	"reversed": {
-		Fn: func(args ...object.Object) object.Object {
-			if len(args) != 1 {
-				return newError("wrong number of arguments. got=%d, want=1",
-					len(args))
-			}
-			if args[0].Type() != object.LIST_OBJ {
-				return newError("sum function takes list type, got=%T", args[0].Type())
-			}
-			vals := args[0].(*object.List).Elements
-			if len(vals) == 0 {
-				return args[0]
-			}
-			list := args[0].(*object.List).Elements
-			slices.Reverse(list)
-			return &object.List{Elements: list}
-		},
-	},
-	"round": {
-		Fn: func(args ...object.Object) object.Object {
-			if len(args) != 1 {
-				return newError("wrong number of arguments. got=%d, want=1",
-					len(args))
-			}
-			if args[0].Type() == object.INTEGER_OBJ {
-				return args[0]
-			}
-			if args[0].Type() == object.FLOAT_OBJ {
-				n := math.Round(args[0].(*object.Float).Value)
+		"abs": {
+			Fn: func(args ...object.Object) object.Object {
+				if len(args) != 1 {
+					return newError("wrong number of arguments. got=%d, want=1",
+						len(args))
+				}
+				if args[0].Type() == object.INTEGER_OBJ {
+					n := args[0].(*object.Integer).Value
+					y := n >> 63
 
-				return &object.Integer{Value: int64(n)}
-			}
-			return newError("round function takes Integer or Float type, got=%T", args[0].Type())
-		},
-	},
-	"sorted": {
-		Fn: func(args ...object.Object) object.Object {
-			if len(args) != 1 {
-				return newError("wrong number of arguments. got=%d, want=1",
-					len(args))
-			}
-			if args[0].Type() != object.LIST_OBJ {
-				return newError("sorted() takes list type, got=%T", args[0].Type())
-			}
+					return &object.Integer{Value: (n ^ y) - y}
+				}
+				if args[0].Type() == object.FLOAT_OBJ {
+					n := args[0].(*object.Float).Value
+					if n < 0 {
+						n = -n
+					}
 
-			list := algorithms.MergeSort(args[0].(*object.List).Elements)
-			return &object.List{Elements: list}
+					return &object.Float{Value: n}
+				}
+				return newError("abs function takes Integer or Float type, got=%T", args[0].Type())
+			},
 		},
-	},
-	"list": {
-		Fn: func(args ...object.Object) object.Object {
-			list := &object.List{}
+		"sum": {
+			Fn: func(args ...object.Object) object.Object {
+				if len(args) != 1 {
+					return newError("wrong number of arguments. got=%d, want=1",
+						len(args))
+				}
+				if args[0].Type() != object.LIST_OBJ {
+					return newError("sum function takes list type, got=%T", args[0].Type())
+				}
+				vals := args[0].(*object.List).Elements
+				if len(vals) == 0 {
+					return newError("cannot take sum of empty list")
+				}
 
-			if len(args) == 1 {
-				if args[0].Type() == object.SET_OBJ {
-					set := args[0].(*object.Set)
-					for _, item := range set.Values {
-						list.Elements = append(list.Elements, item)
+				n := float64(0)
+				v := float64(0)
+				floatFlag := false
+
+				for i := range vals {
+					if vals[i].Type() == object.INTEGER_OBJ {
+						v = float64(vals[i].(*object.Integer).Value)
+					} else if vals[i].Type() == object.FLOAT_OBJ {
+						v = vals[i].(*object.Float).Value
+						floatFlag = true
+					} else {
+						return newError("max function requires Integer or Float type, got=%T", vals[i].Type())
 					}
-				} else {
-					list.Elements = append(list.Elements, args...)
+					n += v
 				}
-			} else {
-				list.Elements = append(list.Elements, args...)
-			}
 
-			return list
+				if floatFlag {
+					return &object.Float{Value: n}
+				}
+				return &object.Integer{Value: int64(n)}
+			},
+		},
+		"str": {
+			Fn: func(args ...object.Object) object.Object {
+				if len(args) != 1 {
+					return newError("str() takes 1 argument. got=%d, want=1",
+						len(args))
+				}
+
+				str, err := objectString(args[0])
+				if err != nil {
+					return err
+				}
+
+				return &object.String{Value: str}
+			},
 		},
-	},
-	"dict": {
-		Fn: func(args ...object.Object) object.Object {
-			dict := &object.Dict{}
+		"repr": {
+			Fn: func(args ...object.Object) object.Object {
+				if len(args) != 1 {
+					return newError("repr() takes 1 argument. got=%d, want=1",
+						len(args))
+				}
+
+				str, err := objectRepr(args[0])
+				if err != nil {
+					return err
+				}
 
-			return dict
+				return &object.String{Value: str}
+			},
 		},
-	},
-	"set": {
-		Fn: func(args ...object.Object) object.Object {
-			set := &object.Set{Values: make(map[object.HashKey]object.Object)}
+		"ord": {
+			Fn: func(args ...object.Object) object.Object {
+				if len(args) != 1 {
+					return newError("wrong number of arguments. got=%d, want=1",
+						len(args))
+				}
 
-			for _, arg := range args {
-				hashKey, ok := arg.(object.Hashable)
+				str, ok := args[0].(*object.String)
 				if !ok {
-					return newError("argument cannot be hashed: %s", arg.Type())
+					return newError("ord() expected string of length 1, but %s found", typeName(args[0]))
 				}
-				key := hashKey.HashKey()
 
-				if _, ok := set.Values[key]; !ok {
-					set.Values[key] = arg
+				runes := []rune(str.Value)
+				if len(runes) != 1 {
+					return newError("ord() expected a character, but string of length %d found", len(runes))
 				}
-			}
 
-			return set
+				return &object.Integer{Value: int64(runes[0])}
+			},
 		},
-	},
-}
+		"chr": {
+			Fn: func(args ...object.Object) object.Object {
+				if len(args) != 1 {
+					return newError("wrong number of arguments. got=%d, want=1",
+						len(args))
+				}
 
-var listMethods = map[string]*object.BuiltinMethod{
-	"append": {
-		Fn: func(obj object.Object, args ...object.Object) object.Object {
-			if len(args) != 1 {
-				return newError("wrong number of arguments. got=%d, want=2",
-					len(args))
-			}
+				code, ok := args[0].(*object.Integer)
+				if !ok {
+					return newError("chr() argument must be INTEGER, not %s", typeName(args[0]))
+				}
+				if code.Value < 0 || code.Value > unicode.MaxRune {
+					return newError("chr() arg not in range(0x110000)")
+				}
 
-			if obj.Type() != object.LIST_OBJ {
-				return newError("list.append() must be called on list, got %s",
-					args[0].Type())
-			}
+				return &object.String{Value: string(rune(code.Value))}
+			},
+		},
+		"format": {
+			RuntimeFn: func(runtime *object.Runtime, kwargs map[string]object.Object, args ...object.Object) object.Object {
+				if len(kwargs) != 0 {
+					return newError("builtin function takes no keyword arguments")
+				}
+				if len(args) != 1 && len(args) != 2 {
+					return newError("wrong number of arguments. got=%d, want=1 or 2",
+						len(args))
+				}
+
+				spec := ""
+				if len(args) == 2 {
+					str, ok := args[1].(*object.String)
+					if !ok {
+						return newError("format() argument 2 must be str, not %s", typeName(args[1]))
+					}
+					spec = str.Value
+				}
 
-			list := obj.(*object.List)
-			list.Elements = append(list.Elements, args[0])
+				str, err := formatObject(runtime, args[0], spec)
+				if err != nil {
+					return err
+				}
 
-			return list
+				return &object.String{Value: str}
+			},
 		},
-	},
-	"reverse": {
-		Fn: func(obj object.Object, args ...object.Object) object.Object {
-			if len(args) != 0 {
-				return newError("list.reverse() takes no arguments")
-			}
+		"reversed": {
+			Fn: func(args ...object.Object) object.Object {
+				if len(args) != 1 {
+					return newError("wrong number of arguments. got=%d, want=1",
+						len(args))
+				}
+				if args[0].Type() != object.LIST_OBJ {
+					return newError("sum function takes list type, got=%T", args[0].Type())
+				}
+				vals := args[0].(*object.List).Elements
+				if len(vals) == 0 {
+					return args[0]
+				}
+				list := args[0].(*object.List).Elements
+				slices.Reverse(list)
+				return &object.List{Elements: list}
+			},
+		},
+		"round": {
+			Fn: func(args ...object.Object) object.Object {
+				if len(args) != 1 {
+					return newError("wrong number of arguments. got=%d, want=1",
+						len(args))
+				}
+				if args[0].Type() == object.INTEGER_OBJ {
+					return args[0]
+				}
+				if args[0].Type() == object.FLOAT_OBJ {
+					n := math.Round(args[0].(*object.Float).Value)
 
-			if obj.Type() != object.LIST_OBJ {
-				return newError("list.reverse() must be called on list, got %s",
-					args[0].Type())
-			}
+					return &object.Integer{Value: int64(n)}
+				}
+				return newError("round function takes Integer or Float type, got=%T", args[0].Type())
+			},
+		},
+		"sorted": {
+			KwFn: func(kwargs map[string]object.Object, args ...object.Object) object.Object {
+				if err := checkArgs("sorted", args, 1, 1); err != nil {
+					return err
+				}
+				bound, err := bindArgs("sorted", []string{"iterable", "key", "reverse"}, args, kwargs)
+				if err != nil {
+					return err
+				}
 
-			list := obj.(*object.List)
-			slices.Reverse(list.Elements)
+				elements, iterErr := iterate(bound[0])
+				if iterErr != nil {
+					return iterErr
+				}
+				key, reverse := bound[1], bound[2] != nil && isTruthy(bound[2])
+				list, sortErr := sortObjects(elements, key, reverse)
+				if sortErr != nil {
+					return sortErr
+				}
+				return &object.List{Elements: list}
+			},
+		},
+		"list": {
+			Fn: func(args ...object.Object) object.Object {
+				if len(args) > 1 {
+					return newError("list expected at most 1 argument, got %d", len(args))
+				}
+				if len(args) == 0 {
+					return &object.List{Elements: []object.Object{}}
+				}
 
-			return NULL
+				elements, err := iterate(args[0])
+				if err != nil {
+					return err
+				}
+
+				return &object.List{Elements: append([]object.Object{}, elements...)}
+			},
 		},
-	},
-	"copy": {
-		Fn: func(obj object.Object, args ...object.Object) object.Object {
-			if len(args) != 0 {
-				return newError("list.copy() takes no arguments")
-			}
+		"tuple": {
+			Fn: func(args ...object.Object) object.Object {
+				if len(args) > 1 {
+					return newError("tuple expected at most 1 argument, got %d", len(args))
+				}
+				if len(args) == 0 {
+					return &object.Tuple{Elements: []object.Object{}}
+				}
 
-			if obj.Type() != object.LIST_OBJ {
-				return newError("list.copy() must be called on list, got %s",
-					args[0].Type())
-			}
+				elements, err := iterate(args[0])
+				if err != nil {
+					return err
+				}
 
-			src := obj.(*object.List)
-			list := make([]object.Object, len(src.Elements))
-			copy(list, src.Elements)
+				return &object.Tuple{Elements: append([]object.Object{}, elements...)}
+			},
+		},
+		"slice": {
+			Fn: func(args ...object.Object) object.Object {
+				if err := checkArgs("slice", args, 1, 3); err != nil {
+					return err
+				}
 
-			return &object.List{Elements: list}
+				// A single argument is the stop, as in Python
+				bounds := []object.Object{NULL, NULL, NULL}
+				if len(args) == 1 {
+					bounds[1] = args[0]
+				} else {
+					copy(bounds, args)
+				}
+				return &object.Slice{Start: bounds[0], Stop: bounds[1], Step: bounds[2]}
+			},
+		},
+		"dict": {
+			KwFn: func(kwargs map[string]object.Object, args ...object.Object) object.Object {
+				if len(args) > 1 {
+					return newError("dict expected at most 1 argument, got %d", len(args))
+				}
+
+				dict := &object.Dict{Pairs: make(map[object.HashKey]object.HashPair)}
+				if err := updateDict(dict, args, kwargs); err != nil {
+					return err
+				}
+
+				return dict
+			},
+			Attrs: map[string]object.Object{
+				"fromkeys": &object.Builtin{Fn: dictFromkeys},
+			},
 		},
-	},
-	"pop": {
-		Fn: func(obj object.Object, args ...object.Object) object.Object {
-			ind := -1
-			if len(args) == 1 {
-				arg, ok := args[0].(*object.Integer)
+		"set":       setConstructor("set", false),
+		"frozenset": setConstructor("frozenset", true),
+		"isinstance": {
+			Fn: func(args ...object.Object) object.Object {
+				if len(args) != 2 {
+					return newError("wrong number of arguments. got=%d, want=2",
+						len(args))
+				}
+
+				classes, err := classInfo(args[1], "isinstance")
+				if err != nil {
+					return err
+				}
+
+				instance, ok := args[0].(*object.Instance)
 				if !ok {
-					return newError("index of list.pop() must be Integer type, got=%T", args[0])
+					return FALSE
+				}
+				for _, cls := range classes {
+					if instance.Class.IsSubclass(cls) {
+						return TRUE
+					}
 				}
-				ind = int(arg.Value)
-			} else if len(args) > 1 {
-				return newError("list.pop() take at most 1 index, got=%d",
-					len(args))
-			}
 
-			if obj.Type() != object.LIST_OBJ {
-				return newError("list.pop() must be called on list, got %s",
-					args[0].Type())
-			}
+				return FALSE
+			},
+		},
+		"issubclass": {
+			Fn: func(args ...object.Object) object.Object {
+				if len(args) != 2 {
+					return newError("wrong number of arguments. got=%d, want=2",
+						len(args))
+				}
 
-			list := obj.(*object.List)
-			elements := list.Elements
-			if ind < 0 {
-				ind = len(elements) + ind
-			}
-			if ind >= len(elements) {
-				return newError("index out of range of list.pop()")
-			}
+				sub, ok := args[0].(*object.Class)
+				if !ok {
+					return newError("issubclass() arg 1 must be a class, got %s", args[0].Type())
+				}
+				classes, err := classInfo(args[1], "issubclass")
+				if err != nil {
+					return err
+				}
 
-			result := elements[ind]
-			newList := make([]object.Object, len(elements)-1)
-			if len(newList) != len(elements)-1 {
-				return newError("incorrect size for return list")
-			}
-			if ind == len(elements) {
-				newList = elements[:ind]
-			} else {
-				newList = append(elements[:ind], elements[ind+1:]...)
-			}
-			list.Elements = newList
+				for _, cls := range classes {
+					if sub.IsSubclass(cls) {
+						return TRUE
+					}
+				}
 
-			return result
+				return FALSE
+			},
 		},
-	},
-	"sort": {
-		Fn: func(obj object.Object, args ...object.Object) object.Object {
-			if len(args) != 0 {
-				return newError("list.sort() takes no arguments")
-			}
+		"super": {
+			Fn: func(args ...object.Object) object.Object {
+				if len(args) != 2 {
+					return newError("super() takes a class and an instance outside of methods, got %d arguments",
+						len(args))
+				}
 
-			if obj.Type() != object.LIST_OBJ {
-				return newError("list.sort() must be called on list, got %s",
-					args[0].Type())
-			}
+				cls, ok := args[0].(*object.Class)
+				if !ok {
+					return newError("super() arg 1 must be a class, got %s", args[0].Type())
+				}
 
-			list := obj.(*object.List)
-			list.Elements = algorithms.MergeSort(list.Elements)
+				switch self := args[1].(type) {
+				case *object.Instance:
+					if !self.Class.IsSubclass(cls) {
+						return newError("super(type, obj): obj must be an instance or subtype of type")
+					}
+				case *object.Class:
+					if !self.IsSubclass(cls) {
+						return newError("super(type, obj): obj must be an instance or subtype of type")
+					}
+				default:
+					return newError("super(type, obj): obj must be an instance or subtype of type")
+				}
 
-			return NULL
+				return &object.Super{Class: cls, Self: args[1]}
+			},
 		},
-	},
+	}
+	maps.Copy(builtins, ioBuiltins(os.Stdin, os.Stdout))
 }
 
-var stringMethods = map[string]*object.BuiltinMethod{
-	"join": {
-		Fn: func(obj object.Object, args ...object.Object) object.Object {
-			if len(args) != 1 {
-				return newError("wrong number of arguments. got=%d, want=2",
-					len(args))
-			}
+var listMethods map[string]*object.BuiltinMethod
 
-			if obj.Type() != object.STRING_OBJ {
-				return newError("string.join() must be called on string, got %s",
-					obj.Type())
-			}
+func init() {
+	listMethods = map[string]*object.BuiltinMethod{
+		"append": {
+			Fn: func(obj object.Object, args ...object.Object) object.Object {
+				if len(args) != 1 {
+					return newError("wrong number of arguments. got=%d, want=2",
+						len(args))
+				}
 
-			if args[0].Type() != object.LIST_OBJ {
-				return newError("string.join() takes a list, got %s",
-					args[0].Type())
-			}
+				if obj.Type() != object.LIST_OBJ {
+					return newError("list.append() must be called on list, got %s",
+						args[0].Type())
+				}
 
-			str := obj.(*object.String).Value
-			list := args[0].(*object.List).Elements
+				list := obj.(*object.List)
+				list.Elements = append(list.Elements, args[0])
 
-			if len(list) == 0 {
-				return newError("cannot join empty list")
-			}
+				return list
+			},
+		},
+		"reverse": {
+			Fn: func(obj object.Object, args ...object.Object) object.Object {
+				if len(args) != 0 {
+					return newError("list.reverse() takes no arguments")
+				}
 
-			result := list[0].Inspect()
-			if len(list) == 1 {
-				return &object.String{Value: result}
-			}
+				if obj.Type() != object.LIST_OBJ {
+					return newError("list.reverse() must be called on list, got %s",
+						args[0].Type())
+				}
 
-			for _, el := range list[1:] {
-				result += str
-				result += el.Inspect()
-			}
+				list := obj.(*object.List)
+				slices.Reverse(list.Elements)
 
-			return &object.String{Value: result}
+				return NULL
+			},
 		},
-	},
-	"upper": {
-		Fn: func(obj object.Object, args ...object.Object) object.Object {
-			if len(args) != 0 {
-				return newError("string.upper() takes no arguments")
-			}
+		"copy": {
+			Fn: func(obj object.Object, args ...object.Object) object.Object {
+				if len(args) != 0 {
+					return newError("list.copy() takes no arguments")
+				}
 
-			if obj.Type() != object.STRING_OBJ {
-				return newError("string.upper() must be called on string, got %s",
-					obj.Type())
-			}
+				if obj.Type() != object.LIST_OBJ {
+					return newError("list.copy() must be called on list, got %s",
+						args[0].Type())
+				}
 
-			str := obj.(*object.String).Value
-			str = strings.ToUpper(str)
+				src := obj.(*object.List)
+				list := make([]object.Object, len(src.Elements))
+				copy(list, src.Elements)
 
-			return &object.String{Value: str}
+				return &object.List{Elements: list}
+			},
 		},
-	},
-	"lower": {
-		Fn: func(obj object.Object, args ...object.Object) object.Object {
-			if len(args) != 0 {
-				return newError("string.lower() takes no arguments")
-			}
+		"pop": {
+			Fn: func(obj object.Object, args ...object.Object) object.Object {
+				ind := -1
+				if len(args) == 1 {
+					arg, ok := args[0].(*object.Integer)
+					if !ok {
+						return newError("index of list.pop() must be Integer type, got=%T", args[0])
+					}
+					ind = int(arg.Value)
+				} else if len(args) > 1 {
+					return newError("list.pop() take at most 1 index, got=%d",
+						len(args))
+				}
 
-			if obj.Type() != object.STRING_OBJ {
-				return newError("string.lower() must be called on string, got %s",
-					obj.Type())
-			}
+				if obj.Type() != object.LIST_OBJ {
+					return newError("list.pop() must be called on list, got %s",
+						obj.Type())
+				}
 
-			str := obj.(*object.String).Value
-			str = strings.ToLower(str)
+				list := obj.(*object.List)
+				elements := list.Elements
+				if len(elements) == 0 {
+					return newError("pop from empty list")
+				}
+				if ind < 0 {
+					ind = len(elements) + ind
+				}
+				if ind < 0 || ind >= len(elements) {
+					return newError("index out of range of list.pop()")
+				}
 
-			return &object.String{Value: str}
+				result := elements[ind]
+				newList := make([]object.Object, len(elements)-1)
+				if len(newList) != len(elements)-1 {
+					return newError("incorrect size for return list")
+				}
+				if ind == len(elements) {
+					newList = elements[:ind]
+				} else {
+					newList = append(elements[:ind], elements[ind+1:]...)
+				}
+				list.Elements = newList
+
+				return result
+			},
 		},
-	},
-	"isupper": {
-		Fn: func(obj object.Object, args ...object.Object) object.Object {
-			if len(args) != 0 {
-				return newError("string.isupper() takes no arguments")
-			}
+		"sort": {
+			KwFn: func(obj object.Object, kwargs map[string]object.Object, args ...object.Object) object.Object {
+				if len(args) != 0 {
+					return newError("list.sort() takes no positional arguments")
+				}
+				bound, err := bindArgs("sort", []string{"key", "reverse"}, args, kwargs)
+				if err != nil {
+					return err
+				}
 
-			if obj.Type() != object.STRING_OBJ {
-				return newError("string.isupper() must be called on string, got %s",
-					obj.Type())
-			}
+				list := obj.(*object.List)
+				key, reverse := bound[0], bound[1] != nil && isTruthy(bound[1])
+				elements, sortErr := sortObjects(list.Elements, key, reverse)
+				if sortErr != nil {
+					return sortErr
+				}
+				list.Elements = elements
 
-			str := obj.(*object.String).Value
-			for _, r := range str {
-				if !unicode.IsUpper(r) && unicode.IsLetter(r) {
-					return FALSE
+				return NULL
+			},
+		},
+		"insert": {
+			Fn: func(obj object.Object, args ...object.Object) object.Object {
+				if err := checkArgs("insert", args, 2, 2); err != nil {
+					return err
+				}
+				idx, ok := args[0].(*object.Integer)
+				if !ok {
+					return newError("list.insert() index must be an integer, not %s", typeName(args[0]))
 				}
-			}
 
-			return TRUE
+				// Out of range positions insert at the nearest end, as in Python
+				list := obj.(*object.List)
+				at := clampSliceIndex(idx.Value, int64(len(list.Elements)))
+				list.Elements = slices.Insert(list.Elements, int(at), args[1])
+
+				return NULL
+			},
 		},
-	},
-	"islower": {
-		Fn: func(obj object.Object, args ...object.Object) object.Object {
-			if len(args) != 0 {
-				return newError("string.islower() takes no arguments")
-			}
+		"extend": {
+			Fn: func(obj object.Object, args ...object.Object) object.Object {
+				if err := checkArgs("extend", args, 1, 1); err != nil {
+					return err
+				}
+				elements, err := iterate(args[0])
+				if err != nil {
+					return newError("'%s' object is not iterable", typeName(args[0]))
+				}
 
-			if obj.Type() != object.STRING_OBJ {
-				return newError("string.islower() must be called on string, got %s",
-					obj.Type())
-			}
+				list := obj.(*object.List)
+				list.Elements = slices.Concat(list.Elements, elements)
 
-			str := obj.(*object.String).Value
-			for _, r := range str {
-				if !unicode.IsLower(r) && unicode.IsLetter(r) {
-					return FALSE
+				return NULL
+			},
+		},
+		"index": {
+			Fn: func(obj object.Object, args ...object.Object) object.Object {
+				if err := checkArgs("index", args, 1, 3); err != nil {
+					return err
 				}
-			}
 
-			return TRUE
+				list := obj.(*object.List)
+				bounds := []object.Object{NULL, NULL}
+				copy(bounds, args[1:])
+
+				slice := &object.Slice{Start: bounds[0], Stop: bounds[1], Step: NULL}
+				indices, err := sliceIndices(slice, int64(len(list.Elements)))
+				if err != nil {
+					return err
+				}
+				for _, i := range indices {
+					equal, err := valuesEqual(list.Elements[i], args[0])
+					if err != nil {
+						return err
+					}
+					if equal {
+						return &object.Integer{Value: i}
+					}
+				}
+
+				repr, reprErr := objectRepr(args[0])
+				if reprErr != nil {
+					return reprErr
+				}
+				return newError("%s is not in list", repr)
+			},
 		},
-	},
-	"swapcase": {
-		Fn: func(obj object.Object, args ...object.Object) object.Object {
-			if len(args) != 0 {
-				return newError("string.swapcase() takes no arguments")
-			}
+		"count": {
+			Fn: func(obj object.Object, args ...object.Object) object.Object {
+				if err := checkArgs("count", args, 1, 1); err != nil {
+					return err
+				}
 
-			if obj.Type() != object.STRING_OBJ {
-				return newError("string.swapcase() must be called on string, got %s",
-					obj.Type())
-			}
+				count := int64(0)
+				for _, el := range obj.(*object.List).Elements {
+					equal, err := valuesEqual(el, args[0])
+					if err != nil {
+						return err
+					}
+					if equal {
+						count++
+					}
+				}
 
-			str := obj.(*object.String).Value
-			str = strings.Map(func(r rune) rune {
-				switch {
-				case unicode.IsLower(r):
-					return unicode.ToUpper(r)
-				case unicode.IsUpper(r):
-					return unicode.ToLower(r)
+				return &object.Integer{Value: count}
+			},
+		},
+		"remove": {
+			Fn: func(obj object.Object, args ...object.Object) object.Object {
+				if err := checkArgs("remove", args, 1, 1); err != nil {
+					return err
 				}
-				return r
-			}, str)
 
-			return &object.String{Value: str}
+				list := obj.(*object.List)
+				for i, el := range list.Elements {
+					equal, err := valuesEqual(el, args[0])
+					if err != nil {
+						return err
+					}
+					if equal {
+						list.Elements = slices.Delete(list.Elements, i, i+1)
+						return NULL
+					}
+				}
+
+				return newError("list.remove(x): x not in list")
+			},
+		},
+		"clear": {
+			Fn: func(obj object.Object, args ...object.Object) object.Object {
+				if err := checkArgs("clear", args, 0, 0); err != nil {
+					return err
+				}
+
+				obj.(*object.List).Elements = []object.Object{}
+
+				return NULL
+			},
 		},
-	},
+	}
 }
 
-var dictMethods = map[string]*object.BuiltinMethod{
-	"keys": {
-		Fn: func(obj object.Object, args ...object.Object) object.Object {
-			if len(args) != 0 {
-				return newError("dict.keys() takes no arguments")
-			}
+var stringMethods map[string]*object.BuiltinMethod
+
+func init() {
+	stringMethods = map[string]*object.BuiltinMethod{
+		"split":      {KwFn: stringSplit},
+		"rsplit":     {KwFn: stringRsplit},
+		"splitlines": {KwFn: stringSplitlines},
+		"partition":  {Fn: stringPartition},
+		"rpartition": {Fn: stringRpartition},
+		"strip":      stripMethod("strip", true, true),
+		"lstrip":     stripMethod("lstrip", true, false),
+		"rstrip":     stripMethod("rstrip", false, true),
+		"replace":    {Fn: stringReplace},
+		"find":       findMethod("find", false, false),
+		"rfind":      findMethod("rfind", true, false),
+		"index":      findMethod("index", false, true),
+		"rindex":     findMethod("rindex", true, true),
+		"count":      {Fn: stringCount},
+		"startswith": affixMethod("startswith", strings.HasPrefix),
+		"endswith":   affixMethod("endswith", strings.HasSuffix),
+		"center":     justifyMethod("center", '^'),
+		"ljust":      justifyMethod("ljust", '<'),
+		"rjust":      justifyMethod("rjust", '>'),
+		"zfill":      {Fn: stringZfill},
+		"title":      {Fn: stringTitle},
+		"capitalize": {Fn: stringCapitalize},
+		"istitle":    {Fn: stringIstitle},
+		"isdigit":    predicateMethod("isdigit", isDigit),
+		"isdecimal":  predicateMethod("isdecimal", isDecimal),
+		"isnumeric":  predicateMethod("isnumeric", unicode.IsNumber),
+		"isalpha":    predicateMethod("isalpha", unicode.IsLetter),
+		"isalnum":    predicateMethod("isalnum", isAlnum),
+		"isspace":    predicateMethod("isspace", isSpace),
+		"isascii":    {Fn: stringIsascii},
+		"encode":     {KwFn: stringEncode},
+		"format": {
+			RuntimeFn: func(runtime *object.Runtime, obj object.Object, kwargs map[string]object.Object, args ...object.Object) object.Object {
+				return formatFields(runtime, obj.(*object.String).Value, args, kwargs)
+			},
+		},
+		"join": {
+			Fn: func(obj object.Object, args ...object.Object) object.Object {
+				if len(args) != 1 {
+					return newError("wrong number of arguments. got=%d, want=2",
+						len(args))
+				}
 
-			if obj.Type() != object.DICT_OBJ {
-				return newError("dict.keys() must be called on dict, got %s",
-					args[0].Type())
-			}
+				if obj.Type() != object.STRING_OBJ {
+					return newError("string.join() must be called on string, got %s",
+						obj.Type())
+				}
 
-			keys := &object.List{}
+				if args[0].Type() != object.LIST_OBJ {
+					return newError("string.join() takes a list, got %s",
+						args[0].Type())
+				}
 
-			dict := obj.(*object.Dict)
-			for _, p := range dict.Pairs {
-				keys.Elements = append(keys.Elements, p.Key)
-			}
+				str := obj.(*object.String).Value
+				list := args[0].(*object.List).Elements
+
+				if len(list) == 0 {
+					return newError("cannot join empty list")
+				}
 
-			return keys
+				result := list[0].Inspect()
+				if len(list) == 1 {
+					return &object.String{Value: result}
+				}
+
+				for _, el := range list[1:] {
+					result += str
+					result += el.Inspect()
+				}
+
+				return &object.String{Value: result}
+			},
 		},
-	},
-	"values": {
-		Fn: func(obj object.Object, args ...object.Object) object.Object {
-			if len(args) != 0 {
-				return newError("dict.values() takes no arguments")
-			}
+		"upper": {
+			Fn: func(obj object.Object, args ...object.Object) object.Object {
+				if len(args) != 0 {
+					return newError("string.upper() takes no arguments")
+				}
 
-			if obj.Type() != object.DICT_OBJ {
-				return newError("dict.values() must be called on dict, got %s",
-					args[0].Type())
-			}
+				if obj.Type() != object.STRING_OBJ {
+					return newError("string.upper() must be called on string, got %s",
+						obj.Type())
+				}
 
-			values := &object.List{}
+				str := obj.(*object.String).Value
+				str = strings.ToUpper(str)
 
-			dict := obj.(*object.Dict)
-			for _, p := range dict.Pairs {
-				values.Elements = append(values.Elements, p.Value)
-			}
+				return &object.String{Value: str}
+			},
+		},
+		"lower": {
+			Fn: func(obj object.Object, args ...object.Object) object.Object {
+				if len(args) != 0 {
+					return newError("string.lower() takes no arguments")
+				}
+
+				if obj.Type() != object.STRING_OBJ {
+					return newError("string.lower() must be called on string, got %s",
+						obj.Type())
+				}
+
+				str := obj.(*object.String).Value
+				str = strings.ToLower(str)
 
-			return values
+				return &object.String{Value: str}
+			},
 		},
-	},
-	"items": {
-		Fn: func(obj object.Object, args ...object.Object) object.Object {
-			if len(args) != 0 {
-				return newError("dict.items() takes no arguments")
-			}
+		"isupper": {
+			Fn: func(obj object.Object, args ...object.Object) object.Object {
+				if len(args) != 0 {
+					return newError("string.isupper() takes no arguments")
+				}
 
-			if obj.Type() != object.DICT_OBJ {
-				return newError("dict.items() must be called on dict, got %s",
-					args[0].Type())
-			}
+				if obj.Type() != object.STRING_OBJ {
+					return newError("string.isupper() must be called on string, got %s",
+						obj.Type())
+				}
+
+				str := obj.(*object.String).Value
+				for _, r := range str {
+					if !unicode.IsUpper(r) && unicode.IsLetter(r) {
+						return FALSE
+					}
+				}
 
-			items := &object.List{}
+				return TRUE
+			},
+		},
+		"islower": {
+			Fn: func(obj object.Object, args ...object.Object) object.Object {
+				if len(args) != 0 {
+					return newError("string.islower() takes no arguments")
+				}
 
-			dict := obj.(*object.Dict)
-			for _, p := range dict.Pairs {
-				elements := make([]object.Object, 2)
-				elements[0] = p.Key
-				elements[1] = p.Value
-				item := &object.List{Elements: elements}
-				items.Elements = append(items.Elements, item)
-			}
+				if obj.Type() != object.STRING_OBJ {
+					return newError("string.islower() must be called on string, got %s",
+						obj.Type())
+				}
 
-			return items
+				str := obj.(*object.String).Value
+				for _, r := range str {
+					if !unicode.IsLower(r) && unicode.IsLetter(r) {
+						return FALSE
+					}
+				}
+
+				return TRUE
+			},
 		},
-	},
-	"pop": {
-		Fn: func(obj object.Object, args ...object.Object) object.Object {
-			if len(args) != 1 {
-				return newError("dict.pop() requires key as argument")
-			}
+		"swapcase": {
+			Fn: func(obj object.Object, args ...object.Object) object.Object {
+				if len(args) != 0 {
+					return newError("string.swapcase() takes no arguments")
+				}
 
-			if obj.Type() != object.DICT_OBJ {
-				return newError("dict.pop() must be called on list, got %s",
-					args[0].Type())
-			}
+				if obj.Type() != object.STRING_OBJ {
+					return newError("string.swapcase() must be called on string, got %s",
+						obj.Type())
+				}
 
-			dict := obj.(*object.Dict)
+				str := obj.(*object.String).Value
+				str = strings.Map(func(r rune) rune {
+					switch {
+					case unicode.IsLower(r):
+						return unicode.ToUpper(r)
+					case unicode.IsUpper(r):
+						return unicode.ToLower(r)
+					}
+					return r
+				}, str)
 
-			hashKey, ok := args[0].(object.Hashable)
-			if !ok {
-				return newError("unusable as hash key: %s", args[0].Type())
-			}
+				return &object.String{Value: str}
+			},
+		},
+	}
+}
 
-			result, ok := dict.Pairs[hashKey.HashKey()]
-			if !ok {
-				return newError("%s not found in dict", args[0].Inspect())
-			}
+var bytesMethods map[string]*object.BuiltinMethod
+
+func init() {
+	bytesMethods = map[string]*object.BuiltinMethod{
+		"decode": {
+			Fn: func(obj object.Object, args ...object.Object) object.Object {
+				if len(args) > 1 {
+					return newError("wrong number of arguments. got=%d, want=0 or 1",
+						len(args))
+				}
 
-			delete(dict.Pairs, hashKey.HashKey())
+				if len(args) == 1 {
+					encoding, ok := args[0].(*object.String)
+					if !ok {
+						return newError("bytes.decode() argument must be str, not %s",
+							args[0].Type())
+					}
+					switch strings.ToLower(encoding.Value) {
+					case "utf-8", "utf8":
+					default:
+						return newError("unknown encoding: %s", encoding.Value)
+					}
+				}
+
+				value := obj.(*object.Bytes).Value
+				for i := 0; i < len(value); {
+					r, size := utf8.DecodeRune(value[i:])
+					if r == utf8.RuneError && size == 1 {
+						return newError("'utf-8' codec can't decode byte 0x%02x in position %d",
+							value[i], i)
+					}
+					i += size
+				}
 
-			return result.Value
+				return &object.String{Value: string(value)}
+			},
 		},
-	},
+	}
 }
 
-var setMethods = map[string]*object.BuiltinMethod{
-	"add": {
-		Fn: func(obj object.Object, args ...object.Object) object.Object {
-			if len(args) != 1 {
-				return newError("set.add() takes 1 argument, got=%d", len(args))
-			}
+var dictMethods map[string]*object.BuiltinMethod
 
-			if obj.Type() != object.SET_OBJ {
-				return newError("set.add() must be called on set, got %s",
-					args[0].Type())
-			}
-			set := obj.(*object.Set)
+// frozenDictMethods holds the dict methods that do not modify the dict
+var frozenDictMethods map[string]*object.BuiltinMethod
 
-			hashKey, ok := args[0].(object.Hashable)
-			if !ok {
-				return newError("argument cannot be hashed: %s", args[0].Type())
-			}
-			key := hashKey.HashKey()
+func init() {
+	dictMethods = map[string]*object.BuiltinMethod{
+		"keys": {
+			Fn: func(obj object.Object, args ...object.Object) object.Object {
+				if len(args) != 0 {
+					return newError("dict.keys() takes no arguments")
+				}
 
-			if _, ok := set.Values[key]; ok {
-				return NULL
-			}
+				if obj.Type() != object.DICT_OBJ {
+					return newError("dict.keys() must be called on dict, got %s",
+						args[0].Type())
+				}
 
-			set.Values[key] = args[0]
+				keys := &object.List{}
 
-			return NULL
-		},
-	},
-	"remove": {
-		Fn: func(obj object.Object, args ...object.Object) object.Object {
-			if len(args) != 1 {
-				return newError("set.remove() takes 1 argument, got=%d", len(args))
-			}
+				dict := obj.(*object.Dict)
+				for _, p := range dict.Items() {
+					keys.Elements = append(keys.Elements, p.Key)
+				}
 
-			if obj.Type() != object.SET_OBJ {
-				return newError("set.remove() must be called on set, got %s",
-					args[0].Type())
-			}
-			set := obj.(*object.Set)
+				return keys
+			},
+		},
+		"values": {
+			Fn: func(obj object.Object, args ...object.Object) object.Object {
+				if len(args) != 0 {
+					return newError("dict.values() takes no arguments")
+				}
 
-			hashKey, ok := args[0].(object.Hashable)
-			if !ok {
-				return newError("argument cannot be hashed: %s", args[0].Type())
-			}
-			key := hashKey.HashKey()
+				if obj.Type() != object.DICT_OBJ {
+					return newError("dict.values() must be called on dict, got %s",
+						args[0].Type())
+				}
 
-			_, ok = set.Values[key]
-			if !ok {
-				return newError("%s not found in set", args[0].Inspect())
-			}
+				values := &object.List{}
 
-			delete(set.Values, key)
+				dict := obj.(*object.Dict)
+				for _, p := range dict.Items() {
+					values.Elements = append(values.Elements, p.Value)
+				}
 
-			return NULL
+				return values
+			},
 		},
-	},
-	"discard": {
-		Fn: func(obj object.Object, args ...object.Object) object.Object {
-			if len(args) != 1 {
-				return newError("set.discard() takes 1 argument, got=%d", len(args))
-			}
+		"items": {
+			Fn: func(obj object.Object, args ...object.Object) object.Object {
+				if len(args) != 0 {
+					return newError("dict.items() takes no arguments")
+				}
 
-			if obj.Type() != object.SET_OBJ {
-				return newError("set.discard() must be called on set, got %s",
-					args[0].Type())
-			}
-			set := obj.(*object.Set)
+				if obj.Type() != object.DICT_OBJ {
+					return newError("dict.items() must be called on dict, got %s",
+						args[0].Type())
+				}
 
-			hashKey, ok := args[0].(object.Hashable)
-			if !ok {
-				return newError("argument cannot be hashed: %s", args[0].Type())
-			}
-			key := hashKey.HashKey()
+				items := &object.List{}
 
-			delete(set.Values, key)
+				dict := obj.(*object.Dict)
+				for _, p := range dict.Items() {
+					elements := make([]object.Object, 2)
+					elements[0] = p.Key
+					elements[1] = p.Value
+					item := &object.List{Elements: elements}
+					items.Elements = append(items.Elements, item)
+				}
 
-			return NULL
+				return items
+			},
 		},
-	},
-	"pop": {
-		Fn: func(obj object.Object, args ...object.Object) object.Object {
-			if len(args) != 1 {
-				return newError("set.pop() takes 1 argument, got=%d", len(args))
-			}
+		"pop": {
+			Fn: func(obj object.Object, args ...object.Object) object.Object {
+				if len(args) != 1 && len(args) != 2 {
+					return newError("dict.pop() requires key as argument")
+				}
 
-			if obj.Type() != object.SET_OBJ {
-				return newError("set.pop() must be called on set, got %s",
-					args[0].Type())
-			}
-			set := obj.(*object.Set)
+				if obj.Type() != object.DICT_OBJ {
+					return newError("dict.pop() must be called on list, got %s",
+						args[0].Type())
+				}
 
-			hashKey, ok := args[0].(object.Hashable)
-			if !ok {
-				return newError("argument cannot be hashed: %s", args[0].Type())
-			}
-			key := hashKey.HashKey()
+				dict := obj.(*object.Dict)
 
-			result, ok := set.Values[key]
-			if !ok {
-				return newError("%s not found in set", args[0].Inspect())
-			}
+				key, ok, err := dictKey(dict, args[0])
+				if err != nil {
+					return err
+				}
 
-			delete(set.Values, key)
+				result := dict.Pairs[key]
+				if !ok && len(args) == 2 {
+					return args[1]
+				}
+				if !ok {
+					return keyError(args[0])
+				}
 
-			return result
-		},
-	},
-	"intersection": {
-		Fn: func(obj object.Object, args ...object.Object) object.Object {
-			if len(args) != 1 {
-				return newError("set.intersection() takes 1 argument, got=%d", len(args))
-			}
-			if args[0].Type() != object.SET_OBJ {
-				return newError("set.intersection() takes set as argument, got %s",
-					args[0].Type())
-			}
+				removeKey(dict.Pairs, key)
 
-			if obj.Type() != object.SET_OBJ {
-				return newError("set.intersection() must be called on set, got %s",
-					obj.Type())
-			}
-			a := obj.(*object.Set)
-			b := args[0].(*object.Set)
-			result := &object.Set{Values: make(map[object.HashKey]object.Object)}
+				return result.Value
+			},
+		},
+		"get": {
+			Fn: func(obj object.Object, args ...object.Object) object.Object {
+				if err := checkArgs("get", args, 1, 2); err != nil {
+					return err
+				}
 
-			for hash, val := range a.Values {
-				cpy, ok := b.Values[hash]
+				dict := obj.(*object.Dict)
+				key, ok, err := dictKey(dict, args[0])
+				if err != nil {
+					return err
+				}
 				if ok {
-					if cpy.Inspect() == val.Inspect() {
-						if _, ok := result.Values[hash]; !ok {
-							result.Values[hash] = val
-						}
-					}
+					return dict.Pairs[key].Value
+				}
+				if len(args) == 2 {
+					return args[1]
+				}
+
+				return NULL
+			},
+		},
+		"setdefault": {
+			Fn: func(obj object.Object, args ...object.Object) object.Object {
+				if err := checkArgs("setdefault", args, 1, 2); err != nil {
+					return err
+				}
+
+				dict := obj.(*object.Dict)
+				key, ok, err := dictKey(dict, args[0])
+				if err != nil {
+					return err
 				}
-			}
-			for hash, val := range b.Values {
-				cpy, ok := a.Values[hash]
 				if ok {
-					if cpy.Inspect() == val.Inspect() {
-						if _, ok := result.Values[hash]; !ok {
-							result.Values[hash] = val
-						}
-					}
+					return dict.Pairs[key].Value
 				}
-			}
 
-			return result
+				var value object.Object = NULL
+				if len(args) == 2 {
+					value = args[1]
+				}
+				dict.Set(key, args[0], value)
+
+				return value
+			},
 		},
-	},
-	"union": {
-		Fn: func(obj object.Object, args ...object.Object) object.Object {
-			if len(args) != 1 {
-				return newError("set.union() takes 1 argument, got=%d", len(args))
-			}
-			if args[0].Type() != object.SET_OBJ {
-				return newError("set.union() takes set as argument, got %s",
-					args[0].Type())
-			}
+		"update": {
+			KwFn: func(obj object.Object, kwargs map[string]object.Object, args ...object.Object) object.Object {
+				if len(args) > 1 {
+					return newError("update expected at most 1 argument, got %d", len(args))
+				}
 
-			if obj.Type() != object.SET_OBJ {
-				return newError("set.union() must be called on set, got %s",
-					obj.Type())
-			}
-			a := obj.(*object.Set)
-			b := args[0].(*object.Set)
-			result := &object.Set{Values: make(map[object.HashKey]object.Object)}
+				if err := updateDict(obj.(*object.Dict), args, kwargs); err != nil {
+					return err
+				}
 
-			for hash, val := range a.Values {
-				if _, ok := result.Values[hash]; !ok {
-					result.Values[hash] = val
+				return NULL
+			},
+		},
+		"popitem": {
+			Fn: func(obj object.Object, args ...object.Object) object.Object {
+				if err := checkArgs("popitem", args, 0, 0); err != nil {
+					return err
 				}
-			}
-			for hash, val := range b.Values {
-				if _, ok := result.Values[hash]; !ok {
-					result.Values[hash] = val
+
+				// As in Python, the pair removed is the last one inserted
+				dict := obj.(*object.Dict)
+				keys := dict.Keys()
+				if len(keys) == 0 {
+					return newError("KeyError: 'popitem(): dictionary is empty'")
+				}
+				key := keys[len(keys)-1]
+				pair := dict.Pairs[key]
+				removeKey(dict.Pairs, key)
+				return &object.Tuple{Elements: []object.Object{pair.Key, pair.Value}}
+			},
+		},
+		"clear": {
+			Fn: func(obj object.Object, args ...object.Object) object.Object {
+				if err := checkArgs("clear", args, 0, 0); err != nil {
+					return err
+				}
+
+				obj.(*object.Dict).Pairs = make(map[object.HashKey]object.HashPair)
+
+				return NULL
+			},
+		},
+		"copy": {
+			Fn: func(obj object.Object, args ...object.Object) object.Object {
+				if err := checkArgs("copy", args, 0, 0); err != nil {
+					return err
 				}
-			}
 
-			return result
+				return obj.(*object.Dict).Copy()
+			},
 		},
-	},
-	"difference": {
-		Fn: func(obj object.Object, args ...object.Object) object.Object {
-			if len(args) != 1 {
-				return newError("set.difference() takes 1 argument, got=%d", len(args))
+		"fromkeys": {
+			Fn: func(obj object.Object, args ...object.Object) object.Object {
+				return dictFromkeys(args...)
+			},
+		},
+	}
+
+	frozenDictMethods = maps.Clone(dictMethods)
+	for _, name := range []string{"pop", "popitem", "setdefault", "update", "clear"} {
+		delete(frozenDictMethods, name)
+	}
+}
+
+// updateDict adds the pairs of a mapping or of an iterable of key, value pairs,
+// followed by any keyword arguments, as dict() and dict.update() do
+func updateDict(dict *object.Dict, args []object.Object, kwargs map[string]object.Object) object.Object {
+	if len(args) == 1 {
+		if other, ok := args[0].(*object.Dict); ok {
+			for _, pair := range other.Items() {
+				if err := SetItem(dict, pair.Key, pair.Value); err != nil {
+					return err
+				}
 			}
-			if args[0].Type() != object.SET_OBJ {
-				return newError("set.difference() takes set as argument, got %s",
-					args[0].Type())
+		} else {
+			items, err := iterate(args[0])
+			if err != nil {
+				return newError("'%s' object is not iterable", typeName(args[0]))
 			}
 
-			if obj.Type() != object.SET_OBJ {
-				return newError("set.difference() must be called on set, got %s",
-					obj.Type())
-			}
-			a := obj.(*object.Set)
-			b := args[0].(*object.Set)
-			result := &object.Set{Values: make(map[object.HashKey]object.Object)}
+			for i, item := range items {
+				pair, err := iterate(item)
+				if err != nil {
+					return newError("cannot convert dictionary update sequence element #%d to a sequence", i)
+				}
+				if len(pair) != 2 {
+					return newError("dictionary update sequence element #%d has length %d; 2 is required",
+						i, len(pair))
+				}
 
-			for hash, val := range a.Values {
-				cpy, ok := b.Values[hash]
-				if ok {
-					if cpy.Inspect() != val.Inspect() {
-						if _, ok := result.Values[hash]; !ok {
-							result.Values[hash] = val
-						}
-					}
-				} else {
-					if _, ok := result.Values[hash]; !ok {
-						result.Values[hash] = val
-					}
+				if err := SetItem(dict, pair[0], pair[1]); err != nil {
+					return err
 				}
 			}
-			for hash, val := range b.Values {
-				cpy, ok := a.Values[hash]
-				if ok {
-					if cpy.Inspect() != val.Inspect() {
-						if _, ok := result.Values[hash]; !ok {
-							result.Values[hash] = val
-						}
-					}
-				} else {
-					if _, ok := result.Values[hash]; !ok {
-						result.Values[hash] = val
-					}
+		}
+	}
+
+	for name, value := range kwargs {
+		if err := SetItem(dict, &object.String{Value: name}, value); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// dictFromkeys implements dict.fromkeys(iterable[, value])
+func dictFromkeys(args ...object.Object) object.Object {
+	if err := checkArgs("fromkeys", args, 1, 2); err != nil {
+		return err
+	}
+
+	keys, err := iterate(args[0])
+	if err != nil {
+		return newError("'%s' object is not iterable", typeName(args[0]))
+	}
+	var value object.Object = NULL
+	if len(args) == 2 {
+		value = args[1]
+	}
+
+	dict := &object.Dict{Pairs: make(map[object.HashKey]object.HashPair)}
+	for _, key := range keys {
+		if err := SetItem(dict, key, value); err != nil {
+			return err
+		}
+	}
+
+	return dict
+}
+
+var setMethods map[string]*object.BuiltinMethod
+
+// frozensetMethods holds the set methods that do not modify the set
+var frozensetMethods map[string]*object.BuiltinMethod
+
+func init() {
+	frozensetMethods = map[string]*object.BuiltinMethod{
+		"copy":                 {Fn: setCopy},
+		"union":                setOperationMethod("|"),
+		"intersection":         setOperationMethod("&"),
+		"difference":           setOperationMethod("-"),
+		"symmetric_difference": {Fn: setSymmetricDifference},
+		"issubset":             setRelationMethod("issubset", isSubset),
+		"issuperset": setRelationMethod("issuperset", func(a, b *object.Set) (bool, object.Object) {
+			return isSubset(b, a)
+		}),
+		"isdisjoint": setRelationMethod("isdisjoint", func(a, b *object.Set) (bool, object.Object) {
+			common, err := combineSets("&", a, b)
+			if err != nil {
+				return false, err
+			}
+			return len(common.Values) == 0, nil
+		}),
+	}
+
+	setMethods = map[string]*object.BuiltinMethod{
+		"add":                         {Fn: setAdd},
+		"remove":                      setRemove("remove", true),
+		"discard":                     setRemove("discard", false),
+		"pop":                         {Fn: setPop},
+		"clear":                       {Fn: setClear},
+		"update":                      setUpdateMethod("update", "|", 0, math.MaxInt),
+		"intersection_update":         setUpdateMethod("intersection_update", "&", 0, math.MaxInt),
+		"difference_update":           setUpdateMethod("difference_update", "-", 0, math.MaxInt),
+		"symmetric_difference_update": setUpdateMethod("symmetric_difference_update", "^", 1, 1),
+	}
+	maps.Copy(setMethods, frozensetMethods)
+}
+
+var sliceMethods map[string]*object.BuiltinMethod
+
+func init() {
+	sliceMethods = map[string]*object.BuiltinMethod{
+		"indices": {
+			Fn: func(obj object.Object, args ...object.Object) object.Object {
+				if err := checkArgs("indices", args, 1, 1); err != nil {
+					return err
+				}
+				length, err := intArg("indices", args[0], 0)
+				if err != nil {
+					return err
+				}
+				if length < 0 {
+					return newError("length should not be negative")
 				}
-			}
 
-			return result
+				start, stop, step, rerr := resolveSlice(obj.(*object.Slice), length)
+				if rerr != nil {
+					return rerr
+				}
+				return &object.Tuple{Elements: []object.Object{
+					&object.Integer{Value: start},
+					&object.Integer{Value: stop},
+					&object.Integer{Value: step},
+				}}
+			},
 		},
-	},
+	}
+}
+
+// sortObjects implements sorted and list.sort. Elements are ordered by their key
+// when a key function is given, with each key computed once. The sort is stable,
+// and equal elements keep their relative order even when reversed
+func sortObjects(elements []object.Object, key object.Object, reverse bool) ([]object.Object, object.Object) {
+	// Pair each element with its key so the sort moves both together
+	decorated := make([]object.Object, len(elements))
+	for i, el := range elements {
+		k := el
+		if key != nil && key != NULL {
+			k = applyFunction(key, []object.Object{el})
+			if isError(k) {
+				return nil, k
+			}
+		}
+		decorated[i] = &object.Tuple{Elements: []object.Object{k, el}}
+	}
+
+	// Reversing before and after a stable sort keeps equal elements in order
+	if reverse {
+		slices.Reverse(decorated)
+	}
+
+	var err object.Object
+	algorithms.TimSort(decorated, func(a, b object.Object) bool {
+		if err != nil {
+			return false
+		}
+		less, e := lessThan(a.(*object.Tuple).Elements[0], b.(*object.Tuple).Elements[0])
+		if e != nil {
+			err = e
+		}
+		return less
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	if reverse {
+		slices.Reverse(decorated)
+	}
+
+	result := make([]object.Object, len(decorated))
+	for i, pair := range decorated {
+		result[i] = pair.(*object.Tuple).Elements[1]
+	}
+
+	return result, nil
 }
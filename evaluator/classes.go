@@ -0,0 +1,698 @@
+package evaluator
+
+import (
+	"fmt"
+	"hash/fnv"
+	"reflect"
+	"simpyl/ast"
+	"simpyl/object"
+	"strings"
+)
+
+/*
+Class Definitions
+*/
+func evalClassStatement(node *ast.ClassStatement, env *object.Environment) object.Object {
+	bases := []*object.Class{}
+	for _, b := range node.Bases {
+		base := Eval(b, env)
+		if isError(base) {
+			return base
+		}
+		cls, ok := base.(*object.Class)
+		if !ok {
+			return newError("class %s: base must be a class, got %s", node.Name, base.Type())
+		}
+		bases = append(bases, cls)
+	}
+
+	cls := &object.Class{Name: node.Name, Bases: bases, Attrs: make(map[string]object.Object)}
+	mro, err := linearize(cls)
+	if err != nil {
+		return err
+	}
+	cls.MRO = mro
+
+	// The class body runs in its own scope, whose bindings become class attributes
+	classEnv := object.NewEnclosedEnvironment(env)
+	result := evalBlockStatement(node.Body, classEnv)
+	if isError(result) {
+		return result
+	}
+
+	for name, val := range classEnv.Locals() {
+		// Methods close over the enclosing scope, not the class body
+		if fn, ok := val.(*object.Function); ok && fn.Env == classEnv {
+			fn.Env = env
+			fn.Class = cls
+		}
+		cls.Attrs[name] = val
+	}
+
+	if err := env.Set(node.Name, cls); isError(err) {
+		return err
+	}
+	return nil
+}
+
+// linearize computes the C3 method resolution order of a class from its bases
+func linearize(cls *object.Class) ([]*object.Class, *object.Error) {
+	seqs := [][]*object.Class{}
+	for _, base := range cls.Bases {
+		seqs = append(seqs, append([]*object.Class{}, base.MRO...))
+	}
+	seqs = append(seqs, append([]*object.Class{}, cls.Bases...))
+
+	mro := []*object.Class{cls}
+	for {
+		remaining := seqs[:0]
+		for _, seq := range seqs {
+			if len(seq) > 0 {
+				remaining = append(remaining, seq)
+			}
+		}
+		seqs = remaining
+		if len(seqs) == 0 {
+			return mro, nil
+		}
+
+		var head *object.Class
+		for _, seq := range seqs {
+			if !inTail(seq[0], seqs) {
+				head = seq[0]
+				break
+			}
+		}
+		if head == nil {
+			names := []string{}
+			for _, base := range cls.Bases {
+				names = append(names, base.Name)
+			}
+			return nil, newError("cannot create a consistent method resolution order (MRO) for bases %s",
+				strings.Join(names, ", "))
+		}
+
+		mro = append(mro, head)
+		for i, seq := range seqs {
+			if seq[0] == head {
+				seqs[i] = seq[1:]
+			}
+		}
+	}
+}
+
+func inTail(cls *object.Class, seqs [][]*object.Class) bool {
+	for _, seq := range seqs {
+		for _, c := range seq[1:] {
+			if c == cls {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+/*
+Instances
+*/
+func instantiate(caller *object.Runtime, cls *object.Class, args []object.Object, kwargs map[string]object.Object) object.Object {
+	instance := &object.Instance{Class: cls, Attrs: make(map[string]object.Object)}
+
+	init, ok := cls.Lookup("__init__")
+	if !ok {
+		if len(args) != 0 || len(kwargs) != 0 {
+			return newError("%s() takes no arguments", cls.Name)
+		}
+		return instance
+	}
+
+	result := callFunction(caller, bindMethod(instance, init), args, kwargs)
+	if isError(result) {
+		return result
+	}
+
+	return instance
+}
+
+func bindMethod(self object.Object, val object.Object) object.Object {
+	switch val.(type) {
+	case *object.Function, *object.BuiltinMethod:
+		return &object.BoundMethod{Self: self, Method: val}
+	default:
+		return val
+	}
+}
+
+// superFunction builds the zero-argument super() available inside methods
+func superFunction(cls *object.Class, self object.Object) *object.Builtin {
+	return &object.Builtin{
+		Fn: func(args ...object.Object) object.Object {
+			if len(args) == 0 {
+				return &object.Super{Class: cls, Self: self}
+			}
+			return builtins["super"].Fn(args...)
+		},
+	}
+}
+
+/*
+Attributes
+*/
+func evalClassAttribute(obj object.Object, name string) object.Object {
+	switch obj := obj.(type) {
+	case *object.Instance:
+		if val, ok := obj.Attrs[name]; ok {
+			return val
+		}
+		if val, ok := obj.Class.Lookup(name); ok {
+			return bindMethod(obj, val)
+		}
+		if name == "__class__" {
+			return obj.Class
+		}
+		return newError("'%s' object has no attribute '%s'", obj.Class.Name, name)
+
+	case *object.Class:
+		if val, ok := obj.Lookup(name); ok {
+			return val
+		}
+		if name == "__name__" {
+			return &object.String{Value: obj.Name}
+		}
+		return newError("type object '%s' has no attribute '%s'", obj.Name, name)
+
+	case *object.Super:
+		mro := superMRO(obj)
+		for i, cls := range mro {
+			if cls != obj.Class {
+				continue
+			}
+			for _, next := range mro[i+1:] {
+				if val, ok := next.Attrs[name]; ok {
+					return bindMethod(obj.Self, val)
+				}
+			}
+			break
+		}
+		return newError("'super' object has no attribute '%s'", name)
+
+	default:
+		return newError("%s object has no attribute '%s'", obj.Type(), name)
+	}
+}
+
+func superMRO(s *object.Super) []*object.Class {
+	switch self := s.Self.(type) {
+	case *object.Instance:
+		return self.Class.MRO
+	case *object.Class:
+		return self.MRO
+	default:
+		return nil
+	}
+}
+
+func evalAttributeAssign(obj object.Object, name string, val object.Object) object.Object {
+	switch obj := obj.(type) {
+	case *object.Instance:
+		obj.Attrs[name] = val
+	case *object.Class:
+		obj.Attrs[name] = val
+	case *object.Module:
+		if err := obj.Env.Set(name, val); isError(err) {
+			return err
+		}
+	case object.HostObject:
+		return obj.SetAttr(name, val)
+	default:
+		return newError("cannot set attribute '%s' on %s object", name, obj.Type())
+	}
+	return NULL
+}
+
+/*
+Type Checks
+*/
+func classInfo(arg object.Object, fname string) ([]*object.Class, *object.Error) {
+	switch arg := arg.(type) {
+	case *object.Class:
+		return []*object.Class{arg}, nil
+	case *object.List:
+		classes := []*object.Class{}
+		for _, el := range arg.Elements {
+			cls, ok := el.(*object.Class)
+			if !ok {
+				return nil, newError("%s() arg 2 must be a class or list of classes, got %s",
+					fname, el.Type())
+			}
+			classes = append(classes, cls)
+		}
+		return classes, nil
+	default:
+		return nil, newError("%s() arg 2 must be a class or list of classes, got %s",
+			fname, arg.Type())
+	}
+}
+
+/*
+Special Methods
+*/
+var infixMethods = map[string][2]string{
+	"+":  {"__add__", "__radd__"},
+	"-":  {"__sub__", "__rsub__"},
+	"*":  {"__mul__", "__rmul__"},
+	"/":  {"__truediv__", "__rtruediv__"},
+	"%":  {"__mod__", "__rmod__"},
+	"|":  {"__or__", "__ror__"},
+	"&":  {"__and__", "__rand__"},
+	"^":  {"__xor__", "__rxor__"},
+	"==": {"__eq__", "__eq__"},
+	"!=": {"__ne__", "__ne__"},
+	"<":  {"__lt__", "__gt__"},
+	">":  {"__gt__", "__lt__"},
+	"<=": {"__le__", "__ge__"},
+	">=": {"__ge__", "__le__"},
+}
+
+// callMethod looks up a special method on the class of an instance and calls it.
+// The boolean result reports whether the method was defined.
+func callMethod(obj object.Object, name string, args ...object.Object) (object.Object, bool) {
+	instance, ok := obj.(*object.Instance)
+	if !ok {
+		return nil, false
+	}
+
+	method, ok := instance.Class.Lookup(name)
+	if !ok {
+		return nil, false
+	}
+
+	return applyFunction(bindMethod(instance, method), args), true
+}
+
+func evalInstanceInfixExpression(operator string, left, right object.Object) object.Object {
+	names, ok := infixMethods[operator]
+	if !ok {
+		return newError("unsupported operand types for %s: %s and %s",
+			operator, typeName(left), typeName(right))
+	}
+
+	if result, ok := callMethod(left, names[0], right); ok {
+		return result
+	}
+	if result, ok := callMethod(right, names[1], left); ok {
+		return result
+	}
+
+	switch operator {
+	case "==":
+		return nativeBoolToBooleanObject(left == right)
+	case "!=":
+		// Without __ne__, != is the inverse of __eq__
+		result, ok := callMethod(left, "__eq__", right)
+		if !ok {
+			result, ok = callMethod(right, "__eq__", left)
+		}
+		if !ok {
+			return nativeBoolToBooleanObject(left != right)
+		}
+		result = toBoolean(result)
+		if isError(result) {
+			return result
+		}
+		return nativeBoolToBooleanObject(result == FALSE)
+	default:
+		return newError("unsupported operand types for %s: %s and %s",
+			operator, typeName(left), typeName(right))
+	}
+}
+
+// toBoolean converts an object to TRUE or FALSE, consulting __bool__ and then
+// __len__ for instances
+func toBoolean(obj object.Object) object.Object {
+	if isError(obj) {
+		return obj
+	}
+
+	if result, ok := callMethod(obj, "__bool__"); ok {
+		if isError(result) {
+			return result
+		}
+		if result.Type() != object.BOOLEAN_OBJ {
+			return newError("__bool__ should return bool, returned %s", typeName(result))
+		}
+		return nativeBoolToBooleanObject(result.(*object.Boolean).Value)
+	}
+
+	if result, ok := callMethod(obj, "__len__"); ok {
+		if isError(result) {
+			return result
+		}
+		length, ok := result.(*object.Integer)
+		if !ok {
+			return newError("__len__ should return an integer, returned %s", typeName(result))
+		}
+		return nativeBoolToBooleanObject(length.Value != 0)
+	}
+
+	return nativeBoolToBooleanObject(isTruthy(obj))
+}
+
+// iterate returns the elements produced by iterating over an object. Instances
+// are iterated through __iter__, which must return an iterable object.
+func iterate(obj object.Object) ([]object.Object, object.Object) {
+	switch obj := obj.(type) {
+	case *object.List:
+		return obj.Elements, nil
+
+	case *object.Set:
+		return obj.Elements(), nil
+
+	case *object.Tuple:
+		return obj.Elements, nil
+
+	case *object.Dict:
+		keys := make([]object.Object, 0, len(obj.Pairs))
+		for _, pair := range obj.Items() {
+			keys = append(keys, pair.Key)
+		}
+		return keys, nil
+
+	case *object.String:
+		elements := []object.Object{}
+		for _, r := range obj.Value {
+			elements = append(elements, &object.String{Value: string(r)})
+		}
+		return elements, nil
+
+	case *object.Bytes:
+		elements := make([]object.Object, len(obj.Value))
+		for i, b := range obj.Value {
+			elements[i] = &object.Integer{Value: int64(b)}
+		}
+		return elements, nil
+
+	case *object.Instance:
+		result, ok := callMethod(obj, "__iter__")
+		if !ok {
+			return nil, newError("'%s' object is not iterable", obj.Class.Name)
+		}
+		if isError(result) {
+			return nil, result
+		}
+		if result == obj {
+			return nil, newError("__iter__ of '%s' must return a different iterable object", obj.Class.Name)
+		}
+		return iterate(result)
+
+	default:
+		return nil, newError("'%s' object is not iterable", typeName(obj))
+	}
+}
+
+// HashKey computes the dict and set key of an object. Instances hash by
+// identity unless their class defines __hash__.
+func HashKey(obj object.Object) (object.HashKey, object.Object) {
+	if instance, ok := obj.(*object.Instance); ok {
+		if result, ok := callMethod(instance, "__hash__"); ok {
+			if isError(result) {
+				return object.HashKey{}, result
+			}
+			hash, ok := result.(*object.Integer)
+			if !ok {
+				return object.HashKey{}, newError("__hash__ method should return an integer")
+			}
+			return object.HashKey{Type: object.INSTANCE_OBJ, Value: uint64(hash.Value)}, nil
+		}
+
+		// Classes that define equality but not a hash are unhashable
+		if _, ok := instance.Class.Lookup("__eq__"); ok {
+			return object.HashKey{}, newError("unhashable type: '%s'", instance.Class.Name)
+		}
+		return object.HashKey{Type: object.INSTANCE_OBJ, Value: uint64(reflect.ValueOf(instance).Pointer())}, nil
+	}
+
+	// Tuples hash by their elements, so they are only hashable if every element is
+	if tuple, ok := obj.(*object.Tuple); ok {
+		h := fnv.New64a()
+		for _, el := range tuple.Elements {
+			key, err := HashKey(el)
+			if err != nil {
+				return object.HashKey{}, err
+			}
+			fmt.Fprintf(h, "%s:%d;", key.Type, key.Value)
+		}
+		return object.HashKey{Type: object.TUPLE_OBJ, Value: h.Sum64()}, nil
+	}
+
+	// Frozen sets combine element hashes with an order independent sum, since their
+	// elements have no order. Mutable sets are unhashable
+	if set, ok := obj.(*object.Set); ok {
+		if !set.Frozen {
+			return object.HashKey{}, newError("unhashable type: 'set'")
+		}
+		var sum uint64
+		for key := range set.Values {
+			h := fnv.New64a()
+			fmt.Fprintf(h, "%s:%d", key.Type, key.Value)
+			sum += h.Sum64()
+		}
+		return object.HashKey{Type: object.FROZENSET_OBJ, Value: sum}, nil
+	}
+
+	hashable, ok := obj.(object.Hashable)
+	if !ok {
+		return object.HashKey{}, newError("unusable as hash key: %s", obj.Type())
+	}
+
+	return hashable.HashKey(), nil
+}
+
+// findKey looks up obj among the keys of m, where keyOf gives the key object
+// stored under each entry. Unequal keys can have equal hashes, so each of them
+// takes the next free slot of its hash and is told apart with == or __eq__.
+// When obj is missing, the key returned is the free slot it would be stored under
+func findKey[V any](m map[object.HashKey]V, obj object.Object, keyOf func(V) object.Object) (object.HashKey, bool, object.Object) {
+	key, err := HashKey(obj)
+	if err != nil {
+		return key, false, err
+	}
+
+	for {
+		entry, ok := m[key]
+		if !ok {
+			return key, false, nil
+		}
+
+		equal, err := valuesEqual(obj, keyOf(entry))
+		if err != nil {
+			return key, false, err
+		}
+		if equal {
+			return key, true, nil
+		}
+		key.Slot++
+	}
+}
+
+// removeKey deletes key from m, moving the last entry that shares its hash into
+// the freed slot so that findKey still reaches every entry
+func removeKey[V any](m map[object.HashKey]V, key object.HashKey) {
+	delete(m, key)
+
+	last := key
+	for {
+		next := last
+		next.Slot++
+		if _, ok := m[next]; !ok {
+			break
+		}
+		last = next
+	}
+	if last != key {
+		m[key] = m[last]
+		delete(m, last)
+	}
+}
+
+// dictKey finds the key of obj in dict
+func dictKey(dict *object.Dict, obj object.Object) (object.HashKey, bool, object.Object) {
+	return findKey(dict.Pairs, obj, func(pair object.HashPair) object.Object { return pair.Key })
+}
+
+// setKey finds the key of obj in set
+func setKey(set *object.Set, obj object.Object) (object.HashKey, bool, object.Object) {
+	return findKey(set.Values, obj, func(val object.Object) object.Object { return val })
+}
+
+// SetItem stores value under key in dict, replacing the value of an equal key.
+// An unhashable key is returned as an error
+func SetItem(dict *object.Dict, key, value object.Object) object.Object {
+	hashed, _, err := dictKey(dict, key)
+	if err != nil {
+		return err
+	}
+	if pair, ok := dict.Pairs[hashed]; ok {
+		key = pair.Key
+	}
+	dict.Set(hashed, key, value)
+	return nil
+}
+
+// objectString converts an object to its str() form, using __str__ for instances
+func objectString(obj object.Object) (string, object.Object) {
+	if result, ok := callMethod(obj, "__str__"); ok {
+		return specialString(result, "__str__")
+	}
+	if str, ok := obj.(*object.String); ok {
+		return str.Value, nil
+	}
+
+	return objectRepr(obj)
+}
+
+// objectRepr converts an object to its repr() form, using __repr__ for instances
+// and for the elements of containers
+func objectRepr(obj object.Object) (string, object.Object) {
+	return reprIn(obj, map[object.Object]bool{})
+}
+
+// reprIn is objectRepr for an object inside the containers in seen. A container
+// that holds itself is shown as "[...]" and the like, as in Python
+func reprIn(obj object.Object, seen map[object.Object]bool) (string, object.Object) {
+	switch obj.(type) {
+	case *object.List, *object.Tuple, *object.Dict, *object.Set:
+		if seen[obj] {
+			return object.RecursiveRepr(obj), nil
+		}
+		seen[obj] = true
+		defer delete(seen, obj)
+	}
+
+	switch obj := obj.(type) {
+	case *object.Instance:
+		if result, ok := callMethod(obj, "__repr__"); ok {
+			return specialString(result, "__repr__")
+		}
+		return obj.Inspect(), nil
+
+	case *object.List:
+		elements := []string{}
+		for _, el := range obj.Elements {
+			str, err := reprIn(el, seen)
+			if err != nil {
+				return "", err
+			}
+			elements = append(elements, str)
+		}
+		return "[" + strings.Join(elements, ", ") + "]", nil
+
+	case *object.Tuple:
+		elements := []string{}
+		for _, el := range obj.Elements {
+			str, err := reprIn(el, seen)
+			if err != nil {
+				return "", err
+			}
+			elements = append(elements, str)
+		}
+		if len(elements) == 1 {
+			return "(" + elements[0] + ",)", nil
+		}
+		return "(" + strings.Join(elements, ", ") + ")", nil
+
+	case *object.String:
+		return stringRepr(obj.Value), nil
+
+	case *object.Dict:
+		pairs := []string{}
+		for _, pair := range obj.Items() {
+			key, err := reprIn(pair.Key, seen)
+			if err != nil {
+				return "", err
+			}
+			val, err := reprIn(pair.Value, seen)
+			if err != nil {
+				return "", err
+			}
+			pairs = append(pairs, key+": "+val)
+		}
+		return "{" + strings.Join(pairs, ", ") + "}", nil
+
+	case *object.Set:
+		vals := []string{}
+		for _, val := range obj.Elements() {
+			str, err := reprIn(val, seen)
+			if err != nil {
+				return "", err
+			}
+			vals = append(vals, str)
+		}
+		return object.FormatSet(obj, vals), nil
+
+	default:
+		return obj.Inspect(), nil
+	}
+}
+
+func specialString(result object.Object, method string) (string, object.Object) {
+	if isError(result) {
+		return "", result
+	}
+	str, ok := result.(*object.String)
+	if !ok {
+		return "", newError("%s returned non-string (type %s)", method, typeName(result))
+	}
+	return str.Value, nil
+}
+
+// lessThan orders two objects for sorting, using __lt__ when either is an instance.
+// Types without an ordering between them are a TypeError rather than a mismatch
+func lessThan(a, b object.Object) (bool, object.Object) {
+	if !orderable(a, b) {
+		return false, unorderableError("<", a, b)
+	}
+
+	result := evalInfixExpression("<", a, b)
+	if isError(result) {
+		return false, result
+	}
+
+	result = toBoolean(result)
+	if isError(result) {
+		return false, result
+	}
+
+	return isTruthy(result), nil
+}
+
+// orderable reports whether the ordering operators are defined between a and b.
+// Instances are assumed orderable, leaving the decision to their dunder methods
+func orderable(a, b object.Object) bool {
+	switch {
+	case a.Type() == object.INSTANCE_OBJ || b.Type() == object.INSTANCE_OBJ:
+		return true
+	case isNumber(a) && isNumber(b):
+		return true
+	case isSet(a) && isSet(b):
+		return true
+	default:
+		switch a.Type() {
+		case object.STRING_OBJ, object.BOOLEAN_OBJ, object.LIST_OBJ, object.TUPLE_OBJ:
+			return a.Type() == b.Type()
+		}
+		return false
+	}
+}
+
+func unorderableError(operator string, a, b object.Object) *object.Error {
+	return newError("TypeError: '%s' not supported between instances of '%s' and '%s'",
+		operator, typeName(a), typeName(b))
+}
+
+func typeName(obj object.Object) string {
+	if instance, ok := obj.(*object.Instance); ok {
+		return instance.Class.Name
+	}
+	return string(obj.Type())
+}
@@ -1,18 +1,38 @@
 package evaluator
 
 import (
+	"bytes"
 	"fmt"
+	"math"
+	"os"
 	"simpyl/ast"
 	"simpyl/object"
+	"slices"
+	"sort"
+	"strings"
 )
 
+// NULL, TRUE and FALSE are never changed, so every interpreter shares them
 var (
 	NULL  = &object.Null{}
-	TRUE  = &object.Boolean{Value: true}
-	FALSE = &object.Boolean{Value: false}
+	TRUE  = object.True
+	FALSE = object.False
 )
 
+// Eval evaluates node in env. An environment without a runtime is given one that
+// uses the process streams, unless it is frozen
 func Eval(node ast.Node, env *object.Environment) object.Object {
+	runtime := env.Runtime()
+	if runtime == nil && !env.Frozen() {
+		runtime = NewRuntime(os.Stdin, os.Stdout, os.Stderr)
+		env.SetRuntime(runtime)
+	}
+	if runtime != nil {
+		if err := step(runtime); err != nil {
+			return err
+		}
+	}
+
 	switch node := node.(type) {
 
 	// Statements
@@ -37,20 +57,36 @@ func Eval(node ast.Node, env *object.Environment) object.Object {
 		if isError(val) {
 			return val
 		}
-		env.Set(node.Name.Value, val)
+		if err := env.Set(node.Name.Value, val); isError(err) {
+			return err
+		}
 
 	case *ast.FunctionStatement:
 		params := node.Parameters
 		body := node.Body
 		name := node.Name
 
-		env.Set(name, &object.Function{Parameters: params, Env: env, Body: body, Name: name})
+		if err := env.Set(name, &object.Function{Parameters: params, Env: env, Body: body, Name: name}); isError(err) {
+			return err
+		}
 
 	case *ast.ForStatement:
-		evalForLoop(node, env)
+		return evalForLoop(node, env)
 
 	case *ast.WhileStatement:
-		evalWhileLoop(node, env)
+		return evalWhileLoop(node, env)
+
+	case *ast.ClassStatement:
+		return evalClassStatement(node, env)
+
+	case *ast.ImportStatement:
+		return evalImportStatement(node, env)
+
+	case *ast.FromImportStatement:
+		return evalFromImportStatement(node, env)
+
+	case *ast.DelStatement:
+		return evalDelStatement(node, env)
 
 	// Expressions
 	case *ast.Identifier:
@@ -68,6 +104,12 @@ func Eval(node ast.Node, env *object.Environment) object.Object {
 	case *ast.StringLiteral:
 		return &object.String{Value: node.Value}
 
+	case *ast.BytesLiteral:
+		return &object.Bytes{Value: node.Value}
+
+	case *ast.FormattedString:
+		return evalFormattedString(node, env)
+
 	case *ast.ListLiteral:
 		elements := evalExpressions(node.Elements, env)
 		if len(elements) == 1 && isError(elements[0]) {
@@ -75,37 +117,48 @@ func Eval(node ast.Node, env *object.Environment) object.Object {
 		}
 		return &object.List{Elements: elements}
 
+	case *ast.TupleLiteral:
+		elements := evalExpressions(node.Elements, env)
+		if len(elements) == 1 && isError(elements[0]) {
+			return elements[0]
+		}
+		return &object.Tuple{Elements: elements}
+
 	case *ast.IndexExpression:
 		left := Eval(node.Left, env)
 		if isError(left) {
 			return left
 		}
-		index := Eval(node.Index, env)
+		index := evalSubscript(node.Index, node.Colon, node.EndIndex, node.Step, env)
 		if isError(index) {
 			return index
 		}
-		colon := node.Colon
-		if colon {
-			end := Eval(node.EndIndex, env)
-			return evalIndexExpression(left, index, colon, end)
-		}
-		return evalIndexExpression(left, index, colon, nil)
+		return evalIndexExpression(left, index)
 
 	case *ast.IndexAssignExpression:
 		left := Eval(node.Left, env)
 		if isError(left) {
 			return left
 		}
-		index := Eval(node.Index, env)
+		index := evalSubscript(node.Index, node.Colon, node.EndIndex, node.Step, env)
 		if isError(index) {
 			return index
 		}
 		val := Eval(node.Value, env)
+		if isError(val) {
+			return val
+		}
 		return evalIndexAssignExpression(left, index, val)
 
 	case *ast.DictLiteral:
 		return evalDictLiteral(node, env)
 
+	case *ast.SetLiteral:
+		return evalSetLiteral(node, env)
+
+	case *ast.SetComprehension:
+		return evalSetComprehension(node, env)
+
 	case *ast.IfExpression:
 		return evalIfExpression(node, env)
 
@@ -120,7 +173,19 @@ func Eval(node ast.Node, env *object.Environment) object.Object {
 			return args[0]
 		}
 
-		return applyFunction(function, args)
+		kwargs, err := evalKeywords(node.Keywords, env)
+		if err != nil {
+			return err
+		}
+
+		if err := checkAllocation(runtime, callAllocation(function, args)); err != nil {
+			return err
+		}
+		result := callFunction(runtime, function, args, kwargs)
+		if err := checkSize(runtime, result); err != nil {
+			return err
+		}
+		return result
 
 	case *ast.ObjectMethod:
 		obj := Eval(node.Obj, env)
@@ -130,7 +195,7 @@ func Eval(node ast.Node, env *object.Environment) object.Object {
 
 		method, ok := node.Method.(*ast.CallExpression)
 		if !ok {
-			newError("Object method not ast.CallExpression. got=%T", node.Method)
+			return newError("Object method not ast.CallExpression. got=%T", node.Method)
 		}
 
 		args := evalExpressions(method.Arguments, env)
@@ -138,11 +203,50 @@ func Eval(node ast.Node, env *object.Environment) object.Object {
 			return args[0]
 		}
 
-		return applyObjectMethod(obj, method.Function, args)
+		kwargs, err := evalKeywords(method.Keywords, env)
+		if err != nil {
+			return err
+		}
+
+		name := method.Function.String()
+		if err := checkAllocation(runtime, methodAllocation(obj, name, args)); err != nil {
+			return err
+		}
+		result := applyObjectMethod(runtime, obj, method.Function, args, kwargs)
+
+		// Methods such as append grow the object they are called on
+		if err := checkSize(runtime, result, obj); err != nil {
+			return err
+		}
+		return result
+
+	case *ast.AttributeExpression:
+		obj := Eval(node.Obj, env)
+		if isError(obj) {
+			return obj
+		}
+		return evalAttribute(obj, node.Attribute.Value)
+
+	case *ast.AttributeAssignExpression:
+		obj := Eval(node.Obj, env)
+		if isError(obj) {
+			return obj
+		}
+		val := Eval(node.Value, env)
+		if isError(val) {
+			return val
+		}
+		return evalAttributeAssign(obj, node.Attribute.Value, val)
 
 	case *ast.InExpression:
 		left := Eval(node.Left, env)
+		if isError(left) {
+			return left
+		}
 		right := Eval(node.Right, env)
+		if isError(right) {
+			return right
+		}
 		return evalInExpression(left, right)
 
 	// Operators
@@ -163,7 +267,22 @@ func Eval(node ast.Node, env *object.Environment) object.Object {
 			return right
 		}
 
-		return evalInfixExpression(node.Operator, left, right)
+		if err := checkRepetition(runtime, node.Operator, left, right); err != nil {
+			return err
+		}
+
+		// % formatting pads values as widely as the format asks, so it needs the
+		// runtime to keep within its allocation limit
+		var result object.Object
+		if str, ok := left.(*object.String); ok && node.Operator == "%" {
+			result = formatPercent(runtime, str.Value, right)
+		} else {
+			result = evalInfixExpression(node.Operator, left, right)
+		}
+		if err := checkSize(runtime, result); err != nil {
+			return err
+		}
+		return result
 	}
 	return nil
 }
@@ -189,13 +308,29 @@ func evalIdentifier(node *ast.Identifier, env *object.Environment) object.Object
 		return val
 	}
 
-	if builtin, ok := builtins[node.Value]; ok {
+	if builtin, ok := lookupBuiltin(node.Value, env); ok {
+		if runtime := env.Runtime(); runtime != nil && !runtime.Capabilities.AllowsBuiltin(node.Value) {
+			return newError("PermissionError: use of builtin '%s' is not allowed", node.Value)
+		}
 		return builtin
 	}
 
 	return newError("identifier not found: " + node.Value)
 }
 
+// lookupBuiltin finds a builtin function, preferring those bound to the runtime
+// of env over the global ones
+func lookupBuiltin(name string, env *object.Environment) (*object.Builtin, bool) {
+	if runtime := env.Runtime(); runtime != nil {
+		if builtin, ok := runtime.Builtins[name]; ok {
+			return builtin, true
+		}
+	}
+
+	builtin, ok := builtins[name]
+	return builtin, ok
+}
+
 func evalBlockStatement(block *ast.BlockStatement, env *object.Environment) object.Object {
 	var result object.Object
 
@@ -225,28 +360,159 @@ func evalExpressions(exps []ast.Expression, env *object.Environment) []object.Ob
 	return result
 }
 
+// evalKeywords evaluates keyword arguments in order, rejecting repeated names
+func evalKeywords(keywords []*ast.KeywordArgument, env *object.Environment) (map[string]object.Object, *object.Error) {
+	if len(keywords) == 0 {
+		return nil, nil
+	}
+
+	kwargs := make(map[string]object.Object, len(keywords))
+	for _, keyword := range keywords {
+		if _, ok := kwargs[keyword.Name]; ok {
+			return nil, newError("keyword argument repeated: %s", keyword.Name)
+		}
+
+		val := Eval(keyword.Value, env)
+		if err, ok := val.(*object.Error); ok {
+			return nil, err
+		}
+		kwargs[keyword.Name] = val
+	}
+
+	return kwargs, nil
+}
+
+// Apply calls a function, builtin, bound method, class or callable instance
+// with positional and keyword arguments. kwargs may be nil. Functions from a
+// frozen environment run in runtime
+func Apply(runtime *object.Runtime, fn object.Object, args []object.Object, kwargs map[string]object.Object) object.Object {
+	return callFunction(runtime, fn, args, kwargs)
+}
+
 func applyFunction(fn object.Object, args []object.Object) object.Object {
+	return applyFunctionWithKeywords(fn, args, nil)
+}
+
+func applyFunctionWithKeywords(fn object.Object, args []object.Object, kwargs map[string]object.Object) object.Object {
+	return callFunction(nil, fn, args, kwargs)
+}
+
+// callFunction calls fn on behalf of a script running in caller, which may be
+// nil. Functions run in the runtime of the environment they were defined in,
+// or in the caller's when that environment is frozen
+func callFunction(caller *object.Runtime, fn object.Object, args []object.Object, kwargs map[string]object.Object) object.Object {
 	switch fn := fn.(type) {
 
 	case *object.Function:
-		extendedEnv := extendFunctionEnv(fn, args)
+		extendedEnv, err := extendFunctionEnv(fn, args, kwargs)
+		if err != nil {
+			return err
+		}
+		runtime := extendedEnv.Runtime()
+		if runtime == nil {
+			runtime = caller
+		}
+		if runtime != nil {
+			// Setting it on the call's own scope lets Eval find it without
+			// walking the scope chain for every node of the body
+			extendedEnv.SetRuntime(runtime)
+			if err := enterCall(runtime); err != nil {
+				return err
+			}
+			defer leaveCall(runtime)
+		}
 		evaluated := Eval(fn.Body, extendedEnv)
 		return unwrapReturnValue(evaluated)
 
 	case *object.Builtin:
+		if fn.RuntimeFn != nil {
+			return fn.RuntimeFn(caller, kwargs, args...)
+		}
+		if fn.KwFn != nil {
+			return fn.KwFn(kwargs, args...)
+		}
+		if len(kwargs) != 0 {
+			return newError("builtin function takes no keyword arguments")
+		}
 		return fn.Fn(args...)
 
+	case *object.BoundMethod:
+		if method, ok := fn.Method.(*object.BuiltinMethod); ok {
+			if method.RuntimeFn != nil {
+				return method.RuntimeFn(caller, fn.Self, kwargs, args...)
+			}
+			if method.KwFn != nil {
+				return method.KwFn(fn.Self, kwargs, args...)
+			}
+			if len(kwargs) != 0 {
+				return newError("builtin method takes no keyword arguments")
+			}
+			return method.Fn(fn.Self, args...)
+		}
+		return callFunction(caller, fn.Method, append([]object.Object{fn.Self}, args...), kwargs)
+
+	case *object.Class:
+		return instantiate(caller, fn, args, kwargs)
+
+	case *object.Instance:
+		if method, ok := fn.Class.Lookup("__call__"); ok {
+			return callFunction(caller, bindMethod(fn, method), args, kwargs)
+		}
+		return newError("'%s' object is not callable", fn.Class.Name)
+
 	default:
 		return newError("not a function: %s", fn.Type())
 	}
 }
 
-func extendFunctionEnv(fn *object.Function, args []object.Object) *object.Environment {
+// extendFunctionEnv binds positional arguments in order, then keyword arguments by
+// parameter name, and reports missing or unexpected arguments
+func extendFunctionEnv(fn *object.Function, args []object.Object, kwargs map[string]object.Object) (*object.Environment, *object.Error) {
+	if len(args) > len(fn.Parameters) {
+		return nil, newError("%s() takes %d positional arguments but %d were given",
+			fn.Name, len(fn.Parameters), len(args))
+	}
+
 	env := object.NewEnclosedEnvironment(fn.Env)
-	for paramIdx, param := range fn.Parameters {
-		env.Set(param.Value, args[paramIdx])
+	for paramIdx, arg := range args {
+		env.Set(fn.Parameters[paramIdx].Value, arg)
 	}
-	return env
+
+	names := make([]string, 0, len(kwargs))
+	for name := range kwargs {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	for _, name := range names {
+		paramIdx := -1
+		for i, param := range fn.Parameters {
+			if param.Value == name {
+				paramIdx = i
+				break
+			}
+		}
+
+		switch {
+		case paramIdx < 0:
+			return nil, newError("%s() got an unexpected keyword argument '%s'", fn.Name, name)
+		case paramIdx < len(args):
+			return nil, newError("%s() got multiple values for argument '%s'", fn.Name, name)
+		}
+		env.Set(name, kwargs[name])
+	}
+
+	for _, param := range fn.Parameters[len(args):] {
+		if _, ok := kwargs[param.Value]; !ok {
+			return nil, newError("%s() missing required argument: '%s'", fn.Name, param.Value)
+		}
+	}
+
+	// Methods get a zero-argument super() bound to their class and instance
+	if fn.Class != nil && len(args) > 0 {
+		env.Set("super", superFunction(fn.Class, args[0]))
+	}
+	return env, nil
 }
 
 func unwrapReturnValue(obj object.Object) object.Object {
@@ -263,12 +529,25 @@ func nativeBoolToBooleanObject(input bool) *object.Boolean {
 	return FALSE
 }
 
-func evalIndexExpression(left, index object.Object, colon bool, end object.Object) object.Object {
+func evalIndexExpression(left, index object.Object) object.Object {
 	switch {
-	case left.Type() == object.LIST_OBJ && index.Type() == object.INTEGER_OBJ:
-		return evalListIndexExpression(left, index, colon, end)
+	case left.Type() == object.INSTANCE_OBJ:
+		if result, ok := callMethod(left, "__getitem__", index); ok {
+			return result
+		}
+		return newError("'%s' object is not subscriptable", typeName(left))
 	case left.Type() == object.DICT_OBJ:
 		return evalDictIndexExpression(left, index)
+	case index.Type() == object.SLICE_OBJ:
+		return evalSliceExpression(left, index.(*object.Slice))
+	case left.Type() == object.LIST_OBJ && index.Type() == object.INTEGER_OBJ:
+		return evalListIndexExpression(left, index)
+	case left.Type() == object.STRING_OBJ && index.Type() == object.INTEGER_OBJ:
+		return evalStringIndexExpression(left, index)
+	case left.Type() == object.BYTES_OBJ && index.Type() == object.INTEGER_OBJ:
+		return evalBytesIndexExpression(left, index)
+	case left.Type() == object.TUPLE_OBJ && index.Type() == object.INTEGER_OBJ:
+		return evalTupleIndexExpression(left, index)
 	default:
 		return newError("index operator not supported: %s", left.Type())
 	}
@@ -276,6 +555,16 @@ func evalIndexExpression(left, index object.Object, colon bool, end object.Objec
 
 func evalIndexAssignExpression(left, index object.Object, val object.Object) object.Object {
 	switch {
+	case left.Type() == object.INSTANCE_OBJ:
+		if result, ok := callMethod(left, "__setitem__", index, val); ok {
+			if isError(result) {
+				return result
+			}
+			return left
+		}
+		return newError("'%s' object does not support item assignment", typeName(left))
+	case index.Type() == object.SLICE_OBJ:
+		return evalSliceAssignExpression(left, index.(*object.Slice), val)
 	case left.Type() == object.LIST_OBJ && index.Type() == object.INTEGER_OBJ:
 		return evalListIndexAssignExpression(left, index, val)
 	case left.Type() == object.DICT_OBJ:
@@ -285,7 +574,7 @@ func evalIndexAssignExpression(left, index object.Object, val object.Object) obj
 	}
 }
 
-func evalListIndexExpression(list, index object.Object, colon bool, end object.Object) object.Object {
+func evalListIndexExpression(list, index object.Object) object.Object {
 	listObject := list.(*object.List)
 	idx := index.(*object.Integer).Value
 
@@ -294,27 +583,54 @@ func evalListIndexExpression(list, index object.Object, colon bool, end object.O
 		idx = int64(len(listObject.Elements)) + idx
 	}
 
-	if colon {
-		edx := end.(*object.Integer).Value
-		if edx < 0 {
-			edx = int64(len(listObject.Elements)) + edx
-		}
+	if idx < 0 || idx >= int64(len(listObject.Elements)) {
+		return newError("list index out of range")
+	}
+	return listObject.Elements[idx]
+}
 
-		if edx <= idx {
-			return newError("Starting index must be before ending index")
-		}
+// evalStringIndexExpression indexes strings by code point
+func evalStringIndexExpression(str, index object.Object) object.Object {
+	value := str.(*object.String)
+	length := int64(value.Len())
+	idx := index.(*object.Integer).Value
+
+	if idx < 0 {
+		idx = length + idx
+	}
+	if idx < 0 || idx >= length {
+		return newError("string index out of range")
+	}
+
+	return &object.String{Value: value.Char(int(idx))}
+}
 
-		returnList := &object.List{}
-		returnList.Elements = listObject.Elements[idx:edx]
+func evalBytesIndexExpression(bytes, index object.Object) object.Object {
+	value := bytes.(*object.Bytes).Value
+	idx := index.(*object.Integer).Value
 
-		return returnList
+	if idx < 0 {
+		idx = int64(len(value)) + idx
+	}
+	if idx < 0 || idx >= int64(len(value)) {
+		return newError("index out of range")
 	}
 
-	max := int64(len(listObject.Elements) - 1)
-	if idx < 0 || idx > max {
-		return NULL
+	return &object.Integer{Value: int64(value[idx])}
+}
+
+func evalTupleIndexExpression(tuple, index object.Object) object.Object {
+	elements := tuple.(*object.Tuple).Elements
+	idx := index.(*object.Integer).Value
+
+	if idx < 0 {
+		idx = int64(len(elements)) + idx
 	}
-	return listObject.Elements[idx]
+	if idx < 0 || idx >= int64(len(elements)) {
+		return newError("tuple index out of range")
+	}
+
+	return elements[idx]
 }
 
 func evalListIndexAssignExpression(list, index, val object.Object) object.Object {
@@ -326,9 +642,8 @@ func evalListIndexAssignExpression(list, index, val object.Object) object.Object
 		idx = int64(len(listObject.Elements)) + idx
 	}
 
-	max := int64(len(listObject.Elements) - 1)
-	if idx < 0 || idx > max {
-		return NULL
+	if idx < 0 || idx >= int64(len(listObject.Elements)) {
+		return newError("list assignment index out of range")
 	}
 
 	listObject.Elements[idx] = val
@@ -336,79 +651,507 @@ func evalListIndexAssignExpression(list, index, val object.Object) object.Object
 	return listObject
 }
 
+/*
+Slices
+*/
+
+// evalSubscript evaluates the contents of the brackets of an index expression. A
+// slice evaluates to a slice object, with Null for omitted bounds
+func evalSubscript(index ast.Expression, colon bool, end, step ast.Expression, env *object.Environment) object.Object {
+	if !colon {
+		return Eval(index, env)
+	}
+
+	bounds := [3]object.Object{NULL, NULL, NULL}
+	for i, node := range []ast.Expression{index, end, step} {
+		if node == nil {
+			continue
+		}
+		bounds[i] = Eval(node, env)
+		if isError(bounds[i]) {
+			return bounds[i]
+		}
+	}
+	return &object.Slice{Start: bounds[0], Stop: bounds[1], Step: bounds[2]}
+}
+
+// resolveSlice resolves the bounds of a slice against a sequence of the given length
+// the way Python's slice.indices does
+func resolveSlice(slice *object.Slice, length int64) (int64, int64, int64, object.Object) {
+	bounds := [3]int64{}
+	given := [3]bool{}
+	for i, bound := range []object.Object{slice.Start, slice.Stop, slice.Step} {
+		switch bound := bound.(type) {
+		case *object.Null:
+		case *object.Integer:
+			bounds[i], given[i] = bound.Value, true
+		default:
+			return 0, 0, 0, newError("slice indices must be integers, got %s", typeName(bound))
+		}
+	}
+
+	step := int64(1)
+	if given[2] {
+		step = bounds[2]
+	}
+	if step == 0 {
+		return 0, 0, 0, newError("slice step cannot be zero")
+	}
+
+	// Omitted bounds run to the far end in the direction of the step
+	lower, upper := int64(0), length
+	if step < 0 {
+		lower, upper = -1, length-1
+	}
+	start, stop := lower, upper
+	if step < 0 {
+		start, stop = upper, lower
+	}
+
+	for i, bound := range bounds[:2] {
+		if !given[i] {
+			continue
+		}
+		if bound < 0 {
+			bound += length
+		}
+		bound = max(lower, min(bound, upper))
+		if i == 0 {
+			start = bound
+		} else {
+			stop = bound
+		}
+	}
+
+	return start, stop, step, nil
+}
+
+// sliceIndices returns the positions a slice selects in a sequence of the given length
+func sliceIndices(slice *object.Slice, length int64) ([]int64, object.Object) {
+	start, stop, step, err := resolveSlice(slice, length)
+	if err != nil {
+		return nil, err
+	}
+
+	indices := []int64{}
+	for i := start; (step > 0 && i < stop) || (step < 0 && i > stop); i += step {
+		indices = append(indices, i)
+	}
+	return indices, nil
+}
+
+// clampSliceIndex resolves a negative slice bound from the end and clamps it to the sequence
+func clampSliceIndex(idx, length int64) int64 {
+	if idx < 0 {
+		idx += length
+	}
+	return max(0, min(idx, length))
+}
+
+// sliceElements picks the elements a slice selects, so that every sequence type
+// shares the semantics of list slicing
+func sliceElements[T any](elements []T, slice *object.Slice) ([]T, object.Object) {
+	indices, err := sliceIndices(slice, int64(len(elements)))
+	if err != nil {
+		return nil, err
+	}
+
+	result := make([]T, len(indices))
+	for i, idx := range indices {
+		result[i] = elements[idx]
+	}
+	return result, nil
+}
+
+func evalSliceExpression(left object.Object, slice *object.Slice) object.Object {
+	switch left := left.(type) {
+	case *object.List:
+		elements, err := sliceElements(left.Elements, slice)
+		if err != nil {
+			return err
+		}
+		return &object.List{Elements: elements}
+
+	case *object.Tuple:
+		elements, err := sliceElements(left.Elements, slice)
+		if err != nil {
+			return err
+		}
+		return &object.Tuple{Elements: elements}
+
+	case *object.String:
+		runes, err := sliceElements([]rune(left.Value), slice)
+		if err != nil {
+			return err
+		}
+		return &object.String{Value: string(runes)}
+
+	case *object.Bytes:
+		value, err := sliceElements(left.Value, slice)
+		if err != nil {
+			return err
+		}
+		return &object.Bytes{Value: value}
+
+	default:
+		return newError("index operator not supported: %s", left.Type())
+	}
+}
+
+func evalSliceAssignExpression(left object.Object, slice *object.Slice, val object.Object) object.Object {
+	list, ok := left.(*object.List)
+	if !ok {
+		return newError("'%s' object does not support slice assignment", typeName(left))
+	}
+
+	values, err := iterate(val)
+	if err != nil {
+		return newError("can only assign an iterable")
+	}
+	// Copy first so that a list can be assigned to a slice of itself
+	values = append([]object.Object{}, values...)
+
+	start, _, step, err := resolveSlice(slice, int64(len(list.Elements)))
+	if err != nil {
+		return err
+	}
+	indices, _ := sliceIndices(slice, int64(len(list.Elements)))
+
+	// A slice of neighbouring positions can be replaced by a sequence of a different length
+	if step == 1 {
+		end := start + int64(len(indices))
+		list.Elements = slices.Concat(list.Elements[:start], values, list.Elements[end:])
+		return list
+	}
+
+	if len(values) != len(indices) {
+		return newError("attempt to assign sequence of size %d to extended slice of size %d",
+			len(values), len(indices))
+	}
+	for i, idx := range indices {
+		list.Elements[idx] = values[i]
+	}
+	return list
+}
+
+/*
+Deletion
+*/
+func evalDelStatement(node *ast.DelStatement, env *object.Environment) object.Object {
+	for _, target := range node.Targets {
+		if result := evalDelete(target, env); isError(result) {
+			return result
+		}
+	}
+	return nil
+}
+
+func evalDelete(target ast.Expression, env *object.Environment) object.Object {
+	switch target := target.(type) {
+	case *ast.Identifier:
+		if env.Frozen() {
+			return newError("cannot delete '%s': the environment is frozen", target.Value)
+		}
+		if !env.Delete(target.Value) {
+			return newError("name '%s' is not defined", target.Value)
+		}
+		return nil
+
+	case *ast.AttributeExpression:
+		obj := Eval(target.Obj, env)
+		if isError(obj) {
+			return obj
+		}
+		instance, ok := obj.(*object.Instance)
+		if !ok {
+			return newError("'%s' object attribute '%s' cannot be deleted", typeName(obj), target.Attribute.Value)
+		}
+		if _, ok := instance.Attrs[target.Attribute.Value]; !ok {
+			return newError("'%s' object has no attribute '%s'", typeName(obj), target.Attribute.Value)
+		}
+		delete(instance.Attrs, target.Attribute.Value)
+		return nil
+
+	case *ast.IndexExpression:
+		left := Eval(target.Left, env)
+		if isError(left) {
+			return left
+		}
+		index := evalSubscript(target.Index, target.Colon, target.EndIndex, target.Step, env)
+		if isError(index) {
+			return index
+		}
+		return evalDeleteIndex(left, index)
+
+	default:
+		return newError("cannot delete %s", target.String())
+	}
+}
+
+func evalDeleteIndex(left, index object.Object) object.Object {
+	switch left := left.(type) {
+	case *object.Instance:
+		if result, ok := callMethod(left, "__delitem__", index); ok {
+			if isError(result) {
+				return result
+			}
+			return nil
+		}
+		return newError("'%s' object does not support item deletion", typeName(left))
+
+	case *object.List:
+		if slice, ok := index.(*object.Slice); ok {
+			return evalDeleteSlice(left, slice)
+		}
+		idx, ok := index.(*object.Integer)
+		if !ok {
+			return newError("list indices must be integers, not %s", typeName(index))
+		}
+		i := idx.Value
+		if i < 0 {
+			i += int64(len(left.Elements))
+		}
+		if i < 0 || i >= int64(len(left.Elements)) {
+			return newError("list assignment index out of range")
+		}
+		left.Elements = slices.Delete(left.Elements, int(i), int(i)+1)
+		return nil
+
+	case *object.Dict:
+		if left.Frozen {
+			return newError("TypeError: read-only dict does not support item deletion")
+		}
+		key, ok, err := dictKey(left, index)
+		if err != nil {
+			return err
+		}
+		if !ok {
+			return keyError(index)
+		}
+		removeKey(left.Pairs, key)
+		return nil
+
+	default:
+		return newError("'%s' object does not support item deletion", typeName(left))
+	}
+}
+
+func evalDeleteSlice(list *object.List, slice *object.Slice) object.Object {
+	indices, err := sliceIndices(slice, int64(len(list.Elements)))
+	if err != nil {
+		return err
+	}
+
+	remove := make(map[int64]bool, len(indices))
+	for _, idx := range indices {
+		remove[idx] = true
+	}
+
+	elements := make([]object.Object, 0, len(list.Elements)-len(indices))
+	for i, el := range list.Elements {
+		if !remove[int64(i)] {
+			elements = append(elements, el)
+		}
+	}
+	list.Elements = elements
+
+	return nil
+}
+
 func evalDictLiteral(node *ast.DictLiteral, env *object.Environment) object.Object {
-	pairs := make(map[object.HashKey]object.HashPair)
+	dict := &object.Dict{Pairs: make(map[object.HashKey]object.HashPair)}
 
-	for keyNode, valueNode := range node.Pairs {
+	for _, keyNode := range node.Keys {
+		valueNode := node.Pairs[keyNode]
 		key := Eval(keyNode, env)
 		if isError(key) {
 			return key
 		}
 
-		hashKey, ok := key.(object.Hashable)
-		if !ok {
-			return newError("unusable as hash key: %s", key.Type())
-		}
-
 		value := Eval(valueNode, env)
 		if isError(value) {
 			return value
 		}
 
-		hashed := hashKey.HashKey()
-		pairs[hashed] = object.HashPair{Key: key, Value: value}
+		if err := SetItem(dict, key, value); err != nil {
+			return err
+		}
 	}
 
-	return &object.Dict{Pairs: pairs}
+	return dict
 }
 
 func evalDictIndexExpression(hash, index object.Object) object.Object {
 	hashObject := hash.(*object.Dict)
 
-	key, ok := index.(object.Hashable)
-	if !ok {
-		return newError("unusable as hash key: %s", index.Type())
+	key, ok, err := dictKey(hashObject, index)
+	if err != nil {
+		return err
 	}
 
-	pair, ok := hashObject.Pairs[key.HashKey()]
+	pair := hashObject.Pairs[key]
 	if !ok {
-		return NULL
+		return keyError(index)
 	}
 
 	return pair.Value
 }
 
+// keyError reports a missing dict key, quoting it the way repr does
+func keyError(key object.Object) object.Object {
+	repr, err := objectRepr(key)
+	if err != nil {
+		return err
+	}
+	return newError("KeyError: %s", repr)
+}
+
 func evalDictIndexAssignExpression(dict, index, val object.Object) object.Object {
 	dictObject := dict.(*object.Dict)
-
-	key, ok := index.(object.Hashable)
-	if !ok {
-		return newError("unusable as dict key: %s", index.Type())
+	if dictObject.Frozen {
+		return newError("TypeError: read-only dict does not support item assignment")
 	}
 
-	dictObject.Pairs[key.HashKey()] = object.HashPair{Key: index, Value: val}
+	if err := SetItem(dictObject, index, val); err != nil {
+		return err
+	}
 
 	return dictObject
 }
 
-func applyObjectMethod(obj object.Object, method ast.Expression, args []object.Object) object.Object {
-	switch obj.(type) {
+func applyObjectMethod(runtime *object.Runtime, obj object.Object, method ast.Expression, args []object.Object, kwargs map[string]object.Object) object.Object {
+	fn := evalAttribute(obj, method.String())
+	if isError(fn) {
+		return fn
+	}
+
+	return callFunction(runtime, fn, args, kwargs)
+}
+
+func evalAttribute(obj object.Object, name string) object.Object {
+	var methods map[string]*object.BuiltinMethod
+
+	switch obj := obj.(type) {
+	case *object.Instance, *object.Class, *object.Super:
+		return evalClassAttribute(obj, name)
+
+	case *object.Module:
+		return evalModuleAttribute(obj, name)
+
+	case object.HostObject:
+		return obj.GetAttr(name)
+
+	case *object.Builtin:
+		if attr, ok := obj.Attrs[name]; ok {
+			return attr
+		}
 
 	case *object.List:
-		return listMethods[method.String()].Fn(obj, args...)
+		methods = listMethods
 
 	case *object.String:
-		return stringMethods[method.String()].Fn(obj, args...)
+		methods = stringMethods
+
+	case *object.Bytes:
+		methods = bytesMethods
 
 	case *object.Dict:
-		return dictMethods[method.String()].Fn(obj, args...)
+		methods = dictMethods
+		if obj.Frozen {
+			methods = frozenDictMethods
+		}
 
 	case *object.Set:
-		return setMethods[method.String()].Fn(obj, args...)
+		methods = setMethods
+		if obj.Frozen {
+			methods = frozensetMethods
+		}
 
-	default:
-		return newError("not a function: %s", obj.Type())
+	case *object.Slice:
+		switch name {
+		case "start":
+			return obj.Start
+		case "stop":
+			return obj.Stop
+		case "step":
+			return obj.Step
+		}
+		methods = sliceMethods
+	}
+
+	if method, ok := methods[name]; ok {
+		return &object.BoundMethod{Self: obj, Method: method}
 	}
+
+	return newError("%s object has no attribute '%s'", obj.Type(), name)
+}
+
+// Attributes lists the attribute and method names that can be looked up on obj,
+// in sorted order. The REPL uses it for tab completion
+func Attributes(obj object.Object) []string {
+	names := map[string]bool{}
+	addMethods := func(methods map[string]*object.BuiltinMethod) {
+		for name := range methods {
+			names[name] = true
+		}
+	}
+	addClass := func(cls *object.Class) {
+		for _, c := range cls.MRO {
+			for name := range c.Attrs {
+				names[name] = true
+			}
+		}
+	}
+
+	switch obj := obj.(type) {
+	case *object.Instance:
+		for name := range obj.Attrs {
+			names[name] = true
+		}
+		addClass(obj.Class)
+	case *object.Class:
+		addClass(obj)
+	case *object.Module:
+		for _, name := range obj.Env.Names() {
+			names[name] = true
+		}
+	case object.HostObject:
+		for _, name := range obj.AttrNames() {
+			names[name] = true
+		}
+	case *object.Builtin:
+		for name := range obj.Attrs {
+			names[name] = true
+		}
+	case *object.List:
+		addMethods(listMethods)
+	case *object.String:
+		addMethods(stringMethods)
+	case *object.Bytes:
+		addMethods(bytesMethods)
+	case *object.Dict:
+		if obj.Frozen {
+			addMethods(frozenDictMethods)
+		} else {
+			addMethods(dictMethods)
+		}
+	case *object.Set:
+		if obj.Frozen {
+			addMethods(frozensetMethods)
+		} else {
+			addMethods(setMethods)
+		}
+	case *object.Slice:
+		addMethods(sliceMethods)
+		names["start"], names["stop"], names["step"] = true, true, true
+	}
+
+	sorted := make([]string, 0, len(names))
+	for name := range names {
+		sorted = append(sorted, name)
+	}
+	sort.Strings(sorted)
+	return sorted
 }
 
 func evalInExpression(left, right object.Object) object.Object {
@@ -416,24 +1159,66 @@ func evalInExpression(left, right object.Object) object.Object {
 	case "LIST":
 		return searchList(left, right)
 
-	case "SET":
+	case "SET", "FROZENSET":
 		return searchSet(left, right)
 
+	case "TUPLE":
+		return searchList(left, &object.List{Elements: right.(*object.Tuple).Elements})
+
+	case "STRING":
+		substr, ok := left.(*object.String)
+		if !ok {
+			return newError("'in <string>' requires string as left operand, not %s", typeName(left))
+		}
+		return nativeBoolToBooleanObject(strings.Contains(right.(*object.String).Value, substr.Value))
+
+	case "DICT":
+		_, ok, err := dictKey(right.(*object.Dict), left)
+		if err != nil {
+			return err
+		}
+		return nativeBoolToBooleanObject(ok)
+
+	case "INSTANCE":
+		return searchInstance(left, right)
+
 	default:
 		return newError("cannot check if object of type %T contains an object", right.Type())
 	}
 }
 
+func isSet(obj object.Object) bool {
+	_, ok := obj.(*object.Set)
+	return ok
+}
+
+func isNumber(obj object.Object) bool {
+	return obj.Type() == object.INTEGER_OBJ || obj.Type() == object.FLOAT_OBJ
+}
+
+func isBooleanAndNumber(a, b object.Object) bool {
+	return a.Type() == object.BOOLEAN_OBJ && isNumber(b) || isNumber(a) && b.Type() == object.BOOLEAN_OBJ
+}
+
+// booleanToInteger returns 1 or 0 for a boolean, and any other object unchanged
+func booleanToInteger(obj object.Object) object.Object {
+	if b, ok := obj.(*object.Boolean); ok {
+		if b.Value {
+			return &object.Integer{Value: 1}
+		}
+		return &object.Integer{Value: 0}
+	}
+	return obj
+}
+
 func searchSet(target, obj object.Object) object.Object {
 	set := obj.(*object.Set)
 
-	hashKey, ok := target.(object.Hashable)
-	if !ok {
-		return newError("object cannot be hashed: %s", target.Type())
+	_, ok, err := setKey(set, target)
+	if err != nil {
+		return err
 	}
-	key := hashKey.HashKey()
 
-	_, ok = set.Values[key]
 	if ok {
 		return TRUE
 	}
@@ -443,112 +1228,146 @@ func searchSet(target, obj object.Object) object.Object {
 
 func searchList(target, obj object.Object) object.Object {
 	list := obj.(*object.List)
-	valType := target.Type()
 
 	for _, el := range list.Elements {
-		if el.Type() == valType {
-			switch target.(type) {
-			case *object.Integer:
-				if target.(*object.Integer).Value == el.(*object.Integer).Value {
-					return TRUE
-				}
-
-			case *object.Float:
-				if target.(*object.Float).Value == el.(*object.Float).Value {
-					return TRUE
-				}
-
-			case *object.Boolean:
-				if target.(*object.Boolean).Value == el.(*object.Boolean).Value {
-					return TRUE
-				}
-
-			case *object.String:
-				if target.(*object.String).Value == el.(*object.String).Value {
-					return TRUE
-				}
-
-			case *object.List:
-				if compareListsEqual(target.(*object.List), el.(*object.List)).Value {
-					return TRUE
-				}
-
-			case *object.Set:
-				if compareSetsEqual(target.(*object.Set), el.(*object.Set)).Value {
-					return TRUE
-				}
-
-			default:
-				return newError("cannot search list for object of type %T", target)
-			}
+		equal, err := valuesEqual(el, target)
+		if err != nil {
+			return err
+		}
+		if equal {
+			return TRUE
 		}
 	}
 
 	return FALSE
 }
 
-func compareListsEqual(l1, l2 *object.List) object.Boolean {
-	if len(l1.Elements) != len(l2.Elements) {
-		return *FALSE
+// searchInstance checks membership with __contains__, falling back to iterating
+// the instance and comparing each element
+func searchInstance(target, obj object.Object) object.Object {
+	if result, ok := callMethod(obj, "__contains__", target); ok {
+		return toBoolean(result)
 	}
 
-	for i := range l1.Elements {
-		if l1.Elements[i].Inspect() != l2.Elements[i].Inspect() {
-			return *FALSE
-		}
+	elements, err := iterate(obj)
+	if err != nil {
+		return newError("argument of type '%s' is not iterable", typeName(obj))
 	}
 
-	return *TRUE
-}
-
-func compareSetsEqual(s1, s2 *object.Set) object.Boolean {
-	for key, val := range s1.Values {
-		if s2.Values[key].Inspect() != val.Inspect() {
-			return *FALSE
-		}
-	}
-	for key, val := range s2.Values {
-		if s1.Values[key].Inspect() != val.Inspect() {
-			return *FALSE
+	for _, el := range elements {
+		result := toBoolean(evalInfixExpression("==", el, target))
+		if result != FALSE {
+			return result
 		}
 	}
 
-	return *TRUE
+	return FALSE
 }
 
 /*
 Loop Statements
 */
-func evalForLoop(node *ast.ForStatement, env *object.Environment) {
+func evalForLoop(node *ast.ForStatement, env *object.Environment) object.Object {
 	exp := Eval(node.Iterable, env)
-	iterable, ok := exp.(*object.List)
-	if !ok {
-		newError("Iterable passed to for loop must be list, got=%T", exp)
+	if isError(exp) {
+		return exp
+	}
+
+	elements, err := iterate(exp)
+	if err != nil {
+		return err
 	}
 
 	block := node.Body
 
 	iterator := node.Iterator.Value
-	for _, i := range iterable.Elements {
-		env.Set(iterator, i)
-		evalBlockStatement(block, env)
+	for _, i := range elements {
+		if err := env.Set(iterator, i); isError(err) {
+			return err
+		}
+		result := evalBlockStatement(block, env)
+		if isLoopExit(result) {
+			return result
+		}
 	}
+
+	return nil
 }
 
-func evalWhileLoop(node *ast.WhileStatement, env *object.Environment) {
-	exp := Eval(node.Condition, env)
+func evalWhileLoop(node *ast.WhileStatement, env *object.Environment) object.Object {
+	for {
+		exp := toBoolean(Eval(node.Condition, env))
+		if isError(exp) {
+			return exp
+		}
+		if exp == FALSE {
+			return nil
+		}
 
-	for isTruthy(exp) {
-		evalBlockStatement(node.Body, env)
-		exp = Eval(node.Condition, env)
+		result := evalBlockStatement(node.Body, env)
+		if isLoopExit(result) {
+			return result
+		}
 	}
 }
 
+// evalComprehension runs the for clauses of a comprehension as nested loops, calling
+// emit in the innermost loop whenever every condition holds
+func evalComprehension(clauses []*ast.ComprehensionClause, env *object.Environment, emit func(*object.Environment) object.Object) object.Object {
+	if len(clauses) == 0 {
+		return emit(env)
+	}
+	clause := clauses[0]
+
+	iterable := Eval(clause.Iterable, env)
+	if isError(iterable) {
+		return iterable
+	}
+	elements, err := iterate(iterable)
+	if err != nil {
+		return err
+	}
+
+	for _, el := range elements {
+		env.Set(clause.Iterator.Value, el)
+
+		keep := true
+		for _, cond := range clause.Conditions {
+			result := toBoolean(Eval(cond, env))
+			if isError(result) {
+				return result
+			}
+			if result == FALSE {
+				keep = false
+				break
+			}
+		}
+		if !keep {
+			continue
+		}
+
+		if result := evalComprehension(clauses[1:], env, emit); result != nil {
+			return result
+		}
+	}
+
+	return nil
+}
+
+// isLoopExit reports whether a loop body result must stop the loop and propagate
+func isLoopExit(result object.Object) bool {
+	if result == nil {
+		return false
+	}
+	rt := result.Type()
+	return rt == object.RETURN_VALUE_OBJ || rt == object.ERROR_OBJ
+}
+
 /*
 Conditional Expressions
 */
 func evalIfExpression(ie *ast.IfExpression, env *object.Environment) object.Object {
-	condition := Eval(ie.Condition, env)
+	condition := toBoolean(Eval(ie.Condition, env))
 
 	if isError(condition) {
 		return condition
@@ -567,10 +1386,8 @@ func isTruthy(obj object.Object) bool {
 	switch {
 	case obj == NULL:
 		return false
-	case obj == TRUE:
-		return true
-	case obj == FALSE:
-		return false
+	case obj.Type() == object.BOOLEAN_OBJ:
+		return obj.(*object.Boolean).Value
 	case obj.Type() == object.INTEGER_OBJ:
 		obj := obj.(*object.Integer)
 		if obj.Value == 0 {
@@ -590,8 +1407,15 @@ Prefix Expressions
 func evalPrefixExpression(operator string, right object.Object) object.Object {
 	switch operator {
 	case "!":
+		right = toBoolean(right)
+		if isError(right) {
+			return right
+		}
 		return evalBangOperatorExpression(right)
 	case "-":
+		if result, ok := callMethod(right, "__neg__"); ok {
+			return result
+		}
 		return evalMinusPrefixOperatorExpression(right)
 	default:
 		return newError("unknown operator: %s%s", operator, right.Type())
@@ -631,12 +1455,38 @@ Infix Expressions
 */
 func evalInfixExpression(operator string, left, right object.Object) object.Object {
 	switch {
+	case left.Type() == object.INSTANCE_OBJ || right.Type() == object.INSTANCE_OBJ:
+		return evalInstanceInfixExpression(operator, left, right)
+	case (operator == "==" || operator == "!=") && isBooleanAndNumber(left, right):
+		// As in Python, true and false equal 1 and 0, so that they can share
+		// dict keys with the numbers
+		return evalInfixExpression(operator, booleanToInteger(left), booleanToInteger(right))
 	case left.Type() == object.INTEGER_OBJ && right.Type() == object.INTEGER_OBJ:
 		return evalIntegerInfixExpression(operator, left, right)
 	case left.Type() == object.FLOAT_OBJ || right.Type() == object.FLOAT_OBJ:
 		return evalFloatInfixExpression(operator, left, right)
 	case left.Type() == object.STRING_OBJ && right.Type() == object.STRING_OBJ:
 		return evalStringInfixExpression(operator, left, right)
+	case operator == "*" && left.Type() == object.STRING_OBJ && right.Type() == object.INTEGER_OBJ:
+		return repeatString(left.(*object.String), right.(*object.Integer))
+	case operator == "*" && left.Type() == object.INTEGER_OBJ && right.Type() == object.STRING_OBJ:
+		return repeatString(right.(*object.String), left.(*object.Integer))
+	case left.Type() == object.BOOLEAN_OBJ && right.Type() == object.BOOLEAN_OBJ:
+		return evalBooleanInfixExpression(operator, left, right)
+	case left.Type() == object.BYTES_OBJ && right.Type() == object.BYTES_OBJ:
+		return evalBytesInfixExpression(operator, left, right)
+	case left.Type() == object.TUPLE_OBJ && right.Type() == object.TUPLE_OBJ:
+		return evalTupleInfixExpression(operator, left, right)
+	case left.Type() == object.LIST_OBJ && right.Type() == object.LIST_OBJ:
+		return evalListInfixExpression(operator, left, right)
+	case operator == "*" && left.Type() == object.LIST_OBJ && right.Type() == object.INTEGER_OBJ:
+		return repeatList(left.(*object.List), right.(*object.Integer))
+	case operator == "*" && left.Type() == object.INTEGER_OBJ && right.Type() == object.LIST_OBJ:
+		return repeatList(right.(*object.List), left.(*object.Integer))
+	case left.Type() == object.DICT_OBJ && right.Type() == object.DICT_OBJ:
+		return evalDictInfixExpression(operator, left, right)
+	case isSet(left) && isSet(right):
+		return evalSetInfixExpression(operator, left, right)
 	case operator == "==":
 		return nativeBoolToBooleanObject(left == right)
 	case operator == "!=":
@@ -664,11 +1514,34 @@ func evalIntegerInfixExpression(operator string, left, right object.Object) obje
 	case "*":
 		return &object.Integer{Value: leftVal * rightVal}
 	case "/":
+		if rightVal == 0 {
+			return newError("integer division or modulo by zero")
+		}
 		return &object.Integer{Value: leftVal / rightVal}
+	case "%":
+		if rightVal == 0 {
+			return newError("integer division or modulo by zero")
+		}
+		// The result takes the sign of the divisor, as in Python
+		mod := leftVal % rightVal
+		if mod != 0 && (mod < 0) != (rightVal < 0) {
+			mod += rightVal
+		}
+		return &object.Integer{Value: mod}
+	case "|":
+		return &object.Integer{Value: leftVal | rightVal}
+	case "&":
+		return &object.Integer{Value: leftVal & rightVal}
+	case "^":
+		return &object.Integer{Value: leftVal ^ rightVal}
 	case "<":
 		return nativeBoolToBooleanObject(leftVal < rightVal)
 	case ">":
 		return nativeBoolToBooleanObject(leftVal > rightVal)
+	case "<=":
+		return nativeBoolToBooleanObject(leftVal <= rightVal)
+	case ">=":
+		return nativeBoolToBooleanObject(leftVal >= rightVal)
 	case "==":
 		return nativeBoolToBooleanObject(leftVal == rightVal)
 	case "!=":
@@ -705,11 +1578,27 @@ func evalFloatInfixExpression(operator string, left, right object.Object) object
 	case "*":
 		return &object.Float{Value: leftVal * rightVal}
 	case "/":
+		if rightVal == 0 {
+			return newError("float division by zero")
+		}
 		return &object.Float{Value: leftVal / rightVal}
+	case "%":
+		if rightVal == 0 {
+			return newError("float modulo")
+		}
+		mod := math.Mod(leftVal, rightVal)
+		if mod != 0 && (mod < 0) != (rightVal < 0) {
+			mod += rightVal
+		}
+		return &object.Float{Value: mod}
 	case "<":
 		return nativeBoolToBooleanObject(leftVal < rightVal)
 	case ">":
 		return nativeBoolToBooleanObject(leftVal > rightVal)
+	case "<=":
+		return nativeBoolToBooleanObject(leftVal <= rightVal)
+	case ">=":
+		return nativeBoolToBooleanObject(leftVal >= rightVal)
 	case "==":
 		return nativeBoolToBooleanObject(leftVal == rightVal)
 	case "!=":
@@ -741,11 +1630,236 @@ func evalStringInfixExpression(operator string, left, right object.Object) objec
 		val := leftVal != rightVal
 		return &object.Boolean{Value: val}
 
+	// UTF-8 byte order matches code point order, as Python compares strings
+	case "<":
+		leftVal := left.(*object.String).Value
+		rightVal := right.(*object.String).Value
+		return nativeBoolToBooleanObject(leftVal < rightVal)
+
+	case ">":
+		leftVal := left.(*object.String).Value
+		rightVal := right.(*object.String).Value
+		return nativeBoolToBooleanObject(leftVal > rightVal)
+
+	case "<=":
+		leftVal := left.(*object.String).Value
+		rightVal := right.(*object.String).Value
+		return nativeBoolToBooleanObject(leftVal <= rightVal)
+
+	case ">=":
+		leftVal := left.(*object.String).Value
+		rightVal := right.(*object.String).Value
+		return nativeBoolToBooleanObject(leftVal >= rightVal)
+
+	default:
+		return newError("unknown operator: %s %s %s",
+			left.Type(), operator, right.Type())
+	}
+
+}
+
+func evalBytesInfixExpression(operator string, left, right object.Object) object.Object {
+	leftVal := left.(*object.Bytes).Value
+	rightVal := right.(*object.Bytes).Value
+
+	switch operator {
+	case "+":
+		value := append(append([]byte{}, leftVal...), rightVal...)
+		return &object.Bytes{Value: value}
+	case "==":
+		return nativeBoolToBooleanObject(bytes.Equal(leftVal, rightVal))
+	case "!=":
+		return nativeBoolToBooleanObject(!bytes.Equal(leftVal, rightVal))
+	default:
+		return newError("unknown operator: %s %s %s",
+			left.Type(), operator, right.Type())
+	}
+}
+
+func evalTupleInfixExpression(operator string, left, right object.Object) object.Object {
+	leftVal := left.(*object.Tuple).Elements
+	rightVal := right.(*object.Tuple).Elements
+
+	switch operator {
+	case "+":
+		elements := append(append([]object.Object{}, leftVal...), rightVal...)
+		return &object.Tuple{Elements: elements}
+	case "==", "!=", "<", ">", "<=", ">=":
+		return compareValues(operator, left, right, 0)
+	default:
+		return newError("unknown operator: %s %s %s",
+			left.Type(), operator, right.Type())
+	}
+}
+
+func evalListInfixExpression(operator string, left, right object.Object) object.Object {
+	leftVal := left.(*object.List).Elements
+	rightVal := right.(*object.List).Elements
+
+	switch operator {
+	case "+":
+		return &object.List{Elements: slices.Concat(leftVal, rightVal)}
+	case "==", "!=", "<", ">", "<=", ">=":
+		return compareValues(operator, left, right, 0)
 	default:
 		return newError("unknown operator: %s %s %s",
 			left.Type(), operator, right.Type())
 	}
+}
+
+func repeatList(list *object.List, count *object.Integer) object.Object {
+	elements := []object.Object{}
+	for i := int64(0); i < count.Value; i++ {
+		elements = append(elements, list.Elements...)
+	}
+	return &object.List{Elements: elements}
+}
+
+// maxCompareDepth is how deeply a comparison may descend into nested containers,
+// so that containers holding themselves raise a RecursionError as in Python
+// instead of recursing forever
+const maxCompareDepth = DefaultMaxDepth
+
+// compareValues compares left and right at depth containers below the outermost
+// comparison. Lists, tuples and dicts are compared here so that the depth can be
+// counted, and everything else is left to evalInfixExpression
+func compareValues(operator string, left, right object.Object, depth int) object.Object {
+	if depth > maxCompareDepth {
+		return newError("RecursionError: maximum recursion depth exceeded in comparison")
+	}
+
+	switch left := left.(type) {
+	case *object.List:
+		if right, ok := right.(*object.List); ok {
+			return compareSequences(operator, left.Elements, right.Elements, depth+1)
+		}
+	case *object.Tuple:
+		if right, ok := right.(*object.Tuple); ok {
+			return compareSequences(operator, left.Elements, right.Elements, depth+1)
+		}
+	case *object.Dict:
+		if right, ok := right.(*object.Dict); ok && (operator == "==" || operator == "!=") {
+			return compareDicts(operator, left, right, depth+1)
+		}
+	}
+	return evalInfixExpression(operator, left, right)
+}
+
+// compareSequences compares lists or tuples lexicographically: the first pair of
+// unequal elements decides the ordering, and otherwise the shorter sequence is smaller
+func compareSequences(operator string, left, right []object.Object, depth int) object.Object {
+	for i := 0; i < len(left) && i < len(right); i++ {
+		equal, err := equalAt(left[i], right[i], depth)
+		if err != nil {
+			return err
+		}
+		if equal {
+			continue
+		}
+
+		switch operator {
+		case "==":
+			return FALSE
+		case "!=":
+			return TRUE
+		default:
+			if !orderable(left[i], right[i]) {
+				return unorderableError(operator, left[i], right[i])
+			}
+			return compareValues(operator, left[i], right[i], depth)
+		}
+	}
+
+	return evalIntegerInfixExpression(operator,
+		&object.Integer{Value: int64(len(left))}, &object.Integer{Value: int64(len(right))})
+}
+
+// evalDictInfixExpression compares dicts by value: they are equal when they hold
+// the same keys and the values under each key are equal
+func evalDictInfixExpression(operator string, left, right object.Object) object.Object {
+	leftDict := left.(*object.Dict)
+	rightDict := right.(*object.Dict)
 
+	switch operator {
+	case "|":
+		dict := leftDict.Copy()
+		for _, pair := range rightDict.Items() {
+			if err := SetItem(dict, pair.Key, pair.Value); err != nil {
+				return err
+			}
+		}
+		return dict
+	case "==", "!=":
+		return compareValues(operator, left, right, 0)
+	default:
+		return newError("unknown operator: %s %s %s",
+			left.Type(), operator, right.Type())
+	}
+}
+
+func compareDicts(operator string, left, right *object.Dict, depth int) object.Object {
+	equal := len(left.Pairs) == len(right.Pairs)
+	for _, pair := range left.Pairs {
+		if !equal {
+			break
+		}
+		key, ok, err := dictKey(right, pair.Key)
+		if err != nil {
+			return err
+		}
+		if !ok {
+			equal = false
+			break
+		}
+		if equal, err = equalAt(pair.Value, right.Pairs[key].Value, depth); err != nil {
+			return err
+		}
+	}
+	return nativeBoolToBooleanObject(equal == (operator == "=="))
+}
+
+// valuesEqual compares two objects with ==, honouring __eq__ on instances. As in
+// Python, an object is always equal to itself here, which also stops a container
+// that holds itself from being compared forever
+func valuesEqual(a, b object.Object) (bool, object.Object) {
+	return equalAt(a, b, 0)
+}
+
+func equalAt(a, b object.Object, depth int) (bool, object.Object) {
+	if a == b {
+		return true, nil
+	}
+	result := toBoolean(compareValues("==", a, b, depth))
+	if isError(result) {
+		return false, result
+	}
+	return result == TRUE, nil
+}
+
+// evalBooleanInfixExpression compares by value, since booleans created outside the
+// evaluator, such as by native modules, are not the TRUE and FALSE singletons.
+// Ordering treats false as less than true, as in Python
+func evalBooleanInfixExpression(operator string, left, right object.Object) object.Object {
+	leftVal := left.(*object.Boolean).Value
+	rightVal := right.(*object.Boolean).Value
+
+	switch operator {
+	case "==":
+		return nativeBoolToBooleanObject(leftVal == rightVal)
+	case "!=":
+		return nativeBoolToBooleanObject(leftVal != rightVal)
+	case "<":
+		return nativeBoolToBooleanObject(!leftVal && rightVal)
+	case ">":
+		return nativeBoolToBooleanObject(leftVal && !rightVal)
+	case "<=":
+		return nativeBoolToBooleanObject(!leftVal || rightVal)
+	case ">=":
+		return nativeBoolToBooleanObject(leftVal || !rightVal)
+	default:
+		return newError("unknown operator: %s %s %s",
+			left.Type(), operator, right.Type())
+	}
 }
 
 /*
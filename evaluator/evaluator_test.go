@@ -1,9 +1,16 @@
 package evaluator
 
 import (
+	"errors"
+	"io"
+	"math"
+	"os"
+	"path/filepath"
 	"simpyl/lexer"
+	"simpyl/native"
 	"simpyl/object"
 	"simpyl/parser"
+	"strings"
 	"testing"
 )
 
@@ -141,6 +148,11 @@ func TestEvalBooleanExpression(t *testing.T) {
 		{"1 != 1", false},
 		{"1 == 2", false},
 		{"1 != 2", true},
+		{"true == 1", true},
+		{"1.0 == true", true},
+		{"false == 0.0", true},
+		{"0.0 == true", false},
+		{"true != 2", true},
 	}
 
 	for _, tt := range tests {
@@ -163,6 +175,111 @@ func TestStringLiteral(t *testing.T) {
 	}
 }
 
+func TestUnicodeStrings(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected interface{}
+	}{
+		{`len("José")`, 4},
+		{`len("日本語")`, 3},
+		{`"José"[3]`, "é"},
+		{`"José"[-1]`, "é"},
+		{`"naïve"[1:4]`, "aïv"},
+		{`"naïve"[-3:10]`, "ïve"},
+		{`"abc"[2:1]`, ""},
+		{`out = ""
+for ch in "añb":
+	out = ch + out
+out`, "bña"},
+		{`"ñ" in "España"`, true},
+		{`"x" in "España"`, false},
+		{`ord("é")`, 233},
+		{`ord("😀")`, 128512},
+		{`chr(233)`, "é"},
+		{`chr(ord("a") + 1)`, "b"},
+		{`café = "ok"
+café`, "ok"},
+		{`"{:>5}|".format("é")`, "    é|"},
+	}
+
+	for _, tt := range tests {
+		evaluated := testEval(tt.input)
+		switch expected := tt.expected.(type) {
+		case int:
+			testIntegerObject(t, evaluated, int64(expected))
+		case bool:
+			testBooleanObject(t, evaluated, expected)
+		case string:
+			testStringObject(t, evaluated, expected)
+		}
+	}
+}
+
+func TestBytesLiterals(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected interface{}
+	}{
+		{`len(b"a\x00b")`, 3},
+		{`b"AB"[0]`, 65},
+		{`b"AB"[-1]`, 66},
+		{`b"ab" + b"cd" == b"abcd"`, true},
+		{`b"ab" != b"ab"`, false},
+		{`b"caf\xc3\xa9".decode()`, "café"},
+		{`str(b"it's\n\xff")`, `b"it's\n\xff"`},
+		{`str(b'a"b')`, `b'a"b'`},
+		{`b"\xff".decode("utf-8")`, "'utf-8' codec can't decode byte 0xff in position 0"},
+	}
+
+	for _, tt := range tests {
+		evaluated := testEval(tt.input)
+		switch expected := tt.expected.(type) {
+		case int:
+			testIntegerObject(t, evaluated, int64(expected))
+		case bool:
+			testBooleanObject(t, evaluated, expected)
+		case string:
+			if errObj, ok := evaluated.(*object.Error); ok {
+				if errObj.Message != expected {
+					t.Errorf("wrong error message. expected=%q, got=%q", expected, errObj.Message)
+				}
+				continue
+			}
+			testStringObject(t, evaluated, expected)
+		}
+	}
+}
+
+func TestTuples(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected interface{}
+	}{
+		{"len((1, 2, 3))", 3},
+		{"len(())", 0},
+		{"(1, 2)[-1]", 2},
+		{"(1, 2) + (3,) == (1, 2, 3)", true},
+		{"(1, (2, 3)) == (1, (2, 4))", false},
+		{"2 in (1, 2)", true},
+		{"{(1, 2): 5}[(1, 2)]", 5},
+		{"str((1,))", "(1,)"},
+		{"str((1, 'a'))", "(1, 'a')"},
+		{"tuple([1, 2])[0]", 1},
+	}
+
+	for _, tt := range tests {
+		evaluated := testEval(tt.input)
+		switch expected := tt.expected.(type) {
+		case int:
+			testIntegerObject(t, evaluated, int64(expected))
+		case bool:
+			testBooleanObject(t, evaluated, expected)
+		case string:
+			testStringObject(t, evaluated, expected)
+		}
+	}
+}
+
 func TestListLiterals(t *testing.T) {
 	input := "[1, 2 * 2, 3 + 3]"
 
@@ -219,10 +336,6 @@ func TestListIndexExpressions(t *testing.T) {
 			"let myArray = [1, 2, 3]; let i = myArray[0]; myArray[i]",
 			2,
 		},
-		{
-			"[1, 2, 3][3]",
-			nil,
-		},
 		{
 			"[1, 2, 3][-1]",
 			3,
@@ -249,6 +362,136 @@ return arr[0]`
 	testIntegerObject(t, evaluated, 3)
 }
 
+func TestListSlicing(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{"str([1, 2, 3, 4, 5, 6][1:4])", "[2, 3, 4]"},
+		{"str([1, 2, 3, 4, 5, 6][0:6:2])", "[1, 3, 5]"},
+		{"str([1, 2, 3, 4, 5, 6][5:0:-2])", "[6, 4, 2]"},
+		{"str([1, 2, 3, 4, 5, 6][-1:-7:-1])", "[6, 5, 4, 3, 2, 1]"},
+		{"str([1, 2, 3][2:1])", "[]"},
+		{"str([1, 2, 3][1:100])", "[2, 3]"},
+		{"a = [1, 2, 3]\nb = a[0:3]\nb[0] = 9\nstr(a)", "[1, 2, 3]"},
+		{"a = [1, 2, 3, 4]\na[1:3] = [9, 9, 9]\nstr(a)", "[1, 9, 9, 9, 4]"},
+		{"a = [1, 2, 3]\na[1:1] = (7, 8)\nstr(a)", "[1, 7, 8, 2, 3]"},
+		{"a = [1, 2, 3]\na[0:3] = a\nstr(a)", "[1, 2, 3]"},
+		{"a = [0, 1, 2, 3, 4, 5]\na[0:6:2] = ['a', 'b', 'c']\nstr(a)", "['a', 1, 'b', 3, 'c', 5]"},
+		{"a = [0, 1, 2, 3, 4, 5, 6]\ndel a[0:7:3]\nstr(a)", "[1, 2, 4, 5]"},
+		{"a = [0, 1, 2, 3]\ndel a[1:3]\nstr(a)", "[0, 3]"},
+		{"a = [0, 1, 2, 3]\ndel a[3:0:-2]\nstr(a)", "[0, 2]"},
+		{"a = [0, 1, 2]\ndel a[-1], a[0]\nstr(a)", "[1]"},
+	}
+
+	for _, tt := range tests {
+		testStringObject(t, testEval(tt.input), tt.expected)
+	}
+}
+
+func TestSequenceSlicing(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected interface{}
+	}{
+		{"str([1, 2, 3, 4][:2])", "[1, 2]"},
+		{"str([1, 2, 3, 4][2:])", "[3, 4]"},
+		{"str([1, 2, 3, 4][::-1])", "[4, 3, 2, 1]"},
+		{"str([1, 2, 3, 4][-3:])", "[2, 3, 4]"},
+		{"str([1, 2, 3, 4][:-5:-2])", "[4, 2]"},
+		{`"héllo"[::-1]`, "olléh"},
+		{`"héllo"[-3:]`, "llo"},
+		{`"héllo"[:2]`, "hé"},
+		{`"abcdef"[1::2]`, "bdf"},
+		{`"abc"[5:]`, ""},
+		{"str((1, 2, 3)[::-1])", "(3, 2, 1)"},
+		{"str((1, 2, 3)[1:])", "(2, 3)"},
+		{"str((1, 2, 3)[:1])", "(1,)"},
+		{`str(b"abcd"[1:3])`, "b'bc'"},
+		{"str(range(10)[::3])", "[0, 3, 6, 9]"},
+		{"str(range(2, 10, 3))", "[2, 5, 8]"},
+		{"str(range(5, 0, -2)[1:])", "[3, 1]"},
+		{"range(-4, 0)[-1]", -1},
+		{"str([0, 1, 2, 3, 4][slice(1, 4, 2)])", "[1, 3]"},
+		{`"abcdef"[slice(3)]`, "abc"},
+		{"slice(1, 5, 2).stop", 5},
+		{"str(slice(2).indices(5))", "(0, 2, 1)"},
+		{"str(slice(-1, -9, -1).indices(5))", "(4, -1, -1)"},
+		{"str(slice(1, 2, 3))", "slice(1, 2, 3)"},
+		{"a = [0, 1, 2, 3]\na[:2] = [9]\nstr(a)", "[9, 2, 3]"},
+		{"a = [0, 1, 2, 3]\na[::-2] = 'xy'\nstr(a)", "[0, 'y', 2, 'x']"},
+		{"a = [0, 1, 2, 3]\na[slice(2)] = []\nstr(a)", "[2, 3]"},
+		{"a = [0, 1, 2, 3]\ndel a[::2]\nstr(a)", "[1, 3]"},
+		{"a = [0, 1, 2, 3]\ndel a[slice(1, 3)]\nstr(a)", "[0, 3]"},
+		{`class C:
+	def __getitem__(self, key):
+		return key
+str(C()[1:])`, "slice(1, null, null)"},
+	}
+
+	for _, tt := range tests {
+		evaluated := testEval(tt.input)
+		switch expected := tt.expected.(type) {
+		case int:
+			testIntegerObject(t, evaluated, int64(expected))
+		case string:
+			testStringObject(t, evaluated, expected)
+		}
+	}
+}
+
+func TestListMethods(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected interface{}
+	}{
+		{"a = [1, 2]\na.insert(0, 0)\nstr(a)", "[0, 1, 2]"},
+		{"a = [1, 2]\na.insert(100, 3)\na.insert(-1, 9)\nstr(a)", "[1, 2, 9, 3]"},
+		{"a = [1]\na.extend((2, 3))\na.extend('ab')\nstr(a)", "[1, 2, 3, 'a', 'b']"},
+		{"[1, 2, 3, 2].index(2)", 1},
+		{"[1, 2, 3, 2].index(2, 2)", 3},
+		{"[1, 2.0, 2, [2]].count(2)", 2},
+		{"[[1, 2], [1, 2]].count([1, 2])", 2},
+		{"a = [1, 2, 1]\na.remove(1)\nstr(a)", "[2, 1]"},
+		{"a = [1, 2]\na.clear()\nlen(a)", 0},
+		{"a = [3, 1, 2]\na.sort(reverse=true)\nstr(a)", "[3, 2, 1]"},
+		{"a = ['bb', 'a', 'ccc', 'dd']\na.sort(key=len)\nstr(a)", "['a', 'bb', 'dd', 'ccc']"},
+		{"a = ['bb', 'a', 'ccc', 'dd']\na.sort(key=len, reverse=true)\nstr(a)", "['ccc', 'bb', 'dd', 'a']"},
+		{"str([1, 2] + [3])", "[1, 2, 3]"},
+		{"str([0] * 3)", "[0, 0, 0]"},
+		{"str(2 * [1, 2])", "[1, 2, 1, 2]"},
+		{"str([1] * -1)", "[]"},
+		{"[1, 2] < [1, 3]", true},
+		{"[1, 2] < [1, 2, 0]", true},
+		{"[2] > [1, 9]", true},
+		{"(1, 2) < (1, 3)", true},
+		{"[[1]] == [[1]]", true},
+		{"[1.0] == [1]", true},
+		{"[1, 2] != [1, 2]", false},
+		{"[{'a': 1}] == [{'a': 1}]", true},
+		{"{'a': [1]} == {'a': [2]}", false},
+		{"[1, 2] in [[1, 2]]", true},
+		{"str(list())", "[]"},
+		{"str(list('abc'))", "['a', 'b', 'c']"},
+		{"str(list(range(2, 10, 3)))", "[2, 5, 8]"},
+		{"str(list({'a': 1}))", "['a']"},
+		{"str(sorted(list({3, 1, 2})))", "[1, 2, 3]"},
+		{"a = [1, 2]\nb = list(a)\nb.append(3)\nstr(a)", "[1, 2]"},
+	}
+
+	for _, tt := range tests {
+		evaluated := testEval(tt.input)
+		switch expected := tt.expected.(type) {
+		case int:
+			testIntegerObject(t, evaluated, int64(expected))
+		case bool:
+			testBooleanObject(t, evaluated, expected)
+		case string:
+			testStringObject(t, evaluated, expected)
+		}
+	}
+}
+
 func TestDictLiterals(t *testing.T) {
 	input := `two = "two"
 {
@@ -297,18 +540,10 @@ func TestDictIndexExpressions(t *testing.T) {
 			`{"foo": 5}["foo"]`,
 			5,
 		},
-		{
-			`{"foo": 5}["bar"]`,
-			nil,
-		},
 		{
 			`let key = "foo"; {"foo": 5}[key]`,
 			5,
 		},
-		{
-			`{}["foo"]`,
-			nil,
-		},
 		{
 			`{5: 5}[5]`,
 			5,
@@ -334,6 +569,172 @@ func TestDictIndexExpressions(t *testing.T) {
 	}
 }
 
+func TestDictMethods(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected interface{}
+	}{
+		{`d = dict(a=1, b=2)
+d["a"] + d["b"]`, 3},
+		{`dict([("x", 1), ["y", 2]])["y"]`, 2},
+		{`dict({"a": 1}, a=2)["a"]`, 2},
+		{`len(dict())`, 0},
+		{`len({"a": 1, "b": 2})`, 2},
+		{`"a" in {"a": 1}`, true},
+		{`2 in {"a": 1}`, false},
+		{`{"x": 1}.get("x")`, 1},
+		{`{"x": 1}.get("q", 5)`, 5},
+		{`str({}.get("q"))`, "null"},
+		{`d = {"a": 1}
+d.setdefault("a", 9) + d.setdefault("c", 3) + d["c"]`, 7},
+		{`d = {"a": 1}
+d.update({"a": 10}, z=26)
+d["a"] + d["z"]`, 36},
+		{`d = {"a": 1}
+d.update([("b", 2)])
+len(d)`, 2},
+		{`d = {"a": 1}
+f = d.copy()
+f["a"] = 0
+d["a"]`, 1},
+		{`dict.fromkeys(["k", "l"], 0) == {"k": 0, "l": 0}`, true},
+		{`len({}.fromkeys("ab"))`, 2},
+		{`({"a": 1} | {"a": 2, "b": 3}) == {"a": 2, "b": 3}`, true},
+		{`5 | 3`, 7},
+		{`d = {"a": 1, "b": 2}
+del d["a"]
+d == {"b": 2}`, true},
+		{`{"b": 3}.pop("b")`, 3},
+		{`{}.pop("q", 7)`, 7},
+		{`d = {"p": 1}
+str(d.popitem()) + str(len(d))`, "('p', 1)0"},
+		{`d = {"a": 1}
+d.clear()
+d == {}`, true},
+		{`total = 0
+for k in {"a": 1, "b": 2}:
+	total = total + len(k)
+total`, 2},
+		{`str({"z": 1, "a": 2, "m": 3})`, "{'z': 1, 'a': 2, 'm': 3}"},
+		{`str(list({"z": 1, "a": 2, "m": 3}))`, "['z', 'a', 'm']"},
+		{`d = {"z": 1, "a": 2}
+d["z"] = 5
+d["b"] = 6
+str(d.items())`, "[['z', 5], ['a', 2], ['b', 6]]"},
+		{`d = {"z": 1, "a": 2}
+del d["z"]
+d["z"] = 3
+str(d.keys()) + str(d.values())`, "['a', 'z'][2, 3]"},
+		{`d = {"x": 1, "y": 2, "z": 3}
+str(d.popitem()) + str(d.popitem()) + str(d)`, "('z', 3)('y', 2){'x': 1}"},
+		{`d = {"b": 1}
+d.setdefault("a", 2)
+d.update(c=3)
+str(d)`, "{'b': 1, 'a': 2, 'c': 3}"},
+		{`str({"b": 1, "a": 2} | {"c": 3, "b": 4})`, "{'b': 4, 'a': 2, 'c': 3}"},
+		{`str({"q": 1, "p": 2}.copy())`, "{'q': 1, 'p': 2}"},
+		{`str({1: "a", 1.0: "b", true: "c"})`, "{1: 'c'}"},
+	}
+
+	for _, tt := range tests {
+		evaluated := testEval(tt.input)
+		switch expected := tt.expected.(type) {
+		case int:
+			testIntegerObject(t, evaluated, int64(expected))
+		case bool:
+			testBooleanObject(t, evaluated, expected)
+		case string:
+			testStringObject(t, evaluated, expected)
+		}
+	}
+}
+
+func TestSets(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected interface{}
+	}{
+		{"({1, 2, 3} | {3, 4}) == {1, 2, 3, 4}", true},
+		{"({1, 2, 3} & {3, 4}) == {3}", true},
+		{"({1, 2, 3} - {3, 4}) == {1, 2}", true},
+		{"({1, 2, 3} ^ {3, 4}) == {1, 2, 4}", true},
+		{"{1, 2} <= {1, 2, 3}", true},
+		{"{1, 2} < {1, 2}", false},
+		{"{1, 2} >= {1}", true},
+		{"{1, 2} > {1, 2}", false},
+		{"{1, 2} == {2, 1}", true},
+		{"len({1, 2, 2})", 2},
+		{"len({1.1, 1.2, 0.9})", 3},
+		{"len({1, 1.0, true})", 1},
+		{"str({1, 1.0, true})", "{1}"},
+		{"1.0 in {1, 2}", true},
+		{"true in {1, 2}", true},
+		{"0 in {false}", true},
+		{"2.5 in {2, 3}", false},
+		{"(1.0,) in {(1,)}", true},
+		{`{1: "a"}[1.0]`, "a"},
+		{`{0: "a"}[false]`, "a"},
+		{`{1.1: "a"}.get(1.2, "none")`, "none"},
+		{"len({2.0, 2.0, 2.5})", 2},
+		{"1.4 in {1.0}", false},
+		{"1.0 in {1.0}", true},
+		{`{1.1: "a", 1.2: "b"}[1.2]`, "b"},
+		{"len({(1.1,), (1.2,)})", 2},
+		{"str(set())", "set()"},
+		{"str(frozenset([1]))", "frozenset({1})"},
+		{"str({1})", "{1}"},
+		{"str({3, 1, 2})", "{1, 2, 3}"},
+		{"str({10, 2.5, -1})", "{-1, 2.5, 10}"},
+		{`str({"b", 2, "a", 1})`, "{1, 2, 'a', 'b'}"},
+		{`str(frozenset(["y", "x"]))`, "frozenset({'x', 'y'})"},
+		{`str(list({"b", "c", "a"}))`, "['a', 'b', 'c']"},
+		{"set([1, 1, 2]) == {1, 2}", true},
+		{`{frozenset([1, 2]): "x"}[frozenset([2, 1])]`, "x"},
+		{"frozenset([1]) | {2} == frozenset([1, 2])", true},
+		{"str(frozenset([1]) | {2})", "frozenset({1, 2})"},
+		{"2 in frozenset([2])", true},
+		{"{1, 2}.symmetric_difference([2, 9]) == {1, 9}", true},
+		{"{1, 2}.issubset([1, 2, 3])", true},
+		{"{1, 2}.issuperset((1,))", true},
+		{"{1, 2}.isdisjoint({7})", true},
+		{"{1, 2, 3}.union([9], {8}) == {1, 2, 3, 8, 9}", true},
+		{"{1, 2, 3}.difference([1], [2]) == {3}", true},
+		{"{1, 2, 3}.intersection([1, 2], [2]) == {2}", true},
+		{`c = {1, 2}
+c.update([3], (4,))
+c.difference_update({1})
+c.intersection_update({2, 3, 4, 5})
+c.symmetric_difference_update({4, 6})
+c == {2, 3, 6}`, true},
+		{`e = {1}
+e.remove(1)
+e.discard(5)
+e.add(4)
+e.pop()`, 4},
+		{"{x * x for x in range(5) if x % 2 == 0} == {0, 4, 16}", true},
+		{"{x + y for x in [1, 2] for y in [10, 20]} == {11, 12, 21, 22}", true},
+		{"x = 5\n{x for x in [1]}\nx", 5},
+		{"6 & 3", 2},
+		{"6 ^ 3", 5},
+		{"2 <= 3", true},
+		{"3.5 >= 4", false},
+		{`"a" <= "a"`, true},
+		{"[1, 2] <= [1, 2]", true},
+	}
+
+	for _, tt := range tests {
+		evaluated := testEval(tt.input)
+		switch expected := tt.expected.(type) {
+		case int:
+			testIntegerObject(t, evaluated, int64(expected))
+		case bool:
+			testBooleanObject(t, evaluated, expected)
+		case string:
+			testStringObject(t, evaluated, expected)
+		}
+	}
+}
+
 func TestIfElseExpressions(t *testing.T) {
 	tests := []struct {
 		input    string
@@ -495,6 +896,36 @@ addTwo(2)`
 	testIntegerObject(t, testEval(input), 4)
 }
 
+func TestKeywordArguments(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected int64
+	}{
+		{`
+def sub(x, y):
+	return x - y
+sub(y=1, x=10)`, 9},
+		{`
+def sub(x, y):
+	return x - y
+sub(10, y=3)`, 7},
+		{`
+class Point:
+	def __init__(self, x, y):
+		self.x = x
+		self.y = y
+Point(y=2, x=1).y`, 2},
+		{`
+class Scale:
+	def scale(self, n, factor):
+		return n * factor
+Scale().scale(3, factor=4)`, 12},
+	}
+	for _, tt := range tests {
+		testIntegerObject(t, testEval(tt.input), tt.expected)
+	}
+}
+
 func TestObjectMethod(t *testing.T) {
 	input := `
 list = []
@@ -504,120 +935,793 @@ list[0]`
 }
 
 /*
-Loop Testing
-*/
-func TestForLoop(t *testing.T) {
-	input := `x = 0
-for i in range(5):
-	x = x + i
-return x`
-	evaluated := testEval(input)
-
-	testIntegerObject(t, evaluated, 10)
-}
-
-func TestWhileLoop(t *testing.T) {
-	input := `x = 0
-while x < 5:
-	x = x + 1
-return x`
-	evaluated := testEval(input)
-
-	testIntegerObject(t, evaluated, 5)
-}
-
-func TestForInFunctionStatement(t *testing.T) {
-	input := `
-def foo(x):
-	for i in range(5):
-		x = x + i
-	return x
-
-x = foo(0)
-return x`
-	evaluated := testEval(input)
-
-	testIntegerObject(t, evaluated, 10)
-}
-
-/*
-Builtin Function Testing
+Class Testing
 */
-func TestBuiltinFunctions(t *testing.T) {
+func TestClassInstances(t *testing.T) {
 	tests := []struct {
 		input    string
 		expected interface{}
 	}{
-		{`len("")`, 0},
-		{`len("four")`, 4},
-		{`len("hello world")`, 11},
-		{`len(1)`, "argument to `len` not supported, got INTEGER"},
-		{`len("one", "two")`, "wrong number of arguments. got=2, want=1"},
+		{`
+class Point:
+	def __init__(self, x, y):
+		self.x = x
+		self.y = y
+	def sum(self):
+		return self.x + self.y
+p = Point(1, 2)
+p.sum()`, 3},
+		{`
+class Point:
+	origin = 0
+p = Point()
+p.origin`, 0},
+		{`
+class Counter:
+	count = 0
+	def __init__(self):
+		Counter.count = Counter.count + 1
+a = Counter()
+b = Counter()
+Counter.count`, 2},
+		{`
+class Box:
+	size = 1
+b = Box()
+b.size = 5
+Box.size`, 1},
+		{`
+class Greeter:
+	def __init__(self, name):
+		self.name = name
+	def greet(self):
+		return "hi " + self.name
+g = Greeter("bob").greet
+g()`, "hi bob"},
+		{`
+class Greeter:
+	def greet(self, name):
+		return "hi " + name
+Greeter.greet(Greeter(), "ann")`, "hi ann"},
+		{`
+class Animal:
+	pass_ = 0
+Animal.__name__`, "Animal"},
 	}
+
 	for _, tt := range tests {
 		evaluated := testEval(tt.input)
 		switch expected := tt.expected.(type) {
 		case int:
 			testIntegerObject(t, evaluated, int64(expected))
 		case string:
-			errObj, ok := evaluated.(*object.Error)
-			if !ok {
-				t.Errorf("object is not Error. got=%T (%+v)",
-					evaluated, evaluated)
-				continue
-			}
-			if errObj.Message != expected {
-				t.Errorf("wrong error message. expected=%q, got=%q",
-					expected, errObj.Message)
-			}
+			testStringObject(t, evaluated, expected)
 		}
 	}
 }
 
-func TestBuiltinRange(t *testing.T) {
-	input := `range(1+2)`
-	evaluated := testEval(input)
-
-	list, ok := evaluated.(*object.List)
-	if !ok {
-		newError("range did not return list, got=%T", evaluated.Type())
+func TestInheritance(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{`
+class Animal:
+	def __init__(self, name):
+		self.name = name
+	def speak(self):
+		return self.name + " makes a sound"
+class Dog(Animal):
+	def speak(self):
+		return self.name + " barks"
+Dog("rex").speak()`, "rex barks"},
+		{`
+class Animal:
+	def __init__(self, name):
+		self.name = name
+	def describe(self):
+		return self.name + " is a " + self.kind()
+	def kind(self):
+		return "animal"
+class Cat(Animal):
+	def kind(self):
+		return "cat"
+Cat("tom").describe()`, "tom is a cat"},
+		{`
+class Animal:
+	def __init__(self, name):
+		self.name = name
+	def speak(self):
+		return self.name + " makes a sound"
+class Dog(Animal):
+	def __init__(self, name, trick):
+		super().__init__(name)
+		self.trick = trick
+	def speak(self):
+		return super().speak() + " and can " + self.trick
+Dog("rex", "sit").speak()`, "rex makes a sound and can sit"},
+		{`
+class A:
+	def who(self):
+		return "A"
+class B(A):
+	def who(self):
+		return "B" + super().who()
+class C(A):
+	def who(self):
+		return "C" + super().who()
+class D(B, C):
+	def who(self):
+		return "D" + super().who()
+D().who()`, "DBCA"},
+		{`
+class A:
+	def who(self):
+		return "A"
+class B(A):
+	def who(self):
+		return "B"
+class C(B):
+	def who(self):
+		return super(B, self).who()
+C().who()`, "A"},
+		{`
+class Left:
+	side = "left"
+class Right:
+	side = "right"
+class Both(Left, Right):
+	pass_ = 0
+Both().side`, "left"},
 	}
-	e := list.Elements
 
-	if len(e) != 3 {
-		newError("range returned incorrect list size. expected=%d elements, got=%d", 3, len(e))
+	for _, tt := range tests {
+		testStringObject(t, testEval(tt.input), tt.expected)
 	}
-
-	testIntegerObject(t, e[0], 0)
-	testIntegerObject(t, e[1], 1)
-	testIntegerObject(t, e[2], 2)
 }
 
-func TestBuiltinMin(t *testing.T) {
-	input := `min([1, 2, 3])`
+func TestMethodResolutionOrder(t *testing.T) {
+	input := `
+class O:
+	x = 0
+class A(O):
+	x = 0
+class B(O):
+	x = 0
+class C(O):
+	x = 0
+class D(O):
+	x = 0
+class E(O):
+	x = 0
+class KA(A, B, C):
+	x = 0
+class KB(D, B, E):
+	x = 0
+class KC(D, A):
+	x = 0
+class Z(KA, KB, KC):
+	x = 0
+Z`
 	evaluated := testEval(input)
-
-	i, ok := evaluated.(*object.Integer)
+	cls, ok := evaluated.(*object.Class)
 	if !ok {
-		newError("min did not return Integer, got=%T", evaluated.Type())
+		t.Fatalf("object is not Class. got=%T (%+v)", evaluated, evaluated)
 	}
 
-	testIntegerObject(t, i, 1)
+	expected := []string{"Z", "KA", "KB", "KC", "D", "A", "B", "C", "E", "O"}
+	if len(cls.MRO) != len(expected) {
+		t.Fatalf("MRO has wrong length. got=%d, want=%d", len(cls.MRO), len(expected))
+	}
+	for i, name := range expected {
+		if cls.MRO[i].Name != name {
+			t.Errorf("MRO[%d] wrong. got=%s, want=%s", i, cls.MRO[i].Name, name)
+		}
+	}
 }
 
-func TestBuiltinMax(t *testing.T) {
-	input := `max([1, 2, 3])`
-	evaluated := testEval(input)
-
-	i, ok := evaluated.(*object.Integer)
-	if !ok {
-		newError("max did not return Integer, got=%T", evaluated.Type())
+func TestIsInstance(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected bool
+	}{
+		{"class A:\n\tx = 0\nisinstance(A(), A)", true},
+		{"class A:\n\tx = 0\nclass B(A):\n\tx = 0\nisinstance(B(), A)", true},
+		{"class A:\n\tx = 0\nclass B(A):\n\tx = 0\nisinstance(A(), B)", false},
+		{"class A:\n\tx = 0\nclass B:\n\tx = 0\nisinstance(A(), [B, A])", true},
+		{"class A:\n\tx = 0\nisinstance(1, A)", false},
+		{"class A:\n\tx = 0\nclass B(A):\n\tx = 0\nissubclass(B, A)", true},
+		{"class A:\n\tx = 0\nclass B(A):\n\tx = 0\nissubclass(A, B)", false},
+		{"class A:\n\tx = 0\nissubclass(A, A)", true},
 	}
 
-	testIntegerObject(t, i, 3)
+	for _, tt := range tests {
+		testBooleanObject(t, testEval(tt.input), tt.expected)
+	}
 }
 
-func TestBuiltinAbs(t *testing.T) {
+func TestOperatorOverloading(t *testing.T) {
+	vec := `
+class Vec:
+	def __init__(self, x, y):
+		self.x = x
+		self.y = y
+	def __add__(self, other):
+		return Vec(self.x + other.x, self.y + other.y)
+	def __sub__(self, other):
+		return Vec(self.x - other.x, self.y - other.y)
+	def __mul__(self, k):
+		return Vec(self.x * k, self.y * k)
+	def __rmul__(self, k):
+		return Vec(self.x * k, self.y * k)
+	def __lt__(self, other):
+		return self.x < other.x
+	def __neg__(self):
+		return Vec(-self.x, -self.y)
+`
+	tests := []struct {
+		input    string
+		expected interface{}
+	}{
+		{vec + "(Vec(1, 2) + Vec(3, 4)).x", 4},
+		{vec + "(Vec(1, 2) - Vec(3, 5)).y", -3},
+		{vec + "(Vec(1, 2) * 3).y", 6},
+		{vec + "(3 * Vec(1, 2)).x", 3},
+		{vec + "(-Vec(1, 2)).x", -1},
+		{vec + "Vec(1, 2) < Vec(2, 0)", true},
+		{vec + "Vec(1, 2) > Vec(2, 0)", false},
+		{vec + "Vec(3, 2) > Vec(2, 0)", true},
+	}
+
+	for _, tt := range tests {
+		evaluated := testEval(tt.input)
+		switch expected := tt.expected.(type) {
+		case int:
+			testIntegerObject(t, evaluated, int64(expected))
+		case bool:
+			testBooleanObject(t, evaluated, expected)
+		}
+	}
+}
+
+func TestSpecialMethods(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected interface{}
+	}{
+		{`
+class Money:
+	def __init__(self, cents):
+		self.cents = cents
+	def __eq__(self, other):
+		return self.cents == other.cents
+Money(5) == Money(5)`, true},
+		{`
+class Money:
+	def __init__(self, cents):
+		self.cents = cents
+	def __eq__(self, other):
+		return self.cents == other.cents
+Money(5) != Money(5)`, false},
+		{`
+class A:
+	x = 0
+a = A()
+a == a`, true},
+		{`
+class A:
+	x = 0
+A() == A()`, false},
+		{`
+class Bag:
+	def __init__(self):
+		self.items = {}
+	def __setitem__(self, key, value):
+		self.items[key] = value
+	def __getitem__(self, key):
+		return self.items[key]
+b = Bag()
+b["a"] = 7
+b["a"]`, 7},
+		{`
+class Bag:
+	def __len__(self):
+		return 3
+len(Bag())`, 3},
+		{`
+class Evens:
+	def __contains__(self, n):
+		return n / 2 * 2 == n
+4 in Evens()`, true},
+		{`
+class Evens:
+	def __contains__(self, n):
+		return n / 2 * 2 == n
+3 in Evens()`, false},
+		{`
+class Range:
+	def __init__(self, n):
+		self.n = n
+	def __iter__(self):
+		return range(self.n)
+total = 0
+for i in Range(4):
+	total = total + i
+total`, 6},
+		{`
+class Range:
+	def __iter__(self):
+		return [1, 2, 3]
+2 in Range()`, true},
+		{`
+class Money:
+	def __init__(self, cents):
+		self.cents = cents
+	def __str__(self):
+		return "$" + str(self.cents)
+str(Money(5))`, "$5"},
+		{`
+class Money:
+	def __init__(self, cents):
+		self.cents = cents
+	def __repr__(self):
+		return "Money(" + str(self.cents) + ")"
+str([Money(1), Money(2)])`, "[Money(1), Money(2)]"},
+		{`
+class Money:
+	def __repr__(self):
+		return "Money()"
+repr(Money())`, "Money()"},
+		{`
+class Empty:
+	def __bool__(self):
+		return false
+if Empty():
+	return 1
+else:
+	return 2`, 2},
+		{`
+class Empty:
+	def __len__(self):
+		return 0
+!Empty()`, true},
+		{`
+class Adder:
+	def __init__(self, n):
+		self.n = n
+	def __call__(self, x):
+		return self.n + x
+add = Adder(2)
+add(3)`, 5},
+		{`
+class Key:
+	def __init__(self, k):
+		self.k = k
+	def __hash__(self):
+		return self.k
+	def __eq__(self, other):
+		return self.k == other.k
+d = {Key(1): "one"}
+d[Key(1)]`, "one"},
+		{`
+class Key:
+	x = 0
+k = Key()
+d = {k: "k"}
+d[k]`, "k"},
+		{`
+class Num:
+	def __init__(self, n):
+		self.n = n
+	def __lt__(self, other):
+		return self.n < other.n
+	def __repr__(self):
+		return str(self.n)
+str(sorted([Num(3), Num(1), Num(2)]))`, "[1, 2, 3]"},
+		{`
+class Countdown:
+	def __init__(self, n):
+		self.n = n
+	def __bool__(self):
+		return self.n != 0
+c = Countdown(3)
+steps = 0
+while c:
+	c.n = c.n - 1
+	steps = steps + 1
+steps`, 3},
+		{`
+class Name:
+	def __init__(self, s):
+		self.s = s
+	def __lt__(self, other):
+		return self.s == "first"
+	def __repr__(self):
+		return self.s
+str(sorted([Name("second"), Name("first")]))`, "[first, second]"},
+		{`
+class V:
+	def __init__(self, x, y):
+		self.x = x
+		self.y = y
+	def __hash__(self):
+		return 1
+	def __eq__(self, other):
+		return [self.x, self.y] == [other.x, other.y]
+d = {V(0, 33): 1, V(1, 2): 2}
+d[V(3, 4)] = 3
+del d[V(0, 33)]
+d[V(1, 2)] = 4
+str([len(d), d[V(1, 2)], d[V(3, 4)], V(0, 33) in d])`, "[2, 4, 3, false]"},
+		{`
+class V:
+	def __init__(self, x):
+		self.x = x
+	def __hash__(self):
+		return 1
+	def __eq__(self, other):
+		return self.x == other.x
+s = {V(1), V(2), V(1), V(3)}
+s.discard(V(1))
+t = s | {V(3), V(4)}
+str([len(s), V(2) in s, V(1) in s, len(t), len(s & t), len(t - s), len(s ^ {V(2), V(5)}), s <= t])`, "[2, true, false, 3, 2, 1, 2, true]"},
+	}
+
+	for _, tt := range tests {
+		evaluated := testEval(tt.input)
+		switch expected := tt.expected.(type) {
+		case int:
+			testIntegerObject(t, evaluated, int64(expected))
+		case bool:
+			testBooleanObject(t, evaluated, expected)
+		case string:
+			testStringObject(t, evaluated, expected)
+		}
+	}
+}
+
+/*
+Module Testing
+*/
+func testEvalFile(t *testing.T, dir, input string) object.Object {
+	l := lexer.New(input)
+	p := parser.New(l)
+	program := p.ParseProgram()
+	env := object.NewEnvironment()
+	env.Set("__file__", &object.String{Value: filepath.Join(dir, "main.py")})
+
+	return Eval(program, env)
+}
+
+func writeModule(t *testing.T, dir, name, src string) {
+	err := os.WriteFile(filepath.Join(dir, name+".py"), []byte(src), 0644)
+	if err != nil {
+		t.Fatalf("could not write module %s: %s", name, err)
+	}
+}
+
+func TestImports(t *testing.T) {
+	dir := t.TempDir()
+	writeModule(t, dir, "shapes", "scale = 2\ndef area(w, h):\n\treturn w * h * scale\nclass Square:\n\tdef __init__(self, s):\n\t\tself.s = s")
+
+	tests := []struct {
+		input    string
+		expected int64
+	}{
+		{"import shapes\nshapes.area(2, 3)", 12},
+		{"import shapes as s\ns.scale", 2},
+		{"from shapes import area\narea(1, 1)", 2},
+		{"from shapes import area as a, Square\na(1, 2) + Square(3).s", 7},
+		{"import shapes\nshapes.scale = 3\nimport shapes as again\nagain.scale", 3},
+	}
+
+	for _, tt := range tests {
+		testIntegerObject(t, testEvalFile(t, dir, tt.input), tt.expected)
+	}
+}
+
+func TestModulesRunOnce(t *testing.T) {
+	dir := t.TempDir()
+	writeModule(t, dir, "counter", "count = 0")
+	writeModule(t, dir, "bump", "import counter\ncounter.count = counter.count + 1")
+
+	input := `
+import bump
+import counter
+from bump import counter as c
+import bump as b
+counter.count + c.count`
+	testIntegerObject(t, testEvalFile(t, dir, input), 2)
+}
+
+func TestModuleSearchPath(t *testing.T) {
+	dir := t.TempDir()
+	lib := t.TempDir()
+	writeModule(t, lib, "helpers", "def triple(x):\n\treturn x * 3")
+	t.Setenv("SIMPYLPATH", lib)
+
+	testIntegerObject(t, testEvalFile(t, dir, "import helpers\nhelpers.triple(2)"), 6)
+}
+
+func TestNativeModules(t *testing.T) {
+	native.MustRegister("greeting", native.Members{
+		"hello": &object.Builtin{Fn: func(args ...object.Object) object.Object {
+			return &object.String{Value: "hello " + args[0].Inspect()}
+		}},
+		"punctuation": &object.String{Value: "!"},
+	})
+
+	// A script module of the same name must not shadow the native one
+	dir := t.TempDir()
+	writeModule(t, dir, "greeting", "punctuation = \"?\"")
+
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{`import greeting
+greeting.hello("bob") + greeting.punctuation`, "hello bob!"},
+		{`from greeting import hello as hi
+hi("ann")`, "hello ann"},
+		{`import greeting
+greeting.__name__`, "greeting"},
+	}
+
+	for _, tt := range tests {
+		testStringObject(t, testEvalFile(t, dir, tt.input), tt.expected)
+	}
+}
+
+func TestMathModule(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected interface{}
+	}{
+		{"math.sqrt(16)", 4.0},
+		{"math.floor(2.7)", 2},
+		{"math.floor(-2.5)", -3},
+		{"math.ceil(2.1)", 3},
+		{"math.ceil(5)", 5},
+		{"math.trunc(-2.7)", -2},
+		{"math.log(math.e)", 1.0},
+		{"math.log(8, 2)", 3.0},
+		{"math.log10(1000)", 3.0},
+		{"math.exp(0)", 1.0},
+		{"math.sin(0)", 0.0},
+		{"math.cos(0)", 1.0},
+		{"math.atan2(0, 1)", 0.0},
+		{"math.degrees(math.pi)", 180.0},
+		{"math.pow(2, 10)", 1024.0},
+		{"math.hypot(3, 4)", 5.0},
+		{"math.fabs(-2)", 2.0},
+		{"math.gcd(12, 18)", 6},
+		{"math.gcd(-4, 6, 10)", 2},
+		{"math.gcd()", 0},
+		{"math.factorial(5)", 120},
+		{"math.factorial(0)", 1},
+		{"math.comb(5, 2)", 10},
+		{"math.comb(2, 5)", 0},
+		{"math.perm(5, 2)", 20},
+		{"math.perm(4)", 24},
+		{"math.factorial(20)", 2432902008176640000},
+		{"math.comb(1000000000, 2)", 499999999500000000},
+		{"math.comb(100, 98)", 4950},
+		{"math.perm(20, 20)", 2432902008176640000},
+		{"math.isclose(1.0, 1.0000000001)", true},
+		{"math.isclose(1.0, 1.1)", false},
+		{"math.isclose(1.0, 1.1, rel_tol=0.2)", true},
+		{"math.isclose(0, 0.001, abs_tol=0.01)", true},
+		{"math.isnan(math.nan)", true},
+		{"math.isinf(-math.inf)", true},
+		{"math.isfinite(math.inf)", false},
+		{"math.isnan(1) == false", true},
+		{"math.sqrt(-1)", "math domain error"},
+		{"math.log(0)", "math domain error"},
+		{"math.acos(2)", "math domain error"},
+		{"math.pow(0, -1)", "math domain error"},
+		{"math.exp(1000)", "math range error"},
+		{"math.floor(math.inf)", "cannot convert float infinity to integer"},
+		{"math.floor(math.nan)", "cannot convert float NaN to integer"},
+		{"math.factorial(-1)", "factorial() not defined for negative values"},
+		{"math.factorial(2.0)", "FLOAT object cannot be interpreted as an integer"},
+		{"math.factorial(21)", "integer overflow: result does not fit in 64 bits"},
+		{"math.factorial(100000000)", "integer overflow: result does not fit in 64 bits"},
+		{"math.comb(68, 34)", "integer overflow: result does not fit in 64 bits"},
+		{"math.comb(1000000000, 500000000)", "integer overflow: result does not fit in 64 bits"},
+		{"math.perm(30, 21)", "integer overflow: result does not fit in 64 bits"},
+		{"math.perm(1000000000, 100000000)", "integer overflow: result does not fit in 64 bits"},
+		{"math.comb(-1, 2)", "n must be a non-negative integer"},
+		{"math.sqrt(\"4\")", "must be real number, not STRING"},
+		{"math.isclose(1, 2, rel_tol=-1)", "tolerances must be non-negative"},
+		{"math.isclose(1, 2, tol=1)", "isclose() got an unexpected keyword argument 'tol'"},
+		{"math.sqrt(x=4)", "builtin function takes no keyword arguments"},
+	}
+
+	for _, tt := range tests {
+		evaluated := testEval("import math\n" + tt.input)
+
+		switch expected := tt.expected.(type) {
+		case int:
+			testIntegerObject(t, evaluated, int64(expected))
+		case float64:
+			result, ok := evaluated.(*object.Float)
+			if !ok {
+				t.Errorf("%s: object is not Float. got=%T (%+v)", tt.input, evaluated, evaluated)
+				continue
+			}
+			if math.Abs(result.Value-expected) > 1e-9 {
+				t.Errorf("%s: wrong value. got=%g, want=%g", tt.input, result.Value, expected)
+			}
+		case bool:
+			testBooleanObject(t, evaluated, expected)
+			if evaluated != nativeBoolToBooleanObject(expected) {
+				t.Errorf("%s: result is not the shared boolean", tt.input)
+			}
+		case string:
+			errObj, ok := evaluated.(*object.Error)
+			if !ok {
+				t.Errorf("%s: object is not Error. got=%T (%+v)", tt.input, evaluated, evaluated)
+				continue
+			}
+			if errObj.Message != expected {
+				t.Errorf("%s: wrong error message. expected=%q, got=%q", tt.input, expected, errObj.Message)
+			}
+		}
+	}
+}
+
+func TestModuleErrors(t *testing.T) {
+	dir := t.TempDir()
+	writeModule(t, dir, "first", "import second")
+	writeModule(t, dir, "second", "import third")
+	writeModule(t, dir, "third", "import first")
+	writeModule(t, dir, "small", "x = 1")
+	writeModule(t, dir, "broken", "x = 1 +")
+	writeModule(t, dir, "failing", "y = undefined")
+
+	tests := []struct {
+		input           string
+		expectedMessage string
+	}{
+		{"import missing", "No module named 'missing'"},
+		{"import first", "circular import: first -> second -> third -> first"},
+		{"from small import y", "cannot import name 'y' from 'small'"},
+		{"import small\nsmall.y", "module 'small' has no attribute 'y'"},
+		{"import broken", "error parsing module 'broken':\nexpected an expression, got end of input at line 1, column 8"},
+		{"import failing", "identifier not found: undefined"},
+	}
+
+	for _, tt := range tests {
+		evaluated := testEvalFile(t, dir, tt.input)
+		errObj, ok := evaluated.(*object.Error)
+		if !ok {
+			t.Errorf("no error object returned. got=%T(%+v)",
+				evaluated, evaluated)
+			continue
+		}
+
+		if errObj.Message != tt.expectedMessage {
+			t.Errorf("wrong error message. expected=%q, got=%q",
+				tt.expectedMessage, errObj.Message)
+		}
+	}
+}
+
+/*
+Loop Testing
+*/
+func TestForLoop(t *testing.T) {
+	input := `x = 0
+for i in range(5):
+	x = x + i
+return x`
+	evaluated := testEval(input)
+
+	testIntegerObject(t, evaluated, 10)
+}
+
+func TestWhileLoop(t *testing.T) {
+	input := `x = 0
+while x < 5:
+	x = x + 1
+return x`
+	evaluated := testEval(input)
+
+	testIntegerObject(t, evaluated, 5)
+}
+
+func TestForInFunctionStatement(t *testing.T) {
+	input := `
+def foo(x):
+	for i in range(5):
+		x = x + i
+	return x
+
+x = foo(0)
+return x`
+	evaluated := testEval(input)
+
+	testIntegerObject(t, evaluated, 10)
+}
+
+/*
+Builtin Function Testing
+*/
+func TestBuiltinFunctions(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected interface{}
+	}{
+		{`len("")`, 0},
+		{`len("four")`, 4},
+		{`len("hello world")`, 11},
+		{`len(1)`, "argument to `len` not supported, got INTEGER"},
+		{`len("one", "two")`, "wrong number of arguments. got=2, want=1"},
+	}
+	for _, tt := range tests {
+		evaluated := testEval(tt.input)
+		switch expected := tt.expected.(type) {
+		case int:
+			testIntegerObject(t, evaluated, int64(expected))
+		case string:
+			errObj, ok := evaluated.(*object.Error)
+			if !ok {
+				t.Errorf("object is not Error. got=%T (%+v)",
+					evaluated, evaluated)
+				continue
+			}
+			if errObj.Message != expected {
+				t.Errorf("wrong error message. expected=%q, got=%q",
+					expected, errObj.Message)
+			}
+		}
+	}
+}
+
+func TestBuiltinRange(t *testing.T) {
+	input := `range(1+2)`
+	evaluated := testEval(input)
+
+	list, ok := evaluated.(*object.List)
+	if !ok {
+		newError("range did not return list, got=%T", evaluated.Type())
+	}
+	e := list.Elements
+
+	if len(e) != 3 {
+		newError("range returned incorrect list size. expected=%d elements, got=%d", 3, len(e))
+	}
+
+	testIntegerObject(t, e[0], 0)
+	testIntegerObject(t, e[1], 1)
+	testIntegerObject(t, e[2], 2)
+}
+
+func TestBuiltinMin(t *testing.T) {
+	input := `min([1, 2, 3])`
+	evaluated := testEval(input)
+
+	i, ok := evaluated.(*object.Integer)
+	if !ok {
+		newError("min did not return Integer, got=%T", evaluated.Type())
+	}
+
+	testIntegerObject(t, i, 1)
+}
+
+func TestBuiltinMax(t *testing.T) {
+	input := `max([1, 2, 3])`
+	evaluated := testEval(input)
+
+	i, ok := evaluated.(*object.Integer)
+	if !ok {
+		newError("max did not return Integer, got=%T", evaluated.Type())
+	}
+
+	testIntegerObject(t, i, 3)
+}
+
+func TestBuiltinAbs(t *testing.T) {
 	input := `abs(-1)`
 	evaluated := testEval(input)
 
@@ -626,36 +1730,329 @@ func TestBuiltinAbs(t *testing.T) {
 		newError("abs did not return Integer, got=%T", evaluated.Type())
 	}
 
-	testIntegerObject(t, i, 1)
+	testIntegerObject(t, i, 1)
+}
+
+func TestBuiltinSum(t *testing.T) {
+	input := `sum([1, 2, 3])`
+	evaluated := testEval(input)
+
+	i, ok := evaluated.(*object.Integer)
+	if !ok {
+		newError("sum did not return Integer, got=%T", evaluated.Type())
+	}
+
+	testIntegerObject(t, i, 6)
+}
+
+func TestBuiltinSorted(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{"str(sorted([3, 1.5, 2]))", "[1.5, 2, 3]"},
+		{"str(sorted((3, 1, 2)))", "[1, 2, 3]"},
+		{"str(sorted('cab'))", "['a', 'b', 'c']"},
+		{"str(sorted({'b': 1, 'a': 2}))", "['a', 'b']"},
+		{"str(sorted([true, false, true]))", "[false, true, true]"},
+		{"str(sorted([[2, 1], [1, 2], [1]]))", "[[1], [1, 2], [2, 1]]"},
+		{"str(sorted([3, 1, 2], reverse=true))", "[3, 2, 1]"},
+		{"str(sorted(['bb', 'a', 'ccc', 'dd'], key=len))", "['a', 'bb', 'dd', 'ccc']"},
+		{"str(sorted(['bb', 'a', 'ccc', 'dd'], key=len, reverse=true))", "['ccc', 'bb', 'dd', 'a']"},
+		{"a = [2, 1]\nb = sorted(a)\nstr(a)", "[2, 1]"},
+		{`
+def second(pair):
+	return pair[1]
+str(sorted([(1, 'b'), (2, 'a'), (3, 'b'), (4, 'a')], key=second))`, "[(2, 'a'), (4, 'a'), (1, 'b'), (3, 'b')]"},
+	}
+
+	for _, tt := range tests {
+		evaluated := testEval(tt.input)
+		testStringObject(t, evaluated, tt.expected)
+	}
+}
+
+func TestBuiltinStr(t *testing.T) {
+	input := `str(1)`
+	evaluated := testEval(input)
+
+	i, ok := evaluated.(*object.String)
+	if !ok {
+		newError("str did not return String, got=%T", evaluated.Type())
+	}
+
+	testStringObject(t, i, "1")
+}
+
+// Floats display like Python's repr, with the shortest digits that round trip,
+// rather than with six fixed decimals
+func TestFloatDisplay(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{`str(2.5)`, "2.5"},
+		{`str(1.0)`, "1.0"},
+		{`str(0.1 + 0.2)`, "0.30000000000000004"},
+		{`str(0.00001)`, "1e-05"},
+		{`str([1.5, 2.0])`, "[1.5, 2.0]"},
+		{`f"{0.1}"`, "0.1"},
+		{`"{}".format(3.0)`, "3.0"},
+		{`"%s" % 0.25`, "0.25"},
+	}
+
+	for _, tt := range tests {
+		testStringObject(t, testEval(tt.input), tt.expected)
+	}
+}
+
+func TestRecursiveContainers(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{"a = [1]\na.append(a)\nstr(a)", "[1, [...]]"},
+		{"d = {}\nd[1] = d\nrepr(d)", "{1: {...}}"},
+		{"a = []\nt = (a,)\na.append(t)\nf'{t}'", "([(...)],)"},
+		{"a = [1]\na.append(a)\nstr(a == a)", "true"},
+		{"a = [1]\na.append(a)\nstr(a.index(a))", "1"},
+		{"a = [1]\na.append(a)\nb = [2]\nb.append(b)\nstr(a < b)", "true"},
+	}
+
+	for _, tt := range tests {
+		evaluated := testEval(tt.input)
+		str, ok := evaluated.(*object.String)
+		if !ok {
+			t.Errorf("%q: object is not String. got=%T (%+v)", tt.input, evaluated, evaluated)
+			continue
+		}
+		if str.Value != tt.expected {
+			t.Errorf("%q: wrong value. expected=%q, got=%q", tt.input, tt.expected, str.Value)
+		}
+	}
+
+	// Distinct containers that hold themselves cannot be compared
+	recursive := []string{
+		"a = [1]\na.append(a)\nb = [1]\nb.append(b)\na == b",
+		"a = [1]\na.append(a)\nb = [1]\nb.append(b)\na < b",
+		"a = [1]\na.append(a)\nb = [1]\nb.append(b)\na != b",
+		"a = []\nt = (a,)\na.append(t)\nb = []\nu = (b,)\nb.append(u)\nt == u",
+		"a = {}\na[1] = a\nb = {}\nb[1] = b\na == b",
+		"a = {}\na[1] = a\nb = {}\nb[1] = b\n[a] in [[b]]",
+	}
+	for _, input := range recursive {
+		evaluated := testEval(input)
+		errObj, ok := evaluated.(*object.Error)
+		if !ok {
+			t.Errorf("%q: no error object returned. got=%T (%+v)", input, evaluated, evaluated)
+			continue
+		}
+		if errObj.Message != "RecursionError: maximum recursion depth exceeded in comparison" {
+			t.Errorf("%q: wrong error message. got=%q", input, errObj.Message)
+		}
+	}
+}
+
+/*
+Operator Testing
+*/
+func TestModulo(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected interface{}
+	}{
+		{"7 % 3", 1},
+		{"-7 % 3", 2},
+		{"7 % -3", -2},
+		{"6 % 3", 0},
+		{"7.5 % 2", 1.5},
+		{"-1.0 % 4", 3.0},
+		{"1 + 10 % 4", 3},
+	}
+
+	for _, tt := range tests {
+		evaluated := testEval(tt.input)
+		switch expected := tt.expected.(type) {
+		case int:
+			testIntegerObject(t, evaluated, int64(expected))
+		case float64:
+			testFloatObject(t, evaluated, expected)
+		}
+	}
+}
+
+func TestFormattedStrings(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{`x = 3.14159
+f"{x:>10.2f}|"`, "      3.14|"},
+		{`name = "bob"
+f"hi {name}!"`, "hi bob!"},
+		{`name = "bob"
+f"{name!r}"`, "'bob'"},
+		{`w = 7
+f"{'ab':^{w}}|"`, "  ab   |"},
+		{`f"{{literal}} {1 + 2}"`, "{literal} 3"},
+		{`f"{[1, 'a']}"`, "[1, 'a']"},
+		{`d = {"k": 5}
+f"{d['k']:03d}"`, "005"},
+		{`f"{1234567:,}"`, "1,234,567"},
+		{`f"{255:#x} {255:08b} {65:c}"`, "0xff 11111111 A"},
+		{`f"{-42:+08d}|{42: d}|{42:+d}"`, "-0000042| 42|+42"},
+		{`f"{0.25:.1%}"`, "25.0%"},
+		{`f"{12345.678:.2e}|{12345.678:E}"`, "1.23e+04|1.234568E+04"},
+		{`f"{0.0001234:g}|{123456789.0:g}"`, "0.0001234|1.23457e+08"},
+		{`f"{2.0:.3}|{1.5}"`, "2.0|1.5"},
+		{`f"{1234.5:_.1f}"`, "1_234.5"},
+		{`f"{'abc':.2}|{'x':*<3}|{7:x>3}"`, "ab|x**|xx7"},
+		{`f"{true}|{true:d}"`, "true|1"},
+		{`class Money:
+	def __format__(self, spec):
+		return "$" + spec
+f"{Money():10}"`, "$10"},
+		{`rf"{1}\n"`, "1\\n"},
+	}
+
+	for _, tt := range tests {
+		testStringObject(t, testEval(tt.input), tt.expected)
+	}
 }
 
-func TestBuiltinSum(t *testing.T) {
-	input := `sum([1, 2, 3])`
-	evaluated := testEval(input)
-
-	i, ok := evaluated.(*object.Integer)
-	if !ok {
-		newError("sum did not return Integer, got=%T", evaluated.Type())
+func TestStrFormat(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{`"{} and {}".format(1, "two")`, "1 and two"},
+		{`"{1} {0} {1}".format("a", "b")`, "b a b"},
+		{`"{name:>6}|{n:05.1f}".format(name="hi", n=2.5)`, "    hi|002.5"},
+		{`"{0[1]} {0[0]!r}".format(["x", "y"])`, "y 'x'"},
+		{`"{d[key]}".format(d={"key": 3})`, "3"},
+		{`class P:
+	def __init__(self):
+		self.x = 4
+"{0.x}".format(P())`, "4"},
+		{`"{:{}}|".format("a", 3)`, "a  |"},
+		{`"{{}} {}".format(1)`, "{} 1"},
 	}
 
-	testIntegerObject(t, i, 6)
+	for _, tt := range tests {
+		testStringObject(t, testEval(tt.input), tt.expected)
+	}
 }
 
-func TestBuiltinStr(t *testing.T) {
-	input := `str(1)`
-	evaluated := testEval(input)
+func TestPercentFormatting(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{`"%s is %d" % ("al", 30)`, "al is 30"},
+		{`"%d%%" % 50`, "50%"},
+		{`"%.2f|%5.1f|%-6.1f|%06.2f" % (3.14159, 2.5, 2.5, -2.5)`, "3.14|  2.5|2.5   |-02.50"},
+		{`"%5s|%-5s|" % ("r", "l")`, "    r|l    |"},
+		{`"%x %X %#o %+d % d" % (255, 255, 8, 5, 5)`, "ff FF 0o10 +5  5"},
+		{`"%(a)s-%(b)03d" % {"a": "k", "b": 7}`, "k-007"},
+		{`"%r %s" % ("q", "q")`, "'q' q"},
+		{`"%*d|%.*f" % (4, 7, 1, 2.25)`, "   7|2.2"},
+		{`"%d" % 3.9`, "3"},
+		{`"%c%c" % (72, "i")`, "Hi"},
+		{`"%e" % 1234.5`, "1.234500e+03"},
+		{`"[%s]" % [1, 2]`, "[[1, 2]]"},
+	}
 
-	i, ok := evaluated.(*object.String)
-	if !ok {
-		newError("str did not return String, got=%T", evaluated.Type())
+	for _, tt := range tests {
+		testStringObject(t, testEval(tt.input), tt.expected)
 	}
+}
 
-	testStringObject(t, i, "1")
+func TestStringMethods(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected interface{}
+	}{
+		{`str(" a  b\tc ".split())`, "['a', 'b', 'c']"},
+		{`str("a,b,,c".split(","))`, "['a', 'b', '', 'c']"},
+		{`str("a,b,c".split(",", 1))`, "['a', 'b,c']"},
+		{`str("  a b  c  ".split(maxsplit=1))`, "['a', 'b  c  ']"},
+		{`str("  a b  c  ".rsplit(maxsplit=1))`, "['  a b', 'c']"},
+		{`str("a,b,c".rsplit(",", 1))`, "['a,b', 'c']"},
+		{`str("".split())`, "[]"},
+		{`str("a\nb\r\nc\rd".splitlines())`, "['a', 'b', 'c', 'd']"},
+		{`str("a\nb\n".splitlines(keepends=true))`, "['a\\n', 'b\\n']"},
+		{`str("a\n\nb".splitlines())`, "['a', '', 'b']"},
+		{`str("a=b=c".partition("="))`, "('a', '=', 'b=c')"},
+		{`str("a=b=c".rpartition("="))`, "('a=b', '=', 'c')"},
+		{`str("abc".partition("x"))`, "('abc', '', '')"},
+		{`str("abc".rpartition("x"))`, "('', '', 'abc')"},
+		{`"  hi \n".strip()`, "hi"},
+		{`"xxhixx".strip("x")`, "hi"},
+		{`"  hi ".lstrip()`, "hi "},
+		{`"  hi ".rstrip()`, "  hi"},
+		{`"aaa".replace("a", "b", 2)`, "bba"},
+		{`"ab".replace("", "-")`, "-a-b-"},
+		{`"hello".find("l")`, 2},
+		{`"hello".rfind("l")`, 3},
+		{`"hello".find("z")`, -1},
+		{`"héllo".find("l", 3)`, 3},
+		{`"abc".find("", 3)`, 3},
+		{`"abc".find("", 4)`, -1},
+		{`"hello".index("e")`, 1},
+		{`"hello".rindex("l")`, 3},
+		{`"hello".count("l")`, 2},
+		{`"hello".count("l", -2)`, 1},
+		{`"abc".count("")`, 4},
+		{`"hello".startswith(("x", "he"))`, true},
+		{`"hello".startswith("l", 2)`, true},
+		{`"abc".startswith("", 4)`, false},
+		{`"hello".endswith("lo")`, true},
+		{`"hello".endswith(("x", "y"))`, false},
+		{`"ab".center(5)`, "  ab "},
+		{`"abc".center(6, "*")`, "*abc**"},
+		{`"ab".ljust(4, "-")`, "ab--"},
+		{`"ab".rjust(4)`, "  ab"},
+		{`"abc".rjust(2)`, "abc"},
+		{`"-42".zfill(6)`, "-00042"},
+		{`"42".zfill(1)`, "42"},
+		{`"they're bill's 2nd".title()`, "They'Re Bill'S 2Nd"},
+		{`"hELLO wORLD".capitalize()`, "Hello world"},
+		{`"Hello World".istitle()`, true},
+		{`"hello World".istitle()`, false},
+		{`"123".isdigit()`, true},
+		{`"²".isdigit()`, true},
+		{`"²".isdecimal()`, false},
+		{`"½".isnumeric()`, true},
+		{`"abé".isalpha()`, true},
+		{`"ab1".isalnum()`, true},
+		{`" \t".isspace()`, true},
+		{`"".isspace()`, false},
+		{`"".isascii()`, true},
+		{`"é".isascii()`, false},
+		{`str("héllo".encode())`, "b'h\\xc3\\xa9llo'"},
+		{`str("héllo".encode("ascii", "replace"))`, "b'h?llo'"},
+		{`str("héllo".encode(encoding="latin-1"))`, "b'h\\xe9llo'"},
+		{`"ab" * 3`, "ababab"},
+		{`2 * "x"`, "xx"},
+		{`"ab" * -1`, ""},
+		{`"a" < "b"`, true},
+		{`"abc" > "abd"`, false},
+		{`"é" > "z"`, true},
+	}
+
+	for _, tt := range tests {
+		evaluated := testEval(tt.input)
+		switch expected := tt.expected.(type) {
+		case int:
+			testIntegerObject(t, evaluated, int64(expected))
+		case bool:
+			testBooleanObject(t, evaluated, expected)
+		case string:
+			testStringObject(t, evaluated, expected)
+		}
+	}
 }
 
-/*
-Operator Testing
-*/
 func TestBangOperator(t *testing.T) {
 	tests := []struct {
 		input    string
@@ -815,6 +2212,355 @@ def f(x):
 {"name": "Monkey"}[f];`,
 			"unusable as hash key: FUNCTION",
 		},
+		{
+			"[1, 2].foo()",
+			"LIST object has no attribute 'foo'",
+		},
+		{
+			`"abc".foo`,
+			"STRING object has no attribute 'foo'",
+		},
+		{
+			"class A:\n\tx = 0\nA().y",
+			"'A' object has no attribute 'y'",
+		},
+		{
+			"class A:\n\tx = 0\nA.y",
+			"type object 'A' has no attribute 'y'",
+		},
+		{
+			"class A:\n\tx = 0\nA(1)",
+			"A() takes no arguments",
+		},
+		{
+			"class A:\n\tx = 0\nclass B(A, A):\n\tx = 0",
+			"cannot create a consistent method resolution order (MRO) for bases A, A",
+		},
+		{
+			"class A(1):\n\tx = 0",
+			"class A: base must be a class, got INTEGER",
+		},
+		{
+			"isinstance(1, 2)",
+			"isinstance() arg 2 must be a class or list of classes, got INTEGER",
+		},
+		{
+			"class A:\n\tx = 0\nA() + 1",
+			"unsupported operand types for +: A and INTEGER",
+		},
+		{
+			"class A:\n\tx = 0\nlen(A())",
+			"object of type 'A' has no len()",
+		},
+		{
+			"class A:\n\tx = 0\nA()[0]",
+			"'A' object is not subscriptable",
+		},
+		{
+			"class A:\n\tx = 0\nA()(1)",
+			"'A' object is not callable",
+		},
+		{
+			"class A:\n\tx = 0\nfor i in A():\n\ti",
+			"'A' object is not iterable",
+		},
+		{
+			"class A:\n\tdef __eq__(self, other):\n\t\treturn true\n{A(): 1}",
+			"unhashable type: 'A'",
+		},
+		{
+			"class A:\n\tdef __str__(self):\n\t\treturn 1\nstr(A())",
+			"__str__ returned non-string (type INTEGER)",
+		},
+		{
+			"5 % 0",
+			"integer division or modulo by zero",
+		},
+		{
+			"5.0 % 0",
+			"float modulo",
+		},
+		{
+			`"%d %d" % (1,)`,
+			"not enough arguments for format string",
+		},
+		{
+			`"%d" % (1, 2)`,
+			"not all arguments converted during string formatting",
+		},
+		{
+			`"%d" % "x"`,
+			"%d format: a real number is required, not STRING",
+		},
+		{
+			`"%y" % 1`,
+			"unsupported format character 'y' (0x79) at index 1",
+		},
+		{
+			`"%(a)s" % {"b": 1}`,
+			"KeyError: 'a'",
+		},
+		{
+			`"{} {}".format(1)`,
+			"replacement index 1 out of range for positional args tuple",
+		},
+		{
+			`"{} {0}".format(1)`,
+			"cannot switch from automatic field numbering to manual field specification",
+		},
+		{
+			`"{name}".format(nome=1)`,
+			"KeyError: 'name'",
+		},
+		{
+			`"{".format()`,
+			"single '{' encountered in format string",
+		},
+		{
+			`f"{'a':d}"`,
+			"unknown format code 'd' for object of type 'str'",
+		},
+		{
+			`f"{1.5:d}"`,
+			"unknown format code 'd' for object of type 'float'",
+		},
+		{
+			`f"{1:.2d}"`,
+			"precision not allowed in integer format specifier",
+		},
+		{
+			`f"{[1]:>5}"`,
+			"unsupported format string passed to LIST.__format__",
+		},
+		{
+			`f"{1:5q5}"`,
+			"invalid format specifier '5q5'",
+		},
+		{
+			"(1,)[3]",
+			"tuple index out of range",
+		},
+		{
+			"[1, 2, 3][3]",
+			"list index out of range",
+		},
+		{
+			"[1, 2, 3][-4]",
+			"list index out of range",
+		},
+		{
+			"a = [1]\na[1] = 2",
+			"list assignment index out of range",
+		},
+		{
+			"5 / 0",
+			"integer division or modulo by zero",
+		},
+		{
+			"5.0 / 0",
+			"float division by zero",
+		},
+		{
+			`"abc"[3]`,
+			"string index out of range",
+		},
+		{
+			`"abc".index("z")`,
+			"substring not found",
+		},
+		{
+			"[1, 2].index(3)",
+			"3 is not in list",
+		},
+		{
+			"[].pop()",
+			"pop from empty list",
+		},
+		{
+			"[1].pop(-2)",
+			"index out of range of list.pop()",
+		},
+		{
+			"[1, 2].remove(3)",
+			"list.remove(x): x not in list",
+		},
+		{
+			"[1, 2].sort(len)",
+			"list.sort() takes no positional arguments",
+		},
+		{
+			"sorted([1, 'a'])",
+			"TypeError: '<' not supported between instances of 'STRING' and 'INTEGER'",
+		},
+		{
+			"[[1], ['a']].sort()",
+			"TypeError: '<' not supported between instances of 'STRING' and 'INTEGER'",
+		},
+		{
+			"sorted([{}, {}])",
+			"TypeError: '<' not supported between instances of 'DICT' and 'DICT'",
+		},
+		{
+			"sorted(1)",
+			"'INTEGER' object is not iterable",
+		},
+		{
+			"a = [1, 2, 3]\na[0:3:2] = [1]",
+			"attempt to assign sequence of size 1 to extended slice of size 2",
+		},
+		{
+			"[1, 2, 3][0:3:0]",
+			"slice step cannot be zero",
+		},
+		{
+			"a = [1]\ndel a[5]",
+			"list assignment index out of range",
+		},
+		{
+			"del y",
+			"name 'y' is not defined",
+		},
+		{
+			`{"foo": 5}["bar"]`,
+			"KeyError: 'bar'",
+		},
+		{
+			`{}[1]`,
+			"KeyError: 1",
+		},
+		{
+			`d = {}
+del d["a"]`,
+			"KeyError: 'a'",
+		},
+		{
+			`{}.pop("a")`,
+			"KeyError: 'a'",
+		},
+		{
+			`{}.popitem()`,
+			"KeyError: 'popitem(): dictionary is empty'",
+		},
+		{
+			`dict([(1, 2, 3)])`,
+			"dictionary update sequence element #0 has length 3; 2 is required",
+		},
+		{
+			`dict({}, {})`,
+			"dict expected at most 1 argument, got 2",
+		},
+		{
+			"{{1}: 2}",
+			"unhashable type: 'set'",
+		},
+		{
+			`"abc"[::0]`,
+			"slice step cannot be zero",
+		},
+		{
+			`(1, 2)["a":]`,
+			"slice indices must be integers, got STRING",
+		},
+		{
+			"range(1, 5, 0)",
+			"range() arg 3 must not be zero",
+		},
+		{
+			"slice(1, 2, 3, 4)",
+			"slice() takes at most 3 arguments (4 given)",
+		},
+		{
+			"t = (1, 2)\nt[0:1] = [3]",
+			"'TUPLE' object does not support slice assignment",
+		},
+		{
+			"s = {1}\ns.remove(2)",
+			"KeyError: 2",
+		},
+		{
+			"set().pop()",
+			"KeyError: 'pop from an empty set'",
+		},
+		{
+			"frozenset([1]).add(2)",
+			"FROZENSET object has no attribute 'add'",
+		},
+		{
+			"{1} | [2]",
+			"type mismatch: SET | LIST",
+		},
+		{
+			"x = 1\ndel x\nx",
+			"identifier not found: x",
+		},
+		{
+			`"a".split("")`,
+			"empty separator",
+		},
+		{
+			`"a".split(",", 1, 2)`,
+			"split() takes at most 2 arguments (3 given)",
+		},
+		{
+			`"a".split(sep=",", limit=1)`,
+			"split() got an unexpected keyword argument 'limit'",
+		},
+		{
+			`"a".startswith(1)`,
+			"startswith first arg must be str or a tuple of str, not INTEGER",
+		},
+		{
+			`"a".center(3, "ab")`,
+			"The fill character must be exactly one character long",
+		},
+		{
+			`"é".encode("ascii")`,
+			"'ascii' codec can't encode character '\\xe9' in position 0: ordinal not in range(128)",
+		},
+		{
+			`"a".upper(1)`,
+			"string.upper() takes no arguments",
+		},
+		{
+			`ord("ab")`,
+			"ord() expected a character, but string of length 2 found",
+		},
+		{
+			"chr(-1)",
+			"chr() arg not in range(0x110000)",
+		},
+		{
+			`1 in "abc"`,
+			"'in <string>' requires string as left operand, not INTEGER",
+		},
+		{
+			"{([1],): 1}",
+			"unusable as hash key: LIST",
+		},
+		{
+			"def f(x, y):\n\treturn x\nf(1)",
+			"f() missing required argument: 'y'",
+		},
+		{
+			"def f(x):\n\treturn x\nf(1, 2)",
+			"f() takes 1 positional arguments but 2 were given",
+		},
+		{
+			"def f(x):\n\treturn x\nf(1, z=2)",
+			"f() got an unexpected keyword argument 'z'",
+		},
+		{
+			"def f(x):\n\treturn x\nf(1, x=2)",
+			"f() got multiple values for argument 'x'",
+		},
+		{
+			"def f(x):\n\treturn x\nf(x=1, x=2)",
+			"keyword argument repeated: x",
+		},
+		{
+			"class A:\n\tx = 0\nA(x=1)",
+			"A() takes no arguments",
+		},
 	}
 
 	for _, tt := range tests {
@@ -832,3 +2578,201 @@ def f(x):
 		}
 	}
 }
+
+func TestThreadingModule(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected interface{}
+	}{
+		{`lock = threading.Lock()
+counts = {"n": 0}
+def work(k):
+    for i in range(k):
+        lock.acquire()
+        counts["n"] = counts["n"] + 1
+        lock.release()
+workers = []
+for i in range(4):
+    worker = threading.Thread(target=work, args=[300])
+    workers.append(worker)
+    worker.start()
+for worker in workers:
+    worker.join()
+counts["n"]`, 1200},
+		{`results = []
+def work(a, b):
+    results.append(a + b)
+worker = threading.Thread(target=work, args=(1,), kwargs={"b": 2})
+worker.start()
+worker.join()
+results[0] + len(results)`, 4},
+		{`worker = threading.Thread(target=len, args=[[1]], name="w")
+before = worker.is_alive()
+worker.start()
+worker.join()
+str(before) + " " + str(worker.is_alive()) + " " + worker.name`, "false false w"},
+		{`lock = threading.RLock()
+lock.acquire()
+lock.acquire()
+lock.release()
+held = lock.locked()
+lock.release()
+str(held) + " " + str(lock.locked())`, "true false"},
+		{`lock = threading.Lock()
+lock.acquire()
+str(lock.acquire(false)) + " " + str(lock.acquire(timeout=0.01))`, "false false"},
+		{`ready = threading.Event()
+def work():
+    ready.set()
+threading.Thread(target=work).start()
+str(ready.wait(5)) + " " + str(ready.is_set())`, "true true"},
+		{`flag = threading.Event()
+flag.wait(0.01)`, false},
+		{`cond = threading.Condition()
+items = []
+def produce():
+    for i in range(5):
+        cond.acquire()
+        items.append(i)
+        cond.notify()
+        cond.release()
+cond.acquire()
+threading.Thread(target=produce).start()
+total = 0
+taken = 0
+while taken < 5:
+    if len(items) == 0:
+        cond.wait()
+    else:
+        total = total + items.pop(0)
+        taken = taken + 1
+cond.release()
+total`, 10},
+		{`jobs = queue.Queue()
+results = queue.Queue(maxsize=1)
+def consume():
+    total = 0
+    item = jobs.get()
+    while item >= 0:
+        total = total + item
+        jobs.task_done()
+        item = jobs.get()
+    jobs.task_done()
+    results.put(total)
+threading.Thread(target=consume).start()
+for i in range(10):
+    jobs.put(i)
+jobs.put(-1)
+jobs.join()
+results.get(timeout=5)`, 45},
+		{`q = queue.Queue(2)
+q.put_nowait(1)
+q.put(2)
+str(q.full()) + " " + str(q.qsize()) + " " + str(q.get_nowait())`, "true 2 1"},
+		{"q = queue.Queue()\nq.get_nowait()", errors.New("queue.Empty: get from an empty queue")},
+		{"q = queue.Queue()\nq.get(timeout=0.01)", errors.New("queue.Empty: get from an empty queue")},
+		{"q = queue.Queue(1)\nq.put(1)\nq.put(2, false)", errors.New("queue.Full: put to a full queue")},
+		{"q = queue.Queue()\nq.task_done()", errors.New("ValueError: task_done() called too many times")},
+		{"threading.Lock().release()", errors.New("RuntimeError: release unlocked lock")},
+		{"threading.RLock().release()", errors.New("RuntimeError: cannot release un-acquired lock")},
+		{"threading.Condition().wait()", errors.New("RuntimeError: cannot wait on un-acquired lock")},
+		{"threading.Condition(1)", errors.New("Condition() argument must be a Lock or RLock, not INTEGER")},
+		{"threading.Thread(target=len).join()", errors.New("RuntimeError: cannot join thread before it is started")},
+		{"worker = threading.Thread(target=len, args=[[]])\nworker.start()\nworker.start()", errors.New("RuntimeError: threads can only be started once")},
+		{"threading.Lock().owner", errors.New("<unlocked Lock> has no attribute 'owner'")},
+	}
+
+	for _, tt := range tests {
+		env := object.NewEnvironment()
+		runtime := NewRuntime(strings.NewReader(""), io.Discard, io.Discard)
+		env.SetRuntime(runtime)
+
+		program := parser.New(lexer.New("import threading\nimport queue\n" + tt.input)).ParseProgram()
+		evaluated := Eval(program, env)
+		WaitThreads(runtime)
+
+		switch expected := tt.expected.(type) {
+		case int:
+			testIntegerObject(t, evaluated, int64(expected))
+		case bool:
+			testBooleanObject(t, evaluated, expected)
+		case string:
+			testStringObject(t, evaluated, expected)
+		case error:
+			errObj, ok := evaluated.(*object.Error)
+			if !ok {
+				t.Errorf("%q: no error object returned. got=%T (%+v)", tt.input, evaluated, evaluated)
+				continue
+			}
+			if errObj.Message != expected.Error() {
+				t.Errorf("%q: wrong error message. expected=%q, got=%q", tt.input, expected, errObj.Message)
+			}
+		}
+	}
+}
+
+// FuzzEval checks that no script can panic the interpreter: every failure must
+// come back as an error object
+func FuzzEval(f *testing.F) {
+	seeds := []string{
+		"x = 5\nx / 0",
+		"[1, 2, 3][10]",
+		"[1, 2][-5] = 3",
+		"'abc'.nosuch()",
+		"sorted([1, 'a', None])",
+		"{[1]: 2}",
+		"d = {}\nd[{}] = 1",
+		"x = 1\nx.y = 2",
+		"int('x') + float('y')",
+		"'%d %s' % (1,)",
+		"f'{1:>{2}d}'",
+		"a, b = 1",
+		"xs = [1, 2, 3]\ndel xs[5]\nxs[::0]",
+		"(1, 2) < (1, 'a')",
+		"-(-9223372036854775807 - 1) // -1 % 0",
+		"set([1]) - 1",
+		"b'abc'[7]",
+		"str(bytes([256]))",
+		"[].pop()",
+		"a = [1]\na.append(a)\nstr(a) + repr(a == a)",
+		"d = {}\nd[1] = d\nf'{d}'",
+		"while true:\n    x = 1",
+		"def f(n):\n    return f(n + 1)\nf(0)",
+		"xs = [1]\nwhile true:\n    xs = xs + xs",
+		"range(-9223372036854775807, 9223372036854775807)",
+		"'ab'.center(999999) * 999999",
+		"f'{1:>50000000}'",
+		"'{:>50000000}'.format(1)",
+		"format(1.5, '.50000000f')",
+		"'%50000000d' % 1",
+		"'%.*f' % (50000000, 1.5)",
+		"a = [1]\na.append(a)\nb = [1]\nb.append(b)\na < b",
+		"a = {}\na[1] = a\nb = {}\nb[1] = b\na == b",
+	}
+	for _, seed := range seeds {
+		f.Add(seed)
+	}
+
+	f.Fuzz(func(t *testing.T, input string) {
+		// Imports read the filesystem, so they are left out
+		if strings.Contains(input, "import") {
+			t.Skip()
+		}
+
+		p := parser.New(lexer.New(input))
+		program := p.ParseProgram()
+		if len(p.Errors()) != 0 {
+			return
+		}
+
+		runtime := NewRuntime(strings.NewReader(""), io.Discard, io.Discard)
+		runtime.MaxSteps = 100000
+		runtime.MaxAlloc = 1 << 16
+		env := object.NewEnvironment()
+		env.SetRuntime(runtime)
+
+		if result := Eval(program, env); result != nil {
+			_ = result.Inspect()
+		}
+	})
+}
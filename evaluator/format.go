@@ -0,0 +1,859 @@
+package evaluator
+
+import (
+	"fmt"
+	"math"
+	"simpyl/ast"
+	"simpyl/object"
+	"strconv"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+/*
+Format Specifications
+*/
+
+// formatSpec is a parsed format specification in Python's mini-language, shared
+// by f-strings, str.format, the format builtin and % formatting:
+// [[fill]align][sign][#][0][width][grouping][.precision][type]
+type formatSpec struct {
+	fill      rune // 0 when absent
+	align     rune // '<', '>', '^', '=' or 0 for the default of the type
+	sign      rune // '+', '-', ' ' or 0
+	alternate bool
+	zero      bool
+	width     int
+	grouping  rune // ',', '_' or 0
+	precision int  // -1 when absent
+	kind      rune // Presentation type, 0 when absent
+}
+
+func parseFormatSpec(spec string) (*formatSpec, *object.Error) {
+	fs := &formatSpec{precision: -1}
+	runes := []rune(spec)
+	isAlign := func(r rune) bool { return strings.ContainsRune("<>^=", r) }
+
+	i := 0
+	if len(runes) >= 2 && isAlign(runes[1]) {
+		fs.fill, fs.align = runes[0], runes[1]
+		i = 2
+	} else if len(runes) >= 1 && isAlign(runes[0]) {
+		fs.align = runes[0]
+		i = 1
+	}
+
+	if i < len(runes) && strings.ContainsRune("+- ", runes[i]) {
+		fs.sign = runes[i]
+		i++
+	}
+	if i < len(runes) && runes[i] == '#' {
+		fs.alternate = true
+		i++
+	}
+	if i < len(runes) && runes[i] == '0' {
+		fs.zero = true
+		i++
+	}
+
+	width, i, ok := readDigits(runes, i)
+	if !ok {
+		return nil, newError("too many decimal digits in format string")
+	}
+	fs.width = width
+
+	if i < len(runes) && (runes[i] == ',' || runes[i] == '_') {
+		fs.grouping = runes[i]
+		i++
+	}
+
+	if i < len(runes) && runes[i] == '.' {
+		start := i + 1
+		fs.precision, i, ok = readDigits(runes, start)
+		if !ok {
+			return nil, newError("too many decimal digits in format string")
+		}
+		if i == start {
+			return nil, newError("format specifier missing precision")
+		}
+	}
+
+	if i < len(runes) {
+		fs.kind = runes[i]
+		i++
+	}
+	if i < len(runes) {
+		return nil, newError("invalid format specifier '%s'", spec)
+	}
+
+	return fs, nil
+}
+
+func readDigits(runes []rune, i int) (int, int, bool) {
+	start := i
+	for i < len(runes) && '0' <= runes[i] && runes[i] <= '9' {
+		i++
+	}
+	if i == start {
+		return 0, i, true
+	}
+
+	n, err := strconv.Atoi(string(runes[start:i]))
+	return n, i, err == nil
+}
+
+// formatObject formats an object with a format spec, using __format__ for
+// instances. An empty spec gives the str() form. The width the spec pads to is
+// checked against the allocation limit of runtime before anything is padded
+func formatObject(runtime *object.Runtime, obj object.Object, spec string) (string, object.Object) {
+	if result, ok := callMethod(obj, "__format__", &object.String{Value: spec}); ok {
+		return specialString(result, "__format__")
+	}
+	if spec == "" {
+		return objectString(obj)
+	}
+
+	fs, err := parseFormatSpec(spec)
+	if err != nil {
+		return "", err
+	}
+	if err := checkFormatSize(runtime, fs, obj, fs.kind); err != nil {
+		return "", err
+	}
+
+	switch obj := obj.(type) {
+	case *object.Integer:
+		return formatInteger(obj.Value, fs)
+	case *object.Boolean:
+		if obj.Value {
+			return formatInteger(1, fs)
+		}
+		return formatInteger(0, fs)
+	case *object.Float:
+		return formatFloat(obj.Value, fs)
+	case *object.String:
+		return formatString(obj.Value, fs)
+	default:
+		return "", newError("unsupported format string passed to %s.__format__", typeName(obj))
+	}
+}
+
+func formatString(value string, fs *formatSpec) (string, object.Object) {
+	switch {
+	case fs.kind != 0 && fs.kind != 's':
+		return "", newError("unknown format code '%c' for object of type 'str'", fs.kind)
+	case fs.sign != 0:
+		return "", newError("sign not allowed in string format specifier")
+	case fs.alternate:
+		return "", newError("alternate form (#) not allowed in string format specifier")
+	case fs.grouping != 0:
+		return "", newError("cannot specify '%c' with 's'", fs.grouping)
+	case fs.align == '=':
+		return "", newError("'=' alignment not allowed in string format specifier")
+	}
+
+	if fs.precision >= 0 && utf8.RuneCountInString(value) > fs.precision {
+		value = string([]rune(value)[:fs.precision])
+	}
+
+	return pad("", value, fs, false), nil
+}
+
+func formatInteger(value int64, fs *formatSpec) (string, object.Object) {
+	switch fs.kind {
+	case 'e', 'E', 'f', 'F', 'g', 'G', '%':
+		return formatFloat(float64(value), fs)
+	}
+
+	if fs.precision >= 0 {
+		return "", newError("precision not allowed in integer format specifier")
+	}
+
+	magnitude := uint64(value)
+	if value < 0 {
+		magnitude = uint64(-value)
+	}
+
+	var digits, prefix string
+	groupSize := 3
+
+	switch fs.kind {
+	case 0, 'd', 'n':
+		digits = strconv.FormatUint(magnitude, 10)
+	case 'b':
+		digits, prefix, groupSize = strconv.FormatUint(magnitude, 2), "0b", 4
+	case 'o':
+		digits, prefix, groupSize = strconv.FormatUint(magnitude, 8), "0o", 4
+	case 'x':
+		digits, prefix, groupSize = strconv.FormatUint(magnitude, 16), "0x", 4
+	case 'X':
+		digits, prefix, groupSize = strings.ToUpper(strconv.FormatUint(magnitude, 16)), "0X", 4
+	case 'c':
+		if value < 0 || value > unicode.MaxRune {
+			return "", newError("%%c arg not in range(0x110000)")
+		}
+		return pad("", string(rune(value)), fs, true), nil
+	default:
+		return "", newError("unknown format code '%c' for object of type 'int'", fs.kind)
+	}
+
+	if fs.grouping != 0 {
+		if fs.grouping == ',' && groupSize != 3 {
+			return "", newError("cannot specify ',' with '%c'", fs.kind)
+		}
+		digits = groupDigits(digits, groupSize, fs.grouping)
+	}
+
+	if !fs.alternate {
+		prefix = ""
+	}
+
+	return pad(signOf(value < 0, fs)+prefix, digits, fs, true), nil
+}
+
+func formatFloat(value float64, fs *formatSpec) (string, object.Object) {
+	negative := math.Signbit(value) && !math.IsNaN(value)
+	magnitude := math.Abs(value)
+
+	precision := fs.precision
+	if precision < 0 {
+		precision = 6
+	}
+
+	if fs.kind != 0 && !strings.ContainsRune("eEfFgGn%", fs.kind) {
+		return "", newError("unknown format code '%c' for object of type 'float'", fs.kind)
+	}
+
+	var body string
+	switch {
+	case fs.kind == 0:
+		body = formatFloatDefault(magnitude, fs.precision)
+	case math.IsInf(magnitude, 0):
+		body = "inf"
+	case math.IsNaN(magnitude):
+		body = "nan"
+	case fs.kind == 'f' || fs.kind == 'F':
+		body = strconv.FormatFloat(magnitude, 'f', precision, 64)
+	case fs.kind == 'e' || fs.kind == 'E':
+		body = strconv.FormatFloat(magnitude, 'e', precision, 64)
+	case fs.kind == '%':
+		body = strconv.FormatFloat(magnitude*100, 'f', precision, 64)
+	default:
+		body = strconv.FormatFloat(magnitude, 'g', max(precision, 1), 64)
+	}
+
+	if fs.kind == '%' {
+		body += "%"
+	}
+	if fs.kind == 'E' || fs.kind == 'F' || fs.kind == 'G' {
+		body = strings.ToUpper(body)
+	}
+
+	if fs.grouping != 0 && body != "" && '0' <= body[0] && body[0] <= '9' {
+		end := strings.IndexFunc(body, func(r rune) bool { return r < '0' || r > '9' })
+		if end < 0 {
+			end = len(body)
+		}
+		body = groupDigits(body[:end], 3, fs.grouping) + body[end:]
+	}
+
+	return pad(signOf(negative, fs), body, fs, true), nil
+}
+
+// formatFloatDefault formats a float without a presentation type: the repr, or
+// with a precision, general format keeping at least one digit after the point
+func formatFloatDefault(magnitude float64, precision int) string {
+	if precision < 0 || math.IsInf(magnitude, 0) || math.IsNaN(magnitude) {
+		return object.FloatRepr(magnitude)
+	}
+
+	body := strconv.FormatFloat(magnitude, 'g', max(precision, 1), 64)
+	if !strings.ContainsAny(body, ".e") {
+		body += ".0"
+	}
+	return body
+}
+
+func signOf(negative bool, fs *formatSpec) string {
+	switch {
+	case negative:
+		return "-"
+	case fs.sign == '+':
+		return "+"
+	case fs.sign == ' ':
+		return " "
+	default:
+		return ""
+	}
+}
+
+// groupDigits inserts a separator between every size digits, counting from the right
+func groupDigits(digits string, size int, sep rune) string {
+	var out strings.Builder
+	for i, ch := range digits {
+		if i > 0 && (len(digits)-i)%size == 0 {
+			out.WriteRune(sep)
+		}
+		out.WriteRune(ch)
+	}
+	return out.String()
+}
+
+// checkFormatSize applies the allocation limit of runtime to the width a spec
+// pads to and, for floats, to the digits its precision asks for, before either
+// is built
+func checkFormatSize(runtime *object.Runtime, fs *formatSpec, obj object.Object, kind rune) *object.Error {
+	size := fs.width
+	if _, ok := obj.(*object.Float); ok || kind != 0 && strings.ContainsRune("eEfFgG%", kind) {
+		size = max(size, fs.precision)
+	}
+	return checkAllocation(runtime, int64(size))
+}
+
+// pad aligns a formatted value within the spec's width. Numbers align right by
+// default, and a leading zero in the spec pads them with zeros after the sign.
+func pad(sign, body string, fs *formatSpec, numeric bool) string {
+	fill := fs.fill
+	if fill == 0 {
+		fill = ' '
+		if fs.zero {
+			fill = '0'
+		}
+	}
+
+	align := fs.align
+	switch {
+	case align != 0:
+	case numeric && fs.zero:
+		align = '='
+	case numeric:
+		align = '>'
+	default:
+		align = '<'
+	}
+
+	n := fs.width - utf8.RuneCountInString(sign) - utf8.RuneCountInString(body)
+	if n <= 0 {
+		return sign + body
+	}
+
+	padding := func(count int) string { return strings.Repeat(string(fill), count) }
+	switch align {
+	case '<':
+		return sign + body + padding(n)
+	case '^':
+		return padding(n/2) + sign + body + padding(n-n/2)
+	case '=':
+		return sign + padding(n) + body
+	default:
+		return padding(n) + sign + body
+	}
+}
+
+// convertAndFormat applies a !s or !r conversion before formatting with spec
+func convertAndFormat(runtime *object.Runtime, obj object.Object, conversion byte, spec string) (string, object.Object) {
+	var str string
+	var err object.Object
+
+	switch conversion {
+	case 's':
+		str, err = objectString(obj)
+	case 'r':
+		str, err = objectRepr(obj)
+	default:
+		return formatObject(runtime, obj, spec)
+	}
+
+	if err != nil {
+		return "", err
+	}
+	return formatObject(runtime, &object.String{Value: str}, spec)
+}
+
+/*
+Formatted String Literals
+*/
+func evalFormattedString(node *ast.FormattedString, env *object.Environment) object.Object {
+	runtime := env.Runtime()
+	var out strings.Builder
+
+	for _, part := range node.Parts {
+		switch part := part.(type) {
+		case *ast.StringLiteral:
+			out.WriteString(part.Value)
+
+		case *ast.FormattedValue:
+			val := Eval(part.Value, env)
+			if isError(val) {
+				return val
+			}
+
+			spec := ""
+			if part.FormatSpec != nil {
+				result := evalFormattedString(part.FormatSpec, env)
+				if isError(result) {
+					return result
+				}
+				spec = result.(*object.String).Value
+			}
+
+			str, err := convertAndFormat(runtime, val, part.Conversion, spec)
+			if err != nil {
+				return err
+			}
+			out.WriteString(str)
+		}
+	}
+
+	return &object.String{Value: out.String()}
+}
+
+/*
+str.format
+*/
+
+// fieldFormatter fills the replacement fields of str.format from positional
+// arguments, by index or in order, and keyword arguments by name
+type fieldFormatter struct {
+	runtime   *object.Runtime
+	args      []object.Object
+	kwargs    map[string]object.Object
+	next      int
+	automatic bool
+	manual    bool
+}
+
+func formatFields(runtime *object.Runtime, format string, args []object.Object, kwargs map[string]object.Object) object.Object {
+	f := &fieldFormatter{runtime: runtime, args: args, kwargs: kwargs}
+
+	str, err := f.format(format, 2)
+	if err != nil {
+		return err
+	}
+	return &object.String{Value: str}
+}
+
+func (f *fieldFormatter) format(format string, depth int) (string, object.Object) {
+	if depth < 0 {
+		return "", newError("max string recursion exceeded")
+	}
+
+	var out strings.Builder
+	for i := 0; i < len(format); i++ {
+		ch := format[i]
+
+		switch {
+		case ch == '{' && strings.HasPrefix(format[i:], "{{"):
+			out.WriteByte('{')
+			i++
+		case ch == '}' && strings.HasPrefix(format[i:], "}}"):
+			out.WriteByte('}')
+			i++
+		case ch == '}':
+			return "", newError("single '}' encountered in format string")
+		case ch == '{':
+			end := matchingBrace(format, i)
+			if end < 0 {
+				return "", newError("single '{' encountered in format string")
+			}
+
+			str, err := f.field(format[i+1:end], depth)
+			if err != nil {
+				return "", err
+			}
+			out.WriteString(str)
+			i = end
+		default:
+			out.WriteByte(ch)
+		}
+	}
+
+	return out.String(), nil
+}
+
+// matchingBrace finds the '}' closing the field opened at start, allowing
+// nested fields in the format spec
+func matchingBrace(format string, start int) int {
+	depth := 0
+	for i := start; i < len(format); i++ {
+		switch format[i] {
+		case '{':
+			depth++
+		case '}':
+			depth--
+			if depth == 0 {
+				return i
+			}
+		}
+	}
+	return -1
+}
+
+func (f *fieldFormatter) field(field string, depth int) (string, object.Object) {
+	// The field name ends at the first '!' or ':' outside an index
+	end := len(field)
+	for i, inIndex := 0, false; i < len(field); i++ {
+		if field[i] == '[' {
+			inIndex = true
+		} else if field[i] == ']' {
+			inIndex = false
+		} else if !inIndex && (field[i] == '!' || field[i] == ':') {
+			end = i
+			break
+		}
+	}
+	name, rest := field[:end], field[end:]
+
+	var conversion byte
+	if strings.HasPrefix(rest, "!") {
+		if len(rest) < 2 || rest[1] != 's' && rest[1] != 'r' {
+			return "", newError("unknown conversion specifier %s", strings.TrimPrefix(rest, "!"))
+		}
+		conversion = rest[1]
+		rest = rest[2:]
+		if rest != "" && rest[0] != ':' {
+			return "", newError("expected ':' after conversion specifier")
+		}
+	}
+
+	// The value is looked up before any nested fields in the spec are numbered
+	val, err := f.lookup(name)
+	if err != nil {
+		return "", err
+	}
+
+	spec, err := f.format(strings.TrimPrefix(rest, ":"), depth-1)
+	if err != nil {
+		return "", err
+	}
+
+	return convertAndFormat(f.runtime, val, conversion, spec)
+}
+
+// lookup resolves a field name such as "", "0", "name", "0.attr" or "name[key]"
+func (f *fieldFormatter) lookup(name string) (object.Object, object.Object) {
+	end := strings.IndexAny(name, ".[")
+	if end < 0 {
+		end = len(name)
+	}
+	first, accessors := name[:end], name[end:]
+
+	var val object.Object
+	if index, err := strconv.Atoi(first); err == nil || first == "" {
+		if first == "" {
+			if f.manual {
+				return nil, newError("cannot switch from manual field specification to automatic field numbering")
+			}
+			f.automatic = true
+			index = f.next
+			f.next++
+		} else {
+			if f.automatic {
+				return nil, newError("cannot switch from automatic field numbering to manual field specification")
+			}
+			f.manual = true
+		}
+
+		if index >= len(f.args) {
+			return nil, newError("replacement index %d out of range for positional args tuple", index)
+		}
+		val = f.args[index]
+	} else {
+		kwarg, ok := f.kwargs[first]
+		if !ok {
+			return nil, newError("KeyError: '%s'", first)
+		}
+		val = kwarg
+	}
+
+	for accessors != "" {
+		if accessors[0] == '.' {
+			end := strings.IndexAny(accessors[1:], ".[") + 1
+			if end <= 0 {
+				end = len(accessors)
+			}
+			val = evalAttribute(val, accessors[1:end])
+			accessors = accessors[end:]
+		} else {
+			end := strings.IndexByte(accessors, ']')
+			if end < 0 {
+				return nil, newError("missing ']' in format string")
+			}
+
+			var key object.Object = &object.String{Value: accessors[1:end]}
+			if n, err := strconv.ParseInt(accessors[1:end], 10, 64); err == nil {
+				key = &object.Integer{Value: n}
+			}
+			val = evalIndexExpression(val, key)
+			accessors = accessors[end+1:]
+		}
+
+		if isError(val) {
+			return nil, val
+		}
+	}
+
+	return val, nil
+}
+
+/*
+Percent Formatting
+*/
+
+// formatPercent implements printf-style formatting for format % values. A tuple
+// supplies several values and a dict supplies values for %(name) keys.
+func formatPercent(runtime *object.Runtime, format string, values object.Object) object.Object {
+	args := []object.Object{values}
+	if tuple, ok := values.(*object.Tuple); ok {
+		args = tuple.Elements
+	}
+	mapping, _ := values.(*object.Dict)
+
+	next := 0
+	nextArg := func() (object.Object, object.Object) {
+		if next >= len(args) {
+			return nil, newError("not enough arguments for format string")
+		}
+		next++
+		return args[next-1], nil
+	}
+
+	var out strings.Builder
+	for i := 0; i < len(format); i++ {
+		if format[i] != '%' {
+			out.WriteByte(format[i])
+			continue
+		}
+
+		start := i
+		i++
+		if i >= len(format) {
+			return newError("incomplete format")
+		}
+
+		var arg object.Object
+		if format[i] == '(' {
+			if mapping == nil {
+				return newError("format requires a mapping")
+			}
+			end := strings.IndexByte(format[i:], ')')
+			if end < 0 {
+				return newError("incomplete format key")
+			}
+
+			key := format[i+1 : i+end]
+			arg = evalDictIndexExpression(mapping, &object.String{Value: key})
+			if isError(arg) {
+				return arg
+			}
+			i += end + 1
+		}
+
+		fs := &formatSpec{precision: -1}
+		for ; i < len(format) && strings.IndexByte("-+ #0", format[i]) >= 0; i++ {
+			switch format[i] {
+			case '-':
+				fs.align = '<'
+			case '+':
+				fs.sign = '+'
+			case ' ':
+				if fs.sign == 0 {
+					fs.sign = ' '
+				}
+			case '#':
+				fs.alternate = true
+			case '0':
+				fs.zero = true
+			}
+		}
+		if fs.align == '<' {
+			fs.zero = false
+		}
+
+		width, n, err := percentNumber(format, i, nextArg)
+		if err != nil {
+			return err
+		}
+		if width < 0 {
+			fs.align, fs.zero, width = '<', false, -width
+		}
+		fs.width, i = width, n
+
+		if i < len(format) && format[i] == '.' {
+			precision, n, err := percentNumber(format, i+1, nextArg)
+			if err != nil {
+				return err
+			}
+			fs.precision, i = max(precision, 0), n
+		}
+
+		for i < len(format) && strings.IndexByte("hlL", format[i]) >= 0 {
+			i++
+		}
+		if i >= len(format) {
+			return newError("incomplete format")
+		}
+
+		conversion := format[i]
+		if conversion == '%' {
+			out.WriteByte('%')
+			continue
+		}
+
+		if arg == nil {
+			arg, err = nextArg()
+			if err != nil {
+				return err
+			}
+		}
+
+		if err := checkFormatSize(runtime, fs, arg, rune(conversion)); err != nil {
+			return err
+		}
+		str, err := formatPercentValue(conversion, arg, fs, start)
+		if err != nil {
+			return err
+		}
+		out.WriteString(str)
+	}
+
+	if mapping == nil && next < len(args) {
+		return newError("not all arguments converted during string formatting")
+	}
+
+	return &object.String{Value: out.String()}
+}
+
+// percentNumber reads a width or precision, where '*' takes the next argument
+func percentNumber(format string, i int, nextArg func() (object.Object, object.Object)) (int, int, object.Object) {
+	if i < len(format) && format[i] == '*' {
+		arg, err := nextArg()
+		if err != nil {
+			return 0, i, err
+		}
+		n, ok := arg.(*object.Integer)
+		if !ok {
+			return 0, i, newError("* wants int")
+		}
+		return int(n.Value), i + 1, nil
+	}
+
+	// Digits are single bytes, so the rune count consumed is also the byte count
+	n, end, ok := readDigits([]rune(format[i:]), 0)
+	if !ok {
+		return 0, i, newError("width too big")
+	}
+	return n, i + end, nil
+}
+
+func formatPercentValue(conversion byte, arg object.Object, fs *formatSpec, index int) (string, object.Object) {
+	switch conversion {
+	case 's', 'r', 'a':
+		convert := objectString
+		if conversion != 's' {
+			convert = objectRepr
+		}
+		str, err := convert(arg)
+		if err != nil {
+			return "", err
+		}
+
+		fs.sign, fs.alternate, fs.zero = 0, false, false
+		if fs.align == 0 {
+			fs.align = '>'
+		}
+		return formatString(str, fs)
+
+	case 'd', 'i', 'u', 'o', 'x', 'X':
+		kind := rune(conversion)
+		if kind == 'i' || kind == 'u' {
+			kind = 'd'
+		}
+		fs.kind, fs.precision = kind, -1
+
+		switch arg := arg.(type) {
+		case *object.Integer:
+			return formatInteger(arg.Value, fs)
+		case *object.Boolean:
+			if arg.Value {
+				return formatInteger(1, fs)
+			}
+			return formatInteger(0, fs)
+		case *object.Float:
+			if kind == 'd' && !math.IsInf(arg.Value, 0) && !math.IsNaN(arg.Value) {
+				return formatInteger(int64(arg.Value), fs)
+			}
+		}
+		if kind == 'd' {
+			return "", newError("%%%c format: a real number is required, not %s", conversion, typeName(arg))
+		}
+		return "", newError("%%%c format: an integer is required, not %s", conversion, typeName(arg))
+
+	case 'e', 'E', 'f', 'F', 'g', 'G':
+		fs.kind = rune(conversion)
+
+		switch arg := arg.(type) {
+		case *object.Float:
+			return formatFloat(arg.Value, fs)
+		case *object.Integer:
+			return formatFloat(float64(arg.Value), fs)
+		}
+		return "", newError("must be real number, not %s", typeName(arg))
+
+	case 'c':
+		switch arg := arg.(type) {
+		case *object.Integer:
+			fs.kind = 'c'
+			return formatInteger(arg.Value, fs)
+		case *object.String:
+			if utf8.RuneCountInString(arg.Value) == 1 {
+				return formatString(arg.Value, fs)
+			}
+		}
+		return "", newError("%%c requires an int or a unicode character")
+
+	default:
+		return "", newError("unsupported format character '%c' (0x%x) at index %d",
+			conversion, conversion, index+1)
+	}
+}
+
+/*
+String Representation
+*/
+
+// stringRepr quotes a string the way Python's repr does
+func stringRepr(value string) string {
+	quote := '\''
+	if strings.ContainsRune(value, '\'') && !strings.ContainsRune(value, '"') {
+		quote = '"'
+	}
+
+	var out strings.Builder
+	out.WriteRune(quote)
+	for _, r := range value {
+		switch {
+		case r == quote || r == '\\':
+			out.WriteRune('\\')
+			out.WriteRune(r)
+		case r == '\t':
+			out.WriteString(`\t`)
+		case r == '\n':
+			out.WriteString(`\n`)
+		case r == '\r':
+			out.WriteString(`\r`)
+		case unicode.IsPrint(r):
+			out.WriteRune(r)
+		case r < 0x100:
+			fmt.Fprintf(&out, `\x%02x`, r)
+		case r < 0x10000:
+			fmt.Fprintf(&out, `\u%04x`, r)
+		default:
+			fmt.Fprintf(&out, `\U%08x`, r)
+		}
+	}
+	out.WriteRune(quote)
+
+	return out.String()
+}
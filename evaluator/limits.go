@@ -0,0 +1,171 @@
+package evaluator
+
+import (
+	"math"
+	"simpyl/object"
+)
+
+// DefaultMaxDepth is the number of nested function calls a new runtime allows,
+// the same as CPython's default recursion limit
+const DefaultMaxDepth = 1000
+
+// contextCheckInterval is the number of steps between checks of a runtime's
+// context, which is too slow to check on every step
+const contextCheckInterval = 256
+
+// step counts one evaluation step of a script against the limits of its runtime,
+// and stops it once the step budget is spent or its context is done
+func step(runtime *object.Runtime) *object.Error {
+	runtime.Steps++
+	if runtime.MaxSteps > 0 && runtime.Steps > runtime.MaxSteps {
+		return newError("step limit of %d exceeded", runtime.MaxSteps)
+	}
+
+	if runtime.Threads.Active && runtime.Steps%switchInterval == 0 {
+		switchThreads(runtime)
+	}
+
+	if runtime.Context != nil && runtime.Steps%contextCheckInterval == 0 {
+		if err := runtime.Context.Err(); err != nil {
+			return newError("interrupted: %s", err)
+		}
+	}
+	return nil
+}
+
+// enterCall counts a function call against the recursion limit. Each successful
+// call must be matched by leaveCall
+func enterCall(runtime *object.Runtime) *object.Error {
+	if runtime.MaxDepth > 0 && runtime.Depth >= runtime.MaxDepth {
+		return newError("RecursionError: maximum recursion depth exceeded")
+	}
+	runtime.Depth++
+	return nil
+}
+
+func leaveCall(runtime *object.Runtime) {
+	runtime.Depth--
+}
+
+// checkAllocation reports a MemoryError when a list, string or other sequence of
+// size items would exceed the allocation limit of runtime
+func checkAllocation(runtime *object.Runtime, size int64) *object.Error {
+	if runtime == nil || runtime.MaxAlloc <= 0 || size <= runtime.MaxAlloc {
+		return nil
+	}
+	return newError("MemoryError: cannot allocate %d items, the limit is %d", size, runtime.MaxAlloc)
+}
+
+// checkSize applies the allocation limit to objects that have already been
+// built, such as the result of a call or a list that a method appended to
+func checkSize(runtime *object.Runtime, objs ...object.Object) *object.Error {
+	for _, obj := range objs {
+		if err := checkAllocation(runtime, sizeOf(obj)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// sizeOf is the length of a string or container, and zero for other objects
+func sizeOf(obj object.Object) int64 {
+	switch obj := obj.(type) {
+	case *object.String:
+		return int64(len(obj.Value))
+	case *object.Bytes:
+		return int64(len(obj.Value))
+	case *object.List:
+		return int64(len(obj.Elements))
+	case *object.Tuple:
+		return int64(len(obj.Elements))
+	case *object.Dict:
+		return int64(len(obj.Pairs))
+	case *object.Set:
+		return int64(len(obj.Values))
+	}
+	return 0
+}
+
+// checkRepetition applies the allocation limit to a sequence repeated with *,
+// before the repetition is built
+func checkRepetition(runtime *object.Runtime, operator string, left, right object.Object) *object.Error {
+	if runtime == nil || runtime.MaxAlloc <= 0 || operator != "*" {
+		return nil
+	}
+
+	count, ok := right.(*object.Integer)
+	seq := left
+	if !ok {
+		count, ok = left.(*object.Integer)
+		seq = right
+	}
+	size := sizeOf(seq)
+	if !ok || count.Value <= 0 || size == 0 {
+		return nil
+	}
+
+	if count.Value > math.MaxInt64/size {
+		return checkAllocation(runtime, math.MaxInt64)
+	}
+	return checkAllocation(runtime, size*count.Value)
+}
+
+// callAllocation estimates the size of what a call will build, for the builtins
+// whose result size is set by an argument rather than by existing objects
+func callAllocation(fn object.Object, args []object.Object) int64 {
+	if fn != builtins["range"] {
+		return 0
+	}
+
+	bounds := []int64{0, 0, 1}
+	for i, arg := range args {
+		val, err := intArg("range", arg, 0)
+		if err != nil || i > 2 {
+			return 0
+		}
+		bounds[i] = val
+	}
+	start, stop, by := bounds[0], bounds[1], bounds[2]
+	if len(args) == 1 {
+		start, stop = 0, bounds[0]
+	}
+
+	// The differences are taken as unsigned so that they cannot overflow
+	var span, stride uint64
+	switch {
+	case by > 0 && stop > start:
+		span, stride = uint64(stop)-uint64(start), uint64(by)
+	case by < 0 && start > stop:
+		span, stride = uint64(start)-uint64(stop), -uint64(by)
+	default:
+		return 0
+	}
+
+	count := span / stride
+	if span%stride != 0 {
+		count++
+	}
+	return int64(min(count, math.MaxInt64))
+}
+
+// methodAllocation estimates the size of the string a padding method will build
+func methodAllocation(obj object.Object, name string, args []object.Object) int64 {
+	str, ok := obj.(*object.String)
+	if !ok || len(args) == 0 {
+		return 0
+	}
+	width, ok := args[0].(*object.Integer)
+	if !ok {
+		return 0
+	}
+
+	switch name {
+	case "center", "ljust", "rjust", "zfill":
+		return width.Value
+	case "expandtabs":
+		if width.Value > 0 && int64(len(str.Value)) > 0 {
+			return min(width.Value, 1<<32) * int64(len(str.Value))
+		}
+	}
+	return 0
+}
@@ -0,0 +1,196 @@
+package evaluator
+
+import (
+	"os"
+	"path/filepath"
+	"simpyl/ast"
+	"simpyl/lexer"
+	"simpyl/native"
+	"simpyl/object"
+	"simpyl/parser"
+	"strings"
+)
+
+// SourceExt is the file extension of importable Simpyl modules
+const SourceExt = ".py"
+
+/*
+Import Statements
+*/
+func evalImportStatement(node *ast.ImportStatement, env *object.Environment) object.Object {
+	module := importModule(node.Module.Value, env)
+	if isError(module) {
+		return module
+	}
+
+	name := node.Module.Value
+	if node.Alias != nil {
+		name = node.Alias.Value
+	}
+	if err := env.Set(name, module); isError(err) {
+		return err
+	}
+
+	return nil
+}
+
+func evalFromImportStatement(node *ast.FromImportStatement, env *object.Environment) object.Object {
+	module := importModule(node.Module.Value, env)
+	if isError(module) {
+		return module
+	}
+
+	for i, name := range node.Names {
+		val := evalAttribute(module, name.Value)
+		if isError(val) {
+			return newError("cannot import name '%s' from '%s'", name.Value, node.Module.Value)
+		}
+
+		bound := name.Value
+		if node.Aliases[i] != nil {
+			bound = node.Aliases[i].Value
+		}
+		if err := env.Set(bound, val); isError(err) {
+			return err
+		}
+	}
+
+	return nil
+}
+
+/*
+Module Loading
+*/
+// importModule imports a module into the runtime of env, which caches it for
+// later imports
+func importModule(name string, env *object.Environment) object.Object {
+	runtime := env.Runtime()
+	if runtime == nil {
+		return newError("cannot import '%s' without a runtime", name)
+	}
+	if !runtime.Capabilities.AllowsModule(name) {
+		return newError("PermissionError: import of module '%s' is not allowed", name)
+	}
+	if runtime.Modules == nil {
+		runtime.Modules = map[string]*object.Module{}
+	}
+
+	if module, ok := runtime.Modules[name]; ok {
+		return module
+	}
+	if build, ok := runtimeModules[name]; ok {
+		return loadNativeModule(name, build(runtime), runtime)
+	}
+	if members, ok := native.Lookup(name); ok {
+		return loadNativeModule(name, members, runtime)
+	}
+
+	path, ok := findModule(name, env)
+	if !ok {
+		return newError("No module named '%s'", name)
+	}
+
+	if module, ok := runtime.Modules[path]; ok {
+		return module
+	}
+
+	for i, loading := range runtime.Importing {
+		if loading == path {
+			chain := []string{}
+			for _, p := range runtime.Importing[i:] {
+				chain = append(chain, moduleName(p))
+			}
+			chain = append(chain, name)
+			return newError("circular import: %s", strings.Join(chain, " -> "))
+		}
+	}
+
+	return loadModule(name, path, runtime)
+}
+
+// findModule resolves a module name against the directory of the importing
+// file, then each directory listed in SIMPYLPATH
+func findModule(name string, env *object.Environment) (string, bool) {
+	dirs := []string{"."}
+	if file, ok := env.Get("__file__"); ok {
+		if file, ok := file.(*object.String); ok {
+			dirs[0] = filepath.Dir(file.Value)
+		}
+	}
+	dirs = append(dirs, filepath.SplitList(os.Getenv("SIMPYLPATH"))...)
+
+	for _, dir := range dirs {
+		if dir == "" {
+			continue
+		}
+		path, err := filepath.Abs(filepath.Join(dir, name+SourceExt))
+		if err != nil {
+			continue
+		}
+		if info, err := os.Stat(path); err == nil && !info.IsDir() {
+			return path, true
+		}
+	}
+
+	return "", false
+}
+
+// loadModule runs a module in a fresh environment that shares the runtime of
+// the importing script
+func loadModule(name, path string, runtime *object.Runtime) object.Object {
+	src, err := os.ReadFile(path)
+	if err != nil {
+		return newError("cannot read module '%s': %s", name, err)
+	}
+
+	l := lexer.New(string(src))
+	p := parser.New(l)
+	program := p.ParseProgram()
+	if len(p.Errors()) != 0 {
+		return newError("error parsing module '%s':\n%s", name, strings.Join(p.Errors(), "\n"))
+	}
+
+	env := object.NewEnvironment()
+	env.SetRuntime(runtime)
+	env.Set("__name__", &object.String{Value: name})
+	env.Set("__file__", &object.String{Value: path})
+	module := &object.Module{Name: name, Path: path, Env: env}
+
+	runtime.Importing = append(runtime.Importing, path)
+	result := Eval(program, env)
+	runtime.Importing = runtime.Importing[:len(runtime.Importing)-1]
+
+	if isError(result) {
+		return result
+	}
+
+	runtime.Modules[path] = module
+	return module
+}
+
+func loadNativeModule(name string, members native.Members, runtime *object.Runtime) object.Object {
+	env := object.NewEnvironment()
+	for key, val := range members {
+		env.Set(key, val)
+	}
+	env.Set("__name__", &object.String{Value: name})
+
+	module := &object.Module{Name: name, Env: env}
+	runtime.Modules[name] = module
+	return module
+}
+
+func moduleName(path string) string {
+	return strings.TrimSuffix(filepath.Base(path), SourceExt)
+}
+
+/*
+Module Attributes
+*/
+func evalModuleAttribute(module *object.Module, name string) object.Object {
+	if val, ok := module.Env.Get(name); ok {
+		return val
+	}
+
+	return newError("module '%s' has no attribute '%s'", module.Name, name)
+}
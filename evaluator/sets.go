@@ -0,0 +1,356 @@
+package evaluator
+
+import (
+	"maps"
+	"simpyl/ast"
+	"simpyl/object"
+)
+
+/*
+Construction
+*/
+func newSet(frozen bool) *object.Set {
+	return &object.Set{Values: make(map[object.HashKey]object.Object), Frozen: frozen}
+}
+
+func evalSetLiteral(node *ast.SetLiteral, env *object.Environment) object.Object {
+	elements := evalExpressions(node.Elements, env)
+	if len(elements) == 1 && isError(elements[0]) {
+		return elements[0]
+	}
+
+	set := newSet(false)
+	if err := addToSet(set, elements); err != nil {
+		return err
+	}
+	return set
+}
+
+func evalSetComprehension(node *ast.SetComprehension, env *object.Environment) object.Object {
+	set := newSet(false)
+
+	err := evalComprehension(node.Clauses, object.NewEnclosedEnvironment(env), func(scope *object.Environment) object.Object {
+		val := Eval(node.Element, scope)
+		if isError(val) {
+			return val
+		}
+		return addToSet(set, []object.Object{val})
+	})
+	if err != nil {
+		return err
+	}
+
+	return set
+}
+
+func addToSet(set *object.Set, elements []object.Object) object.Object {
+	for _, el := range elements {
+		key, ok, err := setKey(set, el)
+		if err != nil {
+			return err
+		}
+		if !ok {
+			set.Values[key] = el
+		}
+	}
+	return nil
+}
+
+// toSet converts the argument of a set method, which may be any iterable
+func toSet(obj object.Object) (*object.Set, object.Object) {
+	if set, ok := obj.(*object.Set); ok {
+		return set, nil
+	}
+
+	elements, err := iterate(obj)
+	if err != nil {
+		return nil, newError("'%s' object is not iterable", typeName(obj))
+	}
+
+	set := newSet(false)
+	if err := addToSet(set, elements); err != nil {
+		return nil, err
+	}
+	return set, nil
+}
+
+// setConstructor builds the set and frozenset builtins, which take an optional iterable
+func setConstructor(name string, frozen bool) *object.Builtin {
+	return &object.Builtin{
+		Fn: func(args ...object.Object) object.Object {
+			if len(args) > 1 {
+				return newError("%s expected at most 1 argument, got %d", name, len(args))
+			}
+
+			set := newSet(frozen)
+			if len(args) == 1 {
+				other, err := toSet(args[0])
+				if err != nil {
+					return err
+				}
+				maps.Copy(set.Values, other.Values)
+			}
+			return set
+		},
+	}
+}
+
+/*
+Operations
+*/
+
+// combineSets applies one of the operators |, &, - and ^. The result has the type of
+// the left operand, as in Python
+func combineSets(operator string, a, b *object.Set) (*object.Set, object.Object) {
+	result := newSet(a.Frozen)
+
+	// keep adds the elements of from whose presence in other is wanted
+	keep := func(from, other *object.Set, wanted bool) object.Object {
+		for _, val := range from.Values {
+			_, ok, err := setKey(other, val)
+			if err != nil {
+				return err
+			}
+			if ok == wanted {
+				if err := addToSet(result, []object.Object{val}); err != nil {
+					return err
+				}
+			}
+		}
+		return nil
+	}
+
+	var err object.Object
+	switch operator {
+	case "|":
+		maps.Copy(result.Values, a.Values)
+		err = keep(b, a, false)
+	case "&":
+		err = keep(a, b, true)
+	case "-":
+		err = keep(a, b, false)
+	case "^":
+		if err = keep(a, b, false); err == nil {
+			err = keep(b, a, false)
+		}
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return result, nil
+}
+
+func isSubset(a, b *object.Set) (bool, object.Object) {
+	if len(a.Values) > len(b.Values) {
+		return false, nil
+	}
+	for _, val := range a.Values {
+		if _, ok, err := setKey(b, val); !ok || err != nil {
+			return false, err
+		}
+	}
+	return true, nil
+}
+
+// evalSetInfixExpression implements the set algebra operators and compares sets
+// by inclusion, so that <= tests for a subset and < for a proper subset
+func evalSetInfixExpression(operator string, left, right object.Object) object.Object {
+	a := left.(*object.Set)
+	b := right.(*object.Set)
+
+	if operator == "|" || operator == "&" || operator == "-" || operator == "^" {
+		return setResult(combineSets(operator, a, b))
+	}
+
+	var subset bool
+	var err object.Object
+	switch operator {
+	case "==", "!=", "<=", "<":
+		subset, err = isSubset(a, b)
+	case ">=", ">":
+		subset, err = isSubset(b, a)
+	default:
+		return newError("unknown operator: %s %s %s",
+			left.Type(), operator, right.Type())
+	}
+	if err != nil {
+		return err
+	}
+
+	switch operator {
+	case "==":
+		return nativeBoolToBooleanObject(len(a.Values) == len(b.Values) && subset)
+	case "!=":
+		return nativeBoolToBooleanObject(len(a.Values) != len(b.Values) || !subset)
+	case "<":
+		return nativeBoolToBooleanObject(len(a.Values) < len(b.Values) && subset)
+	case ">":
+		return nativeBoolToBooleanObject(len(a.Values) > len(b.Values) && subset)
+	default:
+		return nativeBoolToBooleanObject(subset)
+	}
+}
+
+// setResult returns the set built by combineSets, or the error that stopped it
+func setResult(set *object.Set, err object.Object) object.Object {
+	if err != nil {
+		return err
+	}
+	return set
+}
+
+/*
+Methods
+*/
+
+// setOperationMethod builds union, intersection and difference, which accept any
+// number of iterables and return a new set
+func setOperationMethod(operator string) *object.BuiltinMethod {
+	return &object.BuiltinMethod{
+		Fn: func(obj object.Object, args ...object.Object) object.Object {
+			result, err := combineSets("|", obj.(*object.Set), newSet(false))
+
+			for _, arg := range args {
+				if err != nil {
+					break
+				}
+				var other *object.Set
+				if other, err = toSet(arg); err == nil {
+					result, err = combineSets(operator, result, other)
+				}
+			}
+
+			return setResult(result, err)
+		},
+	}
+}
+
+// setUpdateMethod builds update and the *_update methods, which change the set in place
+func setUpdateMethod(name, operator string, minArgs, maxArgs int) *object.BuiltinMethod {
+	return &object.BuiltinMethod{
+		Fn: func(obj object.Object, args ...object.Object) object.Object {
+			if err := checkArgs(name, args, minArgs, maxArgs); err != nil {
+				return err
+			}
+
+			set := obj.(*object.Set)
+			for _, arg := range args {
+				other, err := toSet(arg)
+				if err != nil {
+					return err
+				}
+				result, err := combineSets(operator, set, other)
+				if err != nil {
+					return err
+				}
+				set.Values = result.Values
+			}
+
+			return NULL
+		},
+	}
+}
+
+// setRelationMethod builds issubset, issuperset and isdisjoint
+func setRelationMethod(name string, relation func(a, b *object.Set) (bool, object.Object)) *object.BuiltinMethod {
+	return &object.BuiltinMethod{
+		Fn: func(obj object.Object, args ...object.Object) object.Object {
+			if err := checkArgs(name, args, 1, 1); err != nil {
+				return err
+			}
+
+			other, err := toSet(args[0])
+			if err != nil {
+				return err
+			}
+			result, err := relation(obj.(*object.Set), other)
+			if err != nil {
+				return err
+			}
+			return nativeBoolToBooleanObject(result)
+		},
+	}
+}
+
+func setSymmetricDifference(obj object.Object, args ...object.Object) object.Object {
+	if err := checkArgs("symmetric_difference", args, 1, 1); err != nil {
+		return err
+	}
+
+	other, err := toSet(args[0])
+	if err != nil {
+		return err
+	}
+	return setResult(combineSets("^", obj.(*object.Set), other))
+}
+
+func setCopy(obj object.Object, args ...object.Object) object.Object {
+	if err := checkArgs("copy", args, 0, 0); err != nil {
+		return err
+	}
+	return setResult(combineSets("|", obj.(*object.Set), newSet(false)))
+}
+
+func setAdd(obj object.Object, args ...object.Object) object.Object {
+	if err := checkArgs("add", args, 1, 1); err != nil {
+		return err
+	}
+	return orNull(addToSet(obj.(*object.Set), args))
+}
+
+// setRemove implements remove and discard, which differ only in whether a missing
+// element is an error
+func setRemove(name string, strict bool) *object.BuiltinMethod {
+	return &object.BuiltinMethod{
+		Fn: func(obj object.Object, args ...object.Object) object.Object {
+			if err := checkArgs(name, args, 1, 1); err != nil {
+				return err
+			}
+
+			set := obj.(*object.Set)
+			key, ok, err := setKey(set, args[0])
+			if err != nil {
+				return err
+			}
+			if !ok && strict {
+				return keyError(args[0])
+			}
+			if ok {
+				removeKey(set.Values, key)
+			}
+
+			return NULL
+		},
+	}
+}
+
+// setPop removes an arbitrary element, since sets are unordered
+func setPop(obj object.Object, args ...object.Object) object.Object {
+	if err := checkArgs("pop", args, 0, 0); err != nil {
+		return err
+	}
+
+	set := obj.(*object.Set)
+	for key, val := range set.Values {
+		removeKey(set.Values, key)
+		return val
+	}
+
+	return newError("KeyError: 'pop from an empty set'")
+}
+
+func setClear(obj object.Object, args ...object.Object) object.Object {
+	if err := checkArgs("clear", args, 0, 0); err != nil {
+		return err
+	}
+	obj.(*object.Set).Values = make(map[object.HashKey]object.Object)
+	return NULL
+}
+
+func orNull(err object.Object) object.Object {
+	if err != nil {
+		return err
+	}
+	return NULL
+}
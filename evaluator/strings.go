@@ -0,0 +1,782 @@
+package evaluator
+
+import (
+	"fmt"
+	"simpyl/object"
+	"slices"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+/*
+Argument Helpers
+*/
+
+// bindArgs matches positional and keyword arguments against the parameter names of a
+// builtin method. Parameters that were not passed are left nil
+func bindArgs(name string, params []string, args []object.Object, kwargs map[string]object.Object) ([]object.Object, *object.Error) {
+	if len(args) > len(params) {
+		return nil, newError("%s() takes at most %d arguments (%d given)", name, len(params), len(args))
+	}
+
+	bound := make([]object.Object, len(params))
+	copy(bound, args)
+
+	for _, kw := range sortedKeys(kwargs) {
+		idx := -1
+		for i, param := range params {
+			if param == kw {
+				idx = i
+			}
+		}
+		if idx < 0 {
+			return nil, newError("%s() got an unexpected keyword argument '%s'", name, kw)
+		}
+		if bound[idx] != nil {
+			return nil, newError("%s() got multiple values for argument '%s'", name, kw)
+		}
+		bound[idx] = kwargs[kw]
+	}
+
+	return bound, nil
+}
+
+func sortedKeys(kwargs map[string]object.Object) []string {
+	keys := make([]string, 0, len(kwargs))
+	for key := range kwargs {
+		keys = append(keys, key)
+	}
+	slices.Sort(keys)
+	return keys
+}
+
+// checkArgs validates the number of arguments passed to a string method
+func checkArgs(name string, args []object.Object, minArgs, maxArgs int) *object.Error {
+	switch {
+	case len(args) < minArgs && minArgs == maxArgs:
+		return newError("%s() takes exactly %d arguments (%d given)", name, minArgs, len(args))
+	case len(args) < minArgs:
+		return newError("%s() takes at least %d arguments (%d given)", name, minArgs, len(args))
+	case len(args) > maxArgs && maxArgs == 0:
+		return newError("%s() takes no arguments (%d given)", name, len(args))
+	case len(args) > maxArgs:
+		return newError("%s() takes at most %d arguments (%d given)", name, maxArgs, len(args))
+	}
+	return nil
+}
+
+// stringArg returns the value of a string argument. A missing or null argument
+// yields the default
+func stringArg(name string, arg object.Object, def string) (string, *object.Error) {
+	switch arg := arg.(type) {
+	case nil, *object.Null:
+		return def, nil
+	case *object.String:
+		return arg.Value, nil
+	default:
+		return "", newError("%s() argument must be str, not %s", name, typeName(arg))
+	}
+}
+
+func intArg(name string, arg object.Object, def int64) (int64, *object.Error) {
+	switch arg := arg.(type) {
+	case nil:
+		return def, nil
+	case *object.Integer:
+		return arg.Value, nil
+	case *object.Boolean:
+		if arg.Value {
+			return 1, nil
+		}
+		return 0, nil
+	default:
+		return 0, newError("%s() argument must be int, not %s", name, typeName(arg))
+	}
+}
+
+// substring applies the optional start and end arguments of methods such as find
+// and count. The returned offset is the code point index of the substring, and ok
+// is false when the range lies outside the string, where even "" cannot match
+func substring(value string, bounds []object.Object) (sub string, offset int64, ok bool, err *object.Error) {
+	runes := []rune(value)
+	length := int64(len(runes))
+	start, end := int64(0), length
+
+	for i, bound := range bounds {
+		switch bound := bound.(type) {
+		case *object.Null:
+		case *object.Integer:
+			if i == 0 {
+				start = bound.Value
+			} else {
+				end = bound.Value
+			}
+		default:
+			return "", 0, false, newError("slice indices must be integers, got %s", typeName(bound))
+		}
+	}
+
+	if start < 0 {
+		start = max(start+length, 0)
+	}
+	if start > length {
+		return "", 0, false, nil
+	}
+	end = clampSliceIndex(end, length)
+	if end < start {
+		return "", 0, false, nil
+	}
+
+	return string(runes[start:end]), start, true, nil
+}
+
+// runeIndex converts a byte index in str to a code point index
+func runeIndex(str string, idx int) int64 {
+	return int64(utf8.RuneCountInString(str[:idx]))
+}
+
+/*
+Splitting
+*/
+
+// isSpace reports whitespace the way Python's str.isspace does, which also
+// includes the ASCII file, group, record and unit separators
+func isSpace(r rune) bool {
+	return unicode.IsSpace(r) || (r >= 0x1c && r <= 0x1f)
+}
+
+func stringSplit(obj object.Object, kwargs map[string]object.Object, args ...object.Object) object.Object {
+	return splitString("split", obj, kwargs, args, false)
+}
+
+func stringRsplit(obj object.Object, kwargs map[string]object.Object, args ...object.Object) object.Object {
+	return splitString("rsplit", obj, kwargs, args, true)
+}
+
+func splitString(name string, obj object.Object, kwargs map[string]object.Object, args []object.Object, reverse bool) object.Object {
+	bound, err := bindArgs(name, []string{"sep", "maxsplit"}, args, kwargs)
+	if err != nil {
+		return err
+	}
+	maxsplit, err := intArg(name, bound[1], -1)
+	if err != nil {
+		return err
+	}
+
+	value := obj.(*object.String).Value
+	var parts []string
+
+	switch sep := bound[0].(type) {
+	case nil, *object.Null:
+		if reverse {
+			parts = rsplitWhitespace(value, maxsplit)
+		} else {
+			parts = splitWhitespace(value, maxsplit)
+		}
+	case *object.String:
+		if sep.Value == "" {
+			return newError("empty separator")
+		}
+		if reverse {
+			parts = rsplitSeparator(value, sep.Value, maxsplit)
+		} else if maxsplit < 0 {
+			parts = strings.Split(value, sep.Value)
+		} else {
+			parts = strings.SplitN(value, sep.Value, int(maxsplit)+1)
+		}
+	default:
+		return newError("must be str or null, not %s", typeName(sep))
+	}
+
+	return stringList(parts)
+}
+
+// splitWhitespace splits on runs of whitespace, dropping empty strings. Once maxsplit
+// is reached the remainder is kept whole, including any trailing whitespace
+func splitWhitespace(value string, maxsplit int64) []string {
+	parts := []string{}
+	rest := strings.TrimLeftFunc(value, isSpace)
+
+	for rest != "" {
+		if maxsplit == 0 {
+			parts = append(parts, rest)
+			break
+		}
+
+		end := strings.IndexFunc(rest, isSpace)
+		if end < 0 {
+			parts = append(parts, rest)
+			break
+		}
+
+		parts = append(parts, rest[:end])
+		rest = strings.TrimLeftFunc(rest[end:], isSpace)
+		maxsplit--
+	}
+
+	return parts
+}
+
+func rsplitWhitespace(value string, maxsplit int64) []string {
+	parts := []string{}
+	rest := strings.TrimRightFunc(value, isSpace)
+
+	for rest != "" {
+		if maxsplit == 0 {
+			parts = append(parts, rest)
+			break
+		}
+
+		start := strings.LastIndexFunc(rest, isSpace)
+		if start < 0 {
+			parts = append(parts, rest)
+			break
+		}
+
+		_, size := utf8.DecodeRuneInString(rest[start:])
+		parts = append(parts, rest[start+size:])
+		rest = strings.TrimRightFunc(rest[:start], isSpace)
+		maxsplit--
+	}
+
+	slices.Reverse(parts)
+	return parts
+}
+
+func rsplitSeparator(value, sep string, maxsplit int64) []string {
+	parts := []string{}
+
+	for maxsplit != 0 {
+		idx := strings.LastIndex(value, sep)
+		if idx < 0 {
+			break
+		}
+		parts = append(parts, value[idx+len(sep):])
+		value = value[:idx]
+		maxsplit--
+	}
+	parts = append(parts, value)
+
+	slices.Reverse(parts)
+	return parts
+}
+
+// splitlines splits on the line boundaries Python recognises, treating "\r\n" as one
+func stringSplitlines(obj object.Object, kwargs map[string]object.Object, args ...object.Object) object.Object {
+	bound, err := bindArgs("splitlines", []string{"keepends"}, args, kwargs)
+	if err != nil {
+		return err
+	}
+	keepends := bound[0] != nil && isTruthy(bound[0])
+
+	value := obj.(*object.String).Value
+	parts := []string{}
+	start := 0
+
+	for i := 0; i < len(value); {
+		r, size := utf8.DecodeRuneInString(value[i:])
+		if !isLineBoundary(r) {
+			i += size
+			continue
+		}
+
+		end := i + size
+		if r == '\r' && end < len(value) && value[end] == '\n' {
+			end++
+		}
+		if keepends {
+			parts = append(parts, value[start:end])
+		} else {
+			parts = append(parts, value[start:i])
+		}
+		start, i = end, end
+	}
+	if start < len(value) {
+		parts = append(parts, value[start:])
+	}
+
+	return stringList(parts)
+}
+
+func isLineBoundary(r rune) bool {
+	switch r {
+	case '\n', '\r', '\v', '\f', 0x1c, 0x1d, 0x1e, 0x85, 0x2028, 0x2029:
+		return true
+	}
+	return false
+}
+
+func stringPartition(obj object.Object, args ...object.Object) object.Object {
+	return partitionString("partition", obj, args, false)
+}
+
+func stringRpartition(obj object.Object, args ...object.Object) object.Object {
+	return partitionString("rpartition", obj, args, true)
+}
+
+func partitionString(name string, obj object.Object, args []object.Object, reverse bool) object.Object {
+	if err := checkArgs(name, args, 1, 1); err != nil {
+		return err
+	}
+	sep, err := stringArg(name, args[0], "")
+	if err != nil {
+		return err
+	}
+	if args[0] == NULL || sep == "" {
+		return newError("empty separator")
+	}
+
+	value := obj.(*object.String).Value
+	var idx int
+	if reverse {
+		idx = strings.LastIndex(value, sep)
+	} else {
+		idx = strings.Index(value, sep)
+	}
+
+	if idx < 0 {
+		if reverse {
+			return stringTuple("", "", value)
+		}
+		return stringTuple(value, "", "")
+	}
+	return stringTuple(value[:idx], sep, value[idx+len(sep):])
+}
+
+func stringList(parts []string) *object.List {
+	elements := make([]object.Object, len(parts))
+	for i, part := range parts {
+		elements[i] = &object.String{Value: part}
+	}
+	return &object.List{Elements: elements}
+}
+
+func stringTuple(parts ...string) *object.Tuple {
+	return &object.Tuple{Elements: stringList(parts).Elements}
+}
+
+/*
+Stripping and Replacing
+*/
+func stripMethod(name string, left, right bool) *object.BuiltinMethod {
+	return &object.BuiltinMethod{
+		Fn: func(obj object.Object, args ...object.Object) object.Object {
+			if err := checkArgs(name, args, 0, 1); err != nil {
+				return err
+			}
+
+			value := obj.(*object.String).Value
+			trim := isSpace
+			if len(args) == 1 && args[0] != NULL {
+				chars, err := stringArg(name, args[0], "")
+				if err != nil {
+					return err
+				}
+				trim = func(r rune) bool { return strings.ContainsRune(chars, r) }
+			}
+
+			if left {
+				value = strings.TrimLeftFunc(value, trim)
+			}
+			if right {
+				value = strings.TrimRightFunc(value, trim)
+			}
+			return &object.String{Value: value}
+		},
+	}
+}
+
+func stringReplace(obj object.Object, args ...object.Object) object.Object {
+	if err := checkArgs("replace", args, 2, 3); err != nil {
+		return err
+	}
+
+	old, ok := args[0].(*object.String)
+	if !ok {
+		return newError("replace() argument 1 must be str, not %s", typeName(args[0]))
+	}
+	replacement, ok := args[1].(*object.String)
+	if !ok {
+		return newError("replace() argument 2 must be str, not %s", typeName(args[1]))
+	}
+	count := int64(-1)
+	if len(args) == 3 {
+		var err *object.Error
+		if count, err = intArg("replace", args[2], -1); err != nil {
+			return err
+		}
+	}
+
+	value := obj.(*object.String).Value
+	return &object.String{Value: strings.Replace(value, old.Value, replacement.Value, int(count))}
+}
+
+/*
+Searching
+*/
+func findMethod(name string, reverse, raise bool) *object.BuiltinMethod {
+	return &object.BuiltinMethod{
+		Fn: func(obj object.Object, args ...object.Object) object.Object {
+			if err := checkArgs(name, args, 1, 3); err != nil {
+				return err
+			}
+			sub, ok := args[0].(*object.String)
+			if !ok {
+				return newError("must be str, not %s", typeName(args[0]))
+			}
+
+			value, offset, inRange, err := substring(obj.(*object.String).Value, args[1:])
+			if err != nil {
+				return err
+			}
+
+			idx := -1
+			if inRange && reverse {
+				idx = strings.LastIndex(value, sub.Value)
+			} else if inRange {
+				idx = strings.Index(value, sub.Value)
+			}
+
+			if idx < 0 {
+				if raise {
+					return newError("substring not found")
+				}
+				return &object.Integer{Value: -1}
+			}
+			return &object.Integer{Value: offset + runeIndex(value, idx)}
+		},
+	}
+}
+
+func stringCount(obj object.Object, args ...object.Object) object.Object {
+	if err := checkArgs("count", args, 1, 3); err != nil {
+		return err
+	}
+	sub, ok := args[0].(*object.String)
+	if !ok {
+		return newError("must be str, not %s", typeName(args[0]))
+	}
+
+	value, _, inRange, err := substring(obj.(*object.String).Value, args[1:])
+	if err != nil {
+		return err
+	}
+	if !inRange {
+		return &object.Integer{Value: 0}
+	}
+
+	return &object.Integer{Value: int64(strings.Count(value, sub.Value))}
+}
+
+// affixMethod implements startswith and endswith, which accept a single string or
+// a tuple of strings to try in turn
+func affixMethod(name string, match func(s, affix string) bool) *object.BuiltinMethod {
+	return &object.BuiltinMethod{
+		Fn: func(obj object.Object, args ...object.Object) object.Object {
+			if err := checkArgs(name, args, 1, 3); err != nil {
+				return err
+			}
+
+			var affixes []object.Object
+			switch arg := args[0].(type) {
+			case *object.String:
+				affixes = []object.Object{arg}
+			case *object.Tuple:
+				affixes = arg.Elements
+			default:
+				return newError("%s first arg must be str or a tuple of str, not %s",
+					name, typeName(arg))
+			}
+
+			value, _, inRange, err := substring(obj.(*object.String).Value, args[1:])
+			if err != nil {
+				return err
+			}
+
+			for _, affix := range affixes {
+				str, ok := affix.(*object.String)
+				if !ok {
+					return newError("tuple for %s must only contain str, not %s",
+						name, typeName(affix))
+				}
+				if inRange && match(value, str.Value) {
+					return TRUE
+				}
+			}
+
+			return FALSE
+		},
+	}
+}
+
+/*
+Padding
+*/
+func justifyMethod(name string, align byte) *object.BuiltinMethod {
+	return &object.BuiltinMethod{
+		Fn: func(obj object.Object, args ...object.Object) object.Object {
+			if err := checkArgs(name, args, 1, 2); err != nil {
+				return err
+			}
+			width, err := intArg(name, args[0], 0)
+			if err != nil {
+				return err
+			}
+
+			fill := " "
+			if len(args) == 2 {
+				str, ok := args[1].(*object.String)
+				if !ok || utf8.RuneCountInString(str.Value) != 1 {
+					return newError("The fill character must be exactly one character long")
+				}
+				fill = str.Value
+			}
+
+			value := obj.(*object.String).Value
+			margin := width - int64(utf8.RuneCountInString(value))
+			if margin <= 0 {
+				return &object.String{Value: value}
+			}
+
+			var left int64
+			switch align {
+			case '>':
+				left = margin
+			case '^':
+				// CPython puts the odd space on the left only when the width is odd
+				left = margin/2 + (margin & width & 1)
+			}
+
+			padded := strings.Repeat(fill, int(left)) + value + strings.Repeat(fill, int(margin-left))
+			return &object.String{Value: padded}
+		},
+	}
+}
+
+func stringZfill(obj object.Object, args ...object.Object) object.Object {
+	if err := checkArgs("zfill", args, 1, 1); err != nil {
+		return err
+	}
+	width, err := intArg("zfill", args[0], 0)
+	if err != nil {
+		return err
+	}
+
+	value := obj.(*object.String).Value
+	margin := width - int64(utf8.RuneCountInString(value))
+	if margin <= 0 {
+		return &object.String{Value: value}
+	}
+
+	sign := ""
+	if strings.HasPrefix(value, "+") || strings.HasPrefix(value, "-") {
+		sign, value = value[:1], value[1:]
+	}
+	return &object.String{Value: sign + strings.Repeat("0", int(margin)) + value}
+}
+
+/*
+Case
+*/
+func isCased(r rune) bool {
+	return unicode.IsUpper(r) || unicode.IsLower(r) || unicode.IsTitle(r)
+}
+
+// title capitalises the first cased character after every uncased one, so that
+// "they're" becomes "They'Re" exactly as in CPython
+func stringTitle(obj object.Object, args ...object.Object) object.Object {
+	if err := checkArgs("title", args, 0, 0); err != nil {
+		return err
+	}
+
+	var out strings.Builder
+	previousCased := false
+	for _, r := range obj.(*object.String).Value {
+		if previousCased {
+			out.WriteRune(unicode.ToLower(r))
+		} else {
+			out.WriteRune(unicode.ToTitle(r))
+		}
+		previousCased = isCased(r)
+	}
+
+	return &object.String{Value: out.String()}
+}
+
+func stringCapitalize(obj object.Object, args ...object.Object) object.Object {
+	if err := checkArgs("capitalize", args, 0, 0); err != nil {
+		return err
+	}
+
+	value := obj.(*object.String).Value
+	first, size := utf8.DecodeRuneInString(value)
+	if size == 0 {
+		return &object.String{Value: value}
+	}
+
+	return &object.String{Value: string(unicode.ToTitle(first)) + strings.ToLower(value[size:])}
+}
+
+func stringIstitle(obj object.Object, args ...object.Object) object.Object {
+	if err := checkArgs("istitle", args, 0, 0); err != nil {
+		return err
+	}
+
+	cased, previousCased := false, false
+	for _, r := range obj.(*object.String).Value {
+		switch {
+		case unicode.IsUpper(r) || unicode.IsTitle(r):
+			if previousCased {
+				return FALSE
+			}
+			previousCased, cased = true, true
+		case unicode.IsLower(r):
+			if !previousCased {
+				return FALSE
+			}
+			previousCased, cased = true, true
+		default:
+			previousCased = false
+		}
+	}
+
+	return nativeBoolToBooleanObject(cased)
+}
+
+/*
+Character Classes
+*/
+
+// predicateMethod builds methods such as isdigit that hold when the string is
+// non-empty and every character satisfies fn
+func predicateMethod(name string, fn func(rune) bool) *object.BuiltinMethod {
+	return &object.BuiltinMethod{
+		Fn: func(obj object.Object, args ...object.Object) object.Object {
+			if err := checkArgs(name, args, 0, 0); err != nil {
+				return err
+			}
+
+			value := obj.(*object.String).Value
+			if value == "" {
+				return FALSE
+			}
+			for _, r := range value {
+				if !fn(r) {
+					return FALSE
+				}
+			}
+			return TRUE
+		},
+	}
+}
+
+// isascii is true for the empty string, unlike the other character class tests
+func stringIsascii(obj object.Object, args ...object.Object) object.Object {
+	if err := checkArgs("isascii", args, 0, 0); err != nil {
+		return err
+	}
+
+	for _, r := range obj.(*object.String).Value {
+		if r >= utf8.RuneSelf {
+			return FALSE
+		}
+	}
+	return TRUE
+}
+
+func isDecimal(r rune) bool {
+	return unicode.Is(unicode.Nd, r)
+}
+
+// isDigit also accepts the superscript, subscript and circled digits that Python
+// classes as digits but not as decimals
+func isDigit(r rune) bool {
+	switch {
+	case isDecimal(r),
+		r == 0xb2, r == 0xb3, r == 0xb9,
+		r == 0x2070, r >= 0x2074 && r <= 0x2079,
+		r >= 0x2080 && r <= 0x2089,
+		r >= 0x2460 && r <= 0x2468:
+		return true
+	}
+	return false
+}
+
+func isAlnum(r rune) bool {
+	return unicode.IsLetter(r) || unicode.IsNumber(r)
+}
+
+/*
+Encoding
+*/
+func stringEncode(obj object.Object, kwargs map[string]object.Object, args ...object.Object) object.Object {
+	bound, err := bindArgs("encode", []string{"encoding", "errors"}, args, kwargs)
+	if err != nil {
+		return err
+	}
+	encoding, err := stringArg("encode", bound[0], "utf-8")
+	if err != nil {
+		return err
+	}
+	errors, err := stringArg("encode", bound[1], "strict")
+	if err != nil {
+		return err
+	}
+
+	var limit rune
+	switch strings.ReplaceAll(strings.ToLower(encoding), "_", "-") {
+	case "utf-8", "utf8":
+		return &object.Bytes{Value: []byte(obj.(*object.String).Value)}
+	case "ascii", "us-ascii":
+		encoding, limit = "ascii", 0x80
+	case "latin-1", "latin1", "iso-8859-1":
+		encoding, limit = "latin-1", 0x100
+	default:
+		return newError("unknown encoding: %s", encoding)
+	}
+
+	out := []byte{}
+	for i, r := range []rune(obj.(*object.String).Value) {
+		if r < limit {
+			out = append(out, byte(r))
+			continue
+		}
+
+		switch errors {
+		case "strict":
+			return newError("'%s' codec can't encode character '%s' in position %d: ordinal not in range(%d)",
+				encoding, escapeCodePoint(r), i, limit)
+		case "ignore":
+		case "replace":
+			out = append(out, '?')
+		default:
+			return newError("unknown error handler name '%s'", errors)
+		}
+	}
+
+	return &object.Bytes{Value: out}
+}
+
+func escapeCodePoint(r rune) string {
+	switch {
+	case r < 0x100:
+		return fmt.Sprintf("\\x%02x", r)
+	case r < 0x10000:
+		return fmt.Sprintf("\\u%04x", r)
+	default:
+		return fmt.Sprintf("\\U%08x", r)
+	}
+}
+
+/*
+Operators
+*/
+
+// repeatString implements str * int and int * str. Counts below one give ""
+func repeatString(str *object.String, count *object.Integer) object.Object {
+	if count.Value <= 0 {
+		return &object.String{Value: ""}
+	}
+	return &object.String{Value: strings.Repeat(str.Value, int(count.Value))}
+}
@@ -0,0 +1,931 @@
+package evaluator
+
+import (
+	"fmt"
+	"simpyl/native"
+	"simpyl/object"
+	"sort"
+	"time"
+)
+
+// switchInterval is the number of steps a thread runs before it lets another
+// thread take the GIL
+const switchInterval = 100
+
+// runtimeModules build the modules whose members are bound to the runtime that
+// imports them, such as threading, whose threads share the runtime's GIL
+var runtimeModules map[string]func(runtime *object.Runtime) native.Members
+
+func init() {
+	runtimeModules = map[string]func(runtime *object.Runtime) native.Members{
+		"threading": threadingModule,
+		"queue":     queueModule,
+	}
+}
+
+// WaitThreads waits for every thread the scripts of runtime started, and then
+// releases the GIL. Whoever runs a script calls it once the script is done
+func WaitThreads(runtime *object.Runtime) {
+	threads := &runtime.Threads
+	if !threads.Active {
+		return
+	}
+
+	threads.GIL.Unlock()
+	threads.Running.Wait()
+	threads.Active = false
+	threads.Current = 0
+}
+
+// switchThreads gives the other threads a chance to take the GIL. Go's mutex
+// hands itself to a goroutine that has waited for over a millisecond
+func switchThreads(runtime *object.Runtime) {
+	threads := &runtime.Threads
+	current := threads.Current
+	threads.GIL.Unlock()
+	threads.GIL.Lock()
+	threads.Current = current
+}
+
+// block runs wait without holding the GIL, so that other threads run while the
+// current one is blocked. wait selects on the channel it is waiting for, timer
+// and done, and reports whether its channel was ready. A negative timeout waits
+// forever, and an error is returned when the script's context is done
+func block(runtime *object.Runtime, timeout time.Duration, wait func(timer <-chan time.Time, done <-chan struct{}) bool) (bool, *object.Error) {
+	var timer <-chan time.Time
+	if timeout >= 0 {
+		t := time.NewTimer(timeout)
+		defer t.Stop()
+		timer = t.C
+	}
+
+	var done <-chan struct{}
+	if runtime.Context != nil {
+		done = runtime.Context.Done()
+	}
+
+	var ready bool
+	threads := &runtime.Threads
+	if threads.Active {
+		current := threads.Current
+		threads.GIL.Unlock()
+		ready = wait(timer, done)
+		threads.GIL.Lock()
+		threads.Current = current
+	} else {
+		ready = wait(timer, done)
+	}
+
+	if !ready && runtime.Context != nil && runtime.Context.Err() != nil {
+		return false, newError("interrupted: %s", runtime.Context.Err())
+	}
+	return ready, nil
+}
+
+// waitFor blocks until ch is closed
+func waitFor(runtime *object.Runtime, ch <-chan struct{}, timeout time.Duration) (bool, *object.Error) {
+	return block(runtime, timeout, func(timer <-chan time.Time, done <-chan struct{}) bool {
+		select {
+		case <-ch:
+			return true
+		case <-timer:
+		case <-done:
+		}
+		return false
+	})
+}
+
+// timeoutArg converts a timeout in seconds. Null, or a negative number, means
+// no timeout
+func timeoutArg(name string, arg object.Object) (time.Duration, *object.Error) {
+	switch arg := arg.(type) {
+	case nil, *object.Null:
+		return -1, nil
+	case *object.Integer:
+		return time.Duration(arg.Value) * time.Second, nil
+	case *object.Float:
+		return time.Duration(arg.Value * float64(time.Second)), nil
+	}
+	return 0, newError("%s() timeout must be a number, not %s", name, typeName(arg))
+}
+
+// boolArg reads an optional bool argument
+func boolArg(name string, arg object.Object, def bool) (bool, *object.Error) {
+	switch arg := arg.(type) {
+	case nil:
+		return def, nil
+	case *object.Boolean:
+		return arg.Value, nil
+	}
+	return false, newError("%s() argument must be bool, not %s", name, typeName(arg))
+}
+
+/*
+Host Objects
+*/
+
+// hostObject implements object.HostObject for the objects of the threading and
+// queue modules, which have methods and read-only attributes
+type hostObject struct {
+	inspect func() string
+	attrs   func() map[string]object.Object
+}
+
+func (h *hostObject) Type() object.ObjectType { return object.HOST_OBJ }
+func (h *hostObject) Inspect() string         { return h.inspect() }
+
+func (h *hostObject) GetAttr(name string) object.Object {
+	if val, ok := h.attrs()[name]; ok {
+		return val
+	}
+	return newError("%s has no attribute '%s'", h.Inspect(), name)
+}
+
+func (h *hostObject) SetAttr(name string, val object.Object) object.Object {
+	return newError("cannot set attribute '%s' on %s", name, h.Inspect())
+}
+
+func (h *hostObject) AttrNames() []string {
+	names := []string{}
+	for name := range h.attrs() {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+func method(fn object.BuiltinFunction) *object.Builtin {
+	return &object.Builtin{Fn: fn}
+}
+
+func keywordMethod(fn object.BuiltinKeywordFunction) *object.Builtin {
+	return &object.Builtin{KwFn: fn}
+}
+
+/*
+Threading
+*/
+
+func threadingModule(runtime *object.Runtime) native.Members {
+	return native.Members{
+		"Thread": keywordMethod(func(kwargs map[string]object.Object, args ...object.Object) object.Object {
+			return newThread(runtime, kwargs, args)
+		}),
+		"Lock": method(func(args ...object.Object) object.Object {
+			if err := checkArgs("Lock", args, 0, 0); err != nil {
+				return err
+			}
+			return lockObject(runtime, newLock())
+		}),
+		"RLock": method(func(args ...object.Object) object.Object {
+			if err := checkArgs("RLock", args, 0, 0); err != nil {
+				return err
+			}
+			return lockObject(runtime, &rlock{lock: newLock()})
+		}),
+		"Event": method(func(args ...object.Object) object.Object {
+			if err := checkArgs("Event", args, 0, 0); err != nil {
+				return err
+			}
+			return eventObject(runtime, &event{ch: make(chan struct{})})
+		}),
+		"Condition": method(func(args ...object.Object) object.Object {
+			if err := checkArgs("Condition", args, 0, 1); err != nil {
+				return err
+			}
+			cond := &condition{lock: &rlock{lock: newLock()}}
+			if len(args) == 1 && args[0] != NULL {
+				handle, ok := args[0].(*lockHandle)
+				if !ok {
+					return newError("Condition() argument must be a Lock or RLock, not %s", typeName(args[0]))
+				}
+				cond.lock = handle.lock
+			}
+			return conditionObject(runtime, cond)
+		}),
+		"get_ident": method(func(args ...object.Object) object.Object {
+			if err := checkArgs("get_ident", args, 0, 0); err != nil {
+				return err
+			}
+			return &object.Integer{Value: runtime.Threads.Current}
+		}),
+	}
+}
+
+type thread struct {
+	name    string
+	ident   int64
+	target  object.Object
+	args    []object.Object
+	kwargs  map[string]object.Object
+	started bool
+	done    chan struct{}
+}
+
+func newThread(runtime *object.Runtime, kwargs map[string]object.Object, args []object.Object) object.Object {
+	bound, err := bindArgs("Thread", []string{"target", "args", "kwargs", "name"}, args, kwargs)
+	if err != nil {
+		return err
+	}
+
+	runtime.Threads.Started++
+	t := &thread{
+		name:   fmt.Sprintf("Thread-%d", runtime.Threads.Started),
+		ident:  runtime.Threads.Started,
+		target: bound[0],
+		kwargs: map[string]object.Object{},
+		done:   make(chan struct{}),
+	}
+
+	if bound[1] != nil {
+		elements, err := iterate(bound[1])
+		if err != nil {
+			return err
+		}
+		t.args = elements
+	}
+	if bound[2] != nil {
+		dict, ok := bound[2].(*object.Dict)
+		if !ok {
+			return newError("Thread() kwargs must be a dict, not %s", typeName(bound[2]))
+		}
+		for _, pair := range dict.Items() {
+			key, ok := pair.Key.(*object.String)
+			if !ok {
+				return newError("Thread() keywords must be strings")
+			}
+			t.kwargs[key.Value] = pair.Value
+		}
+	}
+	if bound[3] != nil {
+		name, err := objectString(bound[3])
+		if err != nil {
+			return err
+		}
+		t.name = name
+	}
+
+	return threadObject(runtime, t)
+}
+
+func threadObject(runtime *object.Runtime, t *thread) *hostObject {
+	obj := &hostObject{}
+	obj.inspect = func() string {
+		state := "initial"
+		switch {
+		case t.alive():
+			state = "started"
+		case t.started:
+			state = "stopped"
+		}
+		return fmt.Sprintf("<Thread(%s, %s)>", t.name, state)
+	}
+	obj.attrs = func() map[string]object.Object {
+		return map[string]object.Object{
+			"name":  &object.String{Value: t.name},
+			"ident": &object.Integer{Value: t.ident},
+			"start": method(func(args ...object.Object) object.Object {
+				if err := checkArgs("start", args, 0, 0); err != nil {
+					return err
+				}
+				return t.start(runtime)
+			}),
+			"join": method(func(args ...object.Object) object.Object {
+				if err := checkArgs("join", args, 0, 1); err != nil {
+					return err
+				}
+				return t.join(runtime, args)
+			}),
+			"is_alive": method(func(args ...object.Object) object.Object {
+				if err := checkArgs("is_alive", args, 0, 0); err != nil {
+					return err
+				}
+				return nativeBoolToBooleanObject(t.alive())
+			}),
+		}
+	}
+	return obj
+}
+
+// start runs the target of t on a new goroutine. The first thread a script
+// starts makes the GIL active, held by the thread that started it
+func (t *thread) start(runtime *object.Runtime) object.Object {
+	if t.started {
+		return newError("RuntimeError: threads can only be started once")
+	}
+	t.started = true
+
+	threads := &runtime.Threads
+	if !threads.Active {
+		threads.GIL.Lock()
+		threads.Active = true
+	}
+
+	threads.Running.Add(1)
+	go func() {
+		defer threads.Running.Done()
+
+		threads.GIL.Lock()
+		threads.Current = t.ident
+		if t.target != nil && t.target != NULL {
+			result := callFunction(runtime, t.target, t.args, t.kwargs)
+			if err, ok := result.(*object.Error); ok && runtime.Stderr != nil {
+				fmt.Fprintf(runtime.Stderr, "Exception in thread %s:\n%s\n", t.name, err.Message)
+			}
+		}
+		close(t.done)
+		threads.GIL.Unlock()
+	}()
+
+	return NULL
+}
+
+func (t *thread) join(runtime *object.Runtime, args []object.Object) object.Object {
+	if !t.started {
+		return newError("RuntimeError: cannot join thread before it is started")
+	}
+	if t.ident == runtime.Threads.Current {
+		return newError("RuntimeError: cannot join current thread")
+	}
+
+	var timeout object.Object
+	if len(args) == 1 {
+		timeout = args[0]
+	}
+	duration, err := timeoutArg("join", timeout)
+	if err != nil {
+		return err
+	}
+
+	if _, err := waitFor(runtime, t.done, duration); err != nil {
+		return err
+	}
+	return NULL
+}
+
+func (t *thread) alive() bool {
+	if !t.started {
+		return false
+	}
+	select {
+	case <-t.done:
+		return false
+	default:
+		return true
+	}
+}
+
+/*
+Locks
+*/
+
+// syncLock is implemented by Lock and RLock, which a Condition can wait on
+type syncLock interface {
+	acquire(runtime *object.Runtime, blocking bool, timeout time.Duration) (bool, *object.Error)
+	release(runtime *object.Runtime) *object.Error
+	locked() bool
+
+	// owned reports whether the current thread may release the lock. Any thread
+	// may release a Lock
+	owned(runtime *object.Runtime) bool
+
+	// releaseAll releases the lock however many times the current thread holds
+	// it, and returns that count for restore
+	releaseAll(runtime *object.Runtime) int
+	restore(runtime *object.Runtime, count int) *object.Error
+}
+
+// lockHandle is the object scripts hold for a Lock or RLock
+type lockHandle struct {
+	*hostObject
+	lock syncLock
+}
+
+// lock is a Lock: a buffered channel holds a value while the lock is held
+type lock struct {
+	ch chan struct{}
+}
+
+func newLock() *lock {
+	return &lock{ch: make(chan struct{}, 1)}
+}
+
+func (l *lock) acquire(runtime *object.Runtime, blocking bool, timeout time.Duration) (bool, *object.Error) {
+	select {
+	case l.ch <- struct{}{}:
+		return true, nil
+	default:
+	}
+	if !blocking {
+		return false, nil
+	}
+
+	return block(runtime, timeout, func(timer <-chan time.Time, done <-chan struct{}) bool {
+		select {
+		case l.ch <- struct{}{}:
+			return true
+		case <-timer:
+		case <-done:
+		}
+		return false
+	})
+}
+
+func (l *lock) release(runtime *object.Runtime) *object.Error {
+	select {
+	case <-l.ch:
+		return nil
+	default:
+		return newError("RuntimeError: release unlocked lock")
+	}
+}
+
+func (l *lock) locked() bool {
+	return len(l.ch) == 1
+}
+
+func (l *lock) owned(runtime *object.Runtime) bool {
+	return l.locked()
+}
+
+func (l *lock) releaseAll(runtime *object.Runtime) int {
+	l.release(runtime)
+	return 1
+}
+
+func (l *lock) restore(runtime *object.Runtime, count int) *object.Error {
+	_, err := l.acquire(runtime, true, -1)
+	return err
+}
+
+// rlock is an RLock, which the thread holding it can acquire again
+type rlock struct {
+	lock  *lock
+	owner int64
+	count int
+}
+
+func (r *rlock) acquire(runtime *object.Runtime, blocking bool, timeout time.Duration) (bool, *object.Error) {
+	if r.owned(runtime) {
+		r.count++
+		return true, nil
+	}
+
+	ok, err := r.lock.acquire(runtime, blocking, timeout)
+	if ok {
+		r.owner, r.count = runtime.Threads.Current, 1
+	}
+	return ok, err
+}
+
+func (r *rlock) release(runtime *object.Runtime) *object.Error {
+	if !r.owned(runtime) {
+		return newError("RuntimeError: cannot release un-acquired lock")
+	}
+
+	r.count--
+	if r.count == 0 {
+		return r.lock.release(runtime)
+	}
+	return nil
+}
+
+func (r *rlock) locked() bool {
+	return r.count > 0
+}
+
+func (r *rlock) owned(runtime *object.Runtime) bool {
+	return r.count > 0 && r.owner == runtime.Threads.Current
+}
+
+func (r *rlock) releaseAll(runtime *object.Runtime) int {
+	count := r.count
+	r.count = 0
+	r.lock.release(runtime)
+	return count
+}
+
+func (r *rlock) restore(runtime *object.Runtime, count int) *object.Error {
+	if _, err := r.lock.acquire(runtime, true, -1); err != nil {
+		return err
+	}
+	r.owner, r.count = runtime.Threads.Current, count
+	return nil
+}
+
+func lockObject(runtime *object.Runtime, l syncLock) *lockHandle {
+	name := "Lock"
+	if _, ok := l.(*rlock); ok {
+		name = "RLock"
+	}
+
+	obj := &hostObject{}
+	obj.inspect = func() string {
+		if l.locked() {
+			return fmt.Sprintf("<locked %s>", name)
+		}
+		return fmt.Sprintf("<unlocked %s>", name)
+	}
+	obj.attrs = func() map[string]object.Object {
+		return map[string]object.Object{
+			"acquire": keywordMethod(func(kwargs map[string]object.Object, args ...object.Object) object.Object {
+				bound, err := bindArgs("acquire", []string{"blocking", "timeout"}, args, kwargs)
+				if err != nil {
+					return err
+				}
+				blocking, err := boolArg("acquire", bound[0], true)
+				if err != nil {
+					return err
+				}
+				timeout, err := timeoutArg("acquire", bound[1])
+				if err != nil {
+					return err
+				}
+
+				ok, err := l.acquire(runtime, blocking, timeout)
+				if err != nil {
+					return err
+				}
+				return nativeBoolToBooleanObject(ok)
+			}),
+			"release": method(func(args ...object.Object) object.Object {
+				if err := checkArgs("release", args, 0, 0); err != nil {
+					return err
+				}
+				if err := l.release(runtime); err != nil {
+					return err
+				}
+				return NULL
+			}),
+			"locked": method(func(args ...object.Object) object.Object {
+				if err := checkArgs("locked", args, 0, 0); err != nil {
+					return err
+				}
+				return nativeBoolToBooleanObject(l.locked())
+			}),
+		}
+	}
+
+	return &lockHandle{hostObject: obj, lock: l}
+}
+
+/*
+Events and Conditions
+*/
+
+// event is an Event. Its channel is closed while the flag is set
+type event struct {
+	set bool
+	ch  chan struct{}
+}
+
+func eventObject(runtime *object.Runtime, e *event) *hostObject {
+	obj := &hostObject{}
+	obj.inspect = func() string {
+		if e.set {
+			return "<Event set>"
+		}
+		return "<Event unset>"
+	}
+	obj.attrs = func() map[string]object.Object {
+		return map[string]object.Object{
+			"set": method(func(args ...object.Object) object.Object {
+				if err := checkArgs("set", args, 0, 0); err != nil {
+					return err
+				}
+				if !e.set {
+					e.set = true
+					close(e.ch)
+				}
+				return NULL
+			}),
+			"clear": method(func(args ...object.Object) object.Object {
+				if err := checkArgs("clear", args, 0, 0); err != nil {
+					return err
+				}
+				if e.set {
+					e.set = false
+					e.ch = make(chan struct{})
+				}
+				return NULL
+			}),
+			"is_set": method(func(args ...object.Object) object.Object {
+				if err := checkArgs("is_set", args, 0, 0); err != nil {
+					return err
+				}
+				return nativeBoolToBooleanObject(e.set)
+			}),
+			"wait": method(func(args ...object.Object) object.Object {
+				if err := checkArgs("wait", args, 0, 1); err != nil {
+					return err
+				}
+				var timeout object.Object
+				if len(args) == 1 {
+					timeout = args[0]
+				}
+				duration, err := timeoutArg("wait", timeout)
+				if err != nil {
+					return err
+				}
+
+				ok, err := waitFor(runtime, e.ch, duration)
+				if err != nil {
+					return err
+				}
+				return nativeBoolToBooleanObject(ok)
+			}),
+		}
+	}
+	return obj
+}
+
+// condition is a Condition. Each waiting thread blocks on its own channel,
+// which notify closes
+type condition struct {
+	lock    syncLock
+	waiters []chan struct{}
+}
+
+func (c *condition) wait(runtime *object.Runtime, timeout time.Duration) (bool, *object.Error) {
+	if !c.lock.owned(runtime) {
+		return false, newError("RuntimeError: cannot wait on un-acquired lock")
+	}
+
+	ch := make(chan struct{})
+	c.waiters = append(c.waiters, ch)
+	count := c.lock.releaseAll(runtime)
+
+	notified, err := waitFor(runtime, ch, timeout)
+	if !notified {
+		for i, waiter := range c.waiters {
+			if waiter == ch {
+				c.waiters = append(c.waiters[:i], c.waiters[i+1:]...)
+				break
+			}
+		}
+	}
+
+	if restoreErr := c.lock.restore(runtime, count); restoreErr != nil {
+		return false, restoreErr
+	}
+	return notified, err
+}
+
+func (c *condition) notify(runtime *object.Runtime, n int) *object.Error {
+	if !c.lock.owned(runtime) {
+		return newError("RuntimeError: cannot notify on un-acquired lock")
+	}
+
+	for n > 0 && len(c.waiters) > 0 {
+		close(c.waiters[0])
+		c.waiters = c.waiters[1:]
+		n--
+	}
+	return nil
+}
+
+func conditionObject(runtime *object.Runtime, c *condition) *hostObject {
+	lock := lockObject(runtime, c.lock)
+
+	obj := &hostObject{}
+	obj.inspect = func() string {
+		return fmt.Sprintf("<Condition(%s, %d)>", lock.Inspect(), len(c.waiters))
+	}
+	obj.attrs = func() map[string]object.Object {
+		attrs := lock.attrs()
+		delete(attrs, "locked")
+
+		attrs["wait"] = method(func(args ...object.Object) object.Object {
+			if err := checkArgs("wait", args, 0, 1); err != nil {
+				return err
+			}
+			var timeout object.Object
+			if len(args) == 1 {
+				timeout = args[0]
+			}
+			duration, err := timeoutArg("wait", timeout)
+			if err != nil {
+				return err
+			}
+
+			notified, err := c.wait(runtime, duration)
+			if err != nil {
+				return err
+			}
+			return nativeBoolToBooleanObject(notified)
+		})
+		attrs["notify"] = method(func(args ...object.Object) object.Object {
+			if err := checkArgs("notify", args, 0, 1); err != nil {
+				return err
+			}
+			n := int64(1)
+			if len(args) == 1 {
+				var err *object.Error
+				if n, err = intArg("notify", args[0], 1); err != nil {
+					return err
+				}
+			}
+			if err := c.notify(runtime, int(n)); err != nil {
+				return err
+			}
+			return NULL
+		})
+		attrs["notify_all"] = method(func(args ...object.Object) object.Object {
+			if err := checkArgs("notify_all", args, 0, 0); err != nil {
+				return err
+			}
+			if err := c.notify(runtime, len(c.waiters)); err != nil {
+				return err
+			}
+			return NULL
+		})
+		return attrs
+	}
+	return obj
+}
+
+/*
+Queues
+*/
+
+func queueModule(runtime *object.Runtime) native.Members {
+	return native.Members{
+		"Queue": keywordMethod(func(kwargs map[string]object.Object, args ...object.Object) object.Object {
+			bound, err := bindArgs("Queue", []string{"maxsize"}, args, kwargs)
+			if err != nil {
+				return err
+			}
+			maxsize, err := intArg("Queue", bound[0], 0)
+			if err != nil {
+				return err
+			}
+			return queueObject(runtime, &queue{maxsize: int(maxsize), changed: make(chan struct{})})
+		}),
+	}
+}
+
+// queue is a Queue. Every change closes and replaces changed, which wakes the
+// threads waiting for the queue to change
+type queue struct {
+	items      []object.Object
+	maxsize    int
+	unfinished int
+	changed    chan struct{}
+}
+
+func (q *queue) full() bool {
+	return q.maxsize > 0 && len(q.items) >= q.maxsize
+}
+
+func (q *queue) notify() {
+	close(q.changed)
+	q.changed = make(chan struct{})
+}
+
+// waitUntil blocks until ready reports true, or the timeout passes
+func (q *queue) waitUntil(runtime *object.Runtime, timeout time.Duration, ready func() bool) (bool, *object.Error) {
+	deadline := time.Now().Add(timeout)
+	for !ready() {
+		remaining := time.Duration(-1)
+		if timeout >= 0 {
+			if remaining = time.Until(deadline); remaining <= 0 {
+				return false, nil
+			}
+		}
+
+		if _, err := waitFor(runtime, q.changed, remaining); err != nil {
+			return false, err
+		}
+	}
+	return true, nil
+}
+
+// blockingTimeout reads the block and timeout arguments of put and get. Not
+// blocking is the same as a timeout of zero
+func blockingTimeout(name string, args []object.Object) (time.Duration, *object.Error) {
+	blocking, err := boolArg(name, args[0], true)
+	if err != nil {
+		return 0, err
+	}
+	if !blocking {
+		return 0, nil
+	}
+	return timeoutArg(name, args[1])
+}
+
+func (q *queue) put(runtime *object.Runtime, item object.Object, timeout time.Duration) object.Object {
+	ok, err := q.waitUntil(runtime, timeout, func() bool { return !q.full() })
+	if err != nil {
+		return err
+	}
+	if !ok {
+		return newError("queue.Full: put to a full queue")
+	}
+
+	q.items = append(q.items, item)
+	q.unfinished++
+	q.notify()
+	return NULL
+}
+
+func (q *queue) get(runtime *object.Runtime, timeout time.Duration) object.Object {
+	ok, err := q.waitUntil(runtime, timeout, func() bool { return len(q.items) > 0 })
+	if err != nil {
+		return err
+	}
+	if !ok {
+		return newError("queue.Empty: get from an empty queue")
+	}
+
+	item := q.items[0]
+	q.items = q.items[1:]
+	q.notify()
+	return item
+}
+
+func queueObject(runtime *object.Runtime, q *queue) *hostObject {
+	obj := &hostObject{}
+	obj.inspect = func() string {
+		return fmt.Sprintf("<Queue of %d items>", len(q.items))
+	}
+	obj.attrs = func() map[string]object.Object {
+		return map[string]object.Object{
+			"put": keywordMethod(func(kwargs map[string]object.Object, args ...object.Object) object.Object {
+				bound, err := bindArgs("put", []string{"item", "block", "timeout"}, args, kwargs)
+				if err != nil {
+					return err
+				}
+				if bound[0] == nil {
+					return newError("put() missing required argument: 'item'")
+				}
+				timeout, err := blockingTimeout("put", bound[1:])
+				if err != nil {
+					return err
+				}
+				return q.put(runtime, bound[0], timeout)
+			}),
+			"get": keywordMethod(func(kwargs map[string]object.Object, args ...object.Object) object.Object {
+				bound, err := bindArgs("get", []string{"block", "timeout"}, args, kwargs)
+				if err != nil {
+					return err
+				}
+				timeout, err := blockingTimeout("get", bound)
+				if err != nil {
+					return err
+				}
+				return q.get(runtime, timeout)
+			}),
+			"put_nowait": method(func(args ...object.Object) object.Object {
+				if err := checkArgs("put_nowait", args, 1, 1); err != nil {
+					return err
+				}
+				return q.put(runtime, args[0], 0)
+			}),
+			"get_nowait": method(func(args ...object.Object) object.Object {
+				if err := checkArgs("get_nowait", args, 0, 0); err != nil {
+					return err
+				}
+				return q.get(runtime, 0)
+			}),
+			"qsize": method(func(args ...object.Object) object.Object {
+				if err := checkArgs("qsize", args, 0, 0); err != nil {
+					return err
+				}
+				return &object.Integer{Value: int64(len(q.items))}
+			}),
+			"empty": method(func(args ...object.Object) object.Object {
+				if err := checkArgs("empty", args, 0, 0); err != nil {
+					return err
+				}
+				return nativeBoolToBooleanObject(len(q.items) == 0)
+			}),
+			"full": method(func(args ...object.Object) object.Object {
+				if err := checkArgs("full", args, 0, 0); err != nil {
+					return err
+				}
+				return nativeBoolToBooleanObject(q.full())
+			}),
+			"task_done": method(func(args ...object.Object) object.Object {
+				if err := checkArgs("task_done", args, 0, 0); err != nil {
+					return err
+				}
+				if q.unfinished == 0 {
+					return newError("ValueError: task_done() called too many times")
+				}
+				q.unfinished--
+				q.notify()
+				return NULL
+			}),
+			"join": method(func(args ...object.Object) object.Object {
+				if err := checkArgs("join", args, 0, 0); err != nil {
+					return err
+				}
+				if _, err := q.waitUntil(runtime, -1, func() bool { return q.unfinished == 0 }); err != nil {
+					return err
+				}
+				return NULL
+			}),
+		}
+	}
+	return obj
+}
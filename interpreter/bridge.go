@@ -0,0 +1,345 @@
+package interpreter
+
+import (
+	"fmt"
+	"reflect"
+	"simpyl/evaluator"
+	"simpyl/object"
+	"sort"
+)
+
+var (
+	errorType  = reflect.TypeOf((*error)(nil)).Elem()
+	objectType = reflect.TypeOf((*object.Object)(nil)).Elem()
+)
+
+/*
+Structs
+*/
+
+// goStruct is a Go struct exposed to scripts. Its exported fields are attributes
+// that scripts can read and set, and its exported methods are bound methods.
+// Slice and map fields read as read-only copies, see convert
+type goStruct struct {
+	ptr reflect.Value // A pointer to the struct, so that fields can be set
+}
+
+// wrapStruct wraps the struct v. A struct that is not addressable, such as one
+// passed by value, is copied first
+func wrapStruct(v reflect.Value) *goStruct {
+	if !v.CanAddr() {
+		copied := reflect.New(v.Type())
+		copied.Elem().Set(v)
+		return &goStruct{ptr: copied}
+	}
+	return &goStruct{ptr: v.Addr()}
+}
+
+func (s *goStruct) Type() object.ObjectType { return object.HOST_OBJ }
+func (s *goStruct) Inspect() string         { return fmt.Sprintf("<Go %s>", s.ptr.Type().Elem()) }
+
+func (s *goStruct) GetAttr(name string) object.Object {
+	if field, ok := s.field(name); ok {
+		val, err := convert(field, true)
+		if err != nil {
+			return &object.Error{Message: fmt.Sprintf("attribute '%s': %s", name, err)}
+		}
+		return val
+	}
+
+	if method := s.ptr.MethodByName(name); method.IsValid() {
+		return wrapFunc(method)
+	}
+
+	return &object.Error{Message: fmt.Sprintf("%s has no attribute '%s'", s.Inspect(), name)}
+}
+
+func (s *goStruct) SetAttr(name string, val object.Object) object.Object {
+	field, ok := s.field(name)
+	if !ok {
+		return &object.Error{Message: fmt.Sprintf("%s has no attribute '%s'", s.Inspect(), name)}
+	}
+
+	v, err := toValue(val, field.Type())
+	if err != nil {
+		return &object.Error{Message: fmt.Sprintf("attribute '%s': %s", name, err)}
+	}
+	field.Set(v)
+	return evaluator.NULL
+}
+
+func (s *goStruct) AttrNames() []string {
+	names := []string{}
+	for _, field := range reflect.VisibleFields(s.ptr.Type().Elem()) {
+		if name, ok := fieldName(field); ok {
+			names = append(names, name)
+		}
+	}
+	for i := 0; i < s.ptr.NumMethod(); i++ {
+		names = append(names, s.ptr.Type().Method(i).Name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// field finds the exported field that scripts call name, including fields
+// promoted from embedded structs
+func (s *goStruct) field(name string) (reflect.Value, bool) {
+	for _, field := range reflect.VisibleFields(s.ptr.Type().Elem()) {
+		if attr, ok := fieldName(field); ok && attr == name {
+			v, err := s.ptr.Elem().FieldByIndexErr(field.Index)
+			return v, err == nil
+		}
+	}
+	return reflect.Value{}, false
+}
+
+// fieldName is the attribute name of an exported field, which its simpyl tag
+// can change or hide
+func fieldName(field reflect.StructField) (string, bool) {
+	if !field.IsExported() {
+		return "", false
+	}
+
+	switch tag := field.Tag.Get("simpyl"); tag {
+	case "-":
+		return "", false
+	case "":
+		return field.Name, true
+	default:
+		return tag, true
+	}
+}
+
+/*
+Functions
+*/
+
+// wrapFunc wraps the Go function fn as a builtin
+func wrapFunc(fn reflect.Value) *object.Builtin {
+	t := fn.Type()
+
+	return &object.Builtin{Fn: func(args ...object.Object) object.Object {
+		want := t.NumIn()
+		if t.IsVariadic() && len(args) < want-1 {
+			return &object.Error{Message: fmt.Sprintf("wrong number of arguments. got=%d, want at least %d", len(args), want-1)}
+		}
+		if !t.IsVariadic() && len(args) != want {
+			return &object.Error{Message: fmt.Sprintf("wrong number of arguments. got=%d, want=%d", len(args), want)}
+		}
+
+		in := make([]reflect.Value, len(args))
+		for i, arg := range args {
+			param := t.In(min(i, want-1))
+			if t.IsVariadic() && i >= want-1 {
+				param = param.Elem()
+			}
+
+			v, err := toValue(arg, param)
+			if err != nil {
+				return &object.Error{Message: fmt.Sprintf("argument %d: %s", i+1, err)}
+			}
+			in[i] = v
+		}
+
+		return call(fn, in)
+	}}
+}
+
+// call calls fn with in and converts its results. A panic in fn is raised in
+// the script instead of crashing the host
+func call(fn reflect.Value, in []reflect.Value) (result object.Object) {
+	defer func() {
+		if r := recover(); r != nil {
+			err, _ := r.(error)
+			result = &object.Error{Message: fmt.Sprintf("panic in Go function: %v", r), Err: err}
+		}
+	}()
+	return fromResults(fn.Call(in), fn.Type())
+}
+
+// fromResults converts what a Go function returned. A trailing error result is
+// raised when it is not nil and dropped otherwise
+func fromResults(out []reflect.Value, t reflect.Type) object.Object {
+	if len(out) > 0 && t.Out(len(out)-1) == errorType {
+		if err, _ := out[len(out)-1].Interface().(error); err != nil {
+			return &object.Error{Message: err.Error(), Err: err}
+		}
+		out = out[:len(out)-1]
+	}
+
+	elements := make([]object.Object, len(out))
+	for i, v := range out {
+		obj, err := toObject(v)
+		if err != nil {
+			return &object.Error{Message: fmt.Sprintf("result %d: %s", i+1, err)}
+		}
+		elements[i] = obj
+	}
+
+	switch len(elements) {
+	case 0:
+		return evaluator.NULL
+	case 1:
+		return elements[0]
+	}
+	return &object.Tuple{Elements: elements}
+}
+
+/*
+Arguments
+*/
+
+// toValue converts obj to a Go value of type t, the reverse of toObject
+func toValue(obj object.Object, t reflect.Type) (reflect.Value, error) {
+	if s, ok := obj.(*goStruct); ok {
+		switch {
+		case s.ptr.Type().AssignableTo(t):
+			return s.ptr, nil
+		case s.ptr.Type().Elem().AssignableTo(t):
+			return s.ptr.Elem(), nil
+		}
+	}
+
+	// Values of type any get the usual Go form of obj, while parameters that
+	// take objects get obj itself
+	if t.Kind() == reflect.Interface && t.NumMethod() == 0 {
+		value, err := FromObject(obj)
+		if err != nil {
+			return reflect.Value{}, err
+		}
+		if value == nil {
+			return reflect.Zero(t), nil
+		}
+		return reflect.ValueOf(value), nil
+	}
+	if t.Implements(objectType) {
+		if reflect.TypeOf(obj).AssignableTo(t) {
+			return reflect.ValueOf(obj), nil
+		}
+		return reflect.Value{}, mismatch(obj, t)
+	}
+
+	if obj == evaluator.NULL {
+		switch t.Kind() {
+		case reflect.Pointer, reflect.Slice, reflect.Map, reflect.Interface, reflect.Func:
+			return reflect.Zero(t), nil
+		}
+		return reflect.Value{}, mismatch(obj, t)
+	}
+
+	v := reflect.New(t).Elem()
+	switch t.Kind() {
+	case reflect.Bool:
+		b, ok := obj.(*object.Boolean)
+		if !ok {
+			return v, mismatch(obj, t)
+		}
+		v.SetBool(b.Value)
+
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		n, ok := obj.(*object.Integer)
+		if !ok {
+			return v, mismatch(obj, t)
+		}
+		if v.OverflowInt(n.Value) {
+			return v, fmt.Errorf("%d is out of range for %s", n.Value, t)
+		}
+		v.SetInt(n.Value)
+
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		n, ok := obj.(*object.Integer)
+		if !ok {
+			return v, mismatch(obj, t)
+		}
+		if n.Value < 0 || v.OverflowUint(uint64(n.Value)) {
+			return v, fmt.Errorf("%d is out of range for %s", n.Value, t)
+		}
+		v.SetUint(uint64(n.Value))
+
+	case reflect.Float32, reflect.Float64:
+		switch n := obj.(type) {
+		case *object.Integer:
+			v.SetFloat(float64(n.Value))
+		case *object.Float:
+			if v.OverflowFloat(n.Value) {
+				return v, fmt.Errorf("%g is out of range for %s", n.Value, t)
+			}
+			v.SetFloat(n.Value)
+		default:
+			return v, mismatch(obj, t)
+		}
+
+	case reflect.String:
+		str, ok := obj.(*object.String)
+		if !ok {
+			return v, mismatch(obj, t)
+		}
+		v.SetString(str.Value)
+
+	case reflect.Slice, reflect.Array:
+		if b, ok := obj.(*object.Bytes); ok && t.Elem().Kind() == reflect.Uint8 && t.Kind() == reflect.Slice {
+			v.Set(reflect.ValueOf(append([]byte{}, b.Value...)).Convert(t))
+			return v, nil
+		}
+
+		var elements []object.Object
+		switch seq := obj.(type) {
+		case *object.List:
+			elements = seq.Elements
+		case *object.Tuple:
+			elements = seq.Elements
+		default:
+			return v, mismatch(obj, t)
+		}
+
+		if t.Kind() == reflect.Array && len(elements) != t.Len() {
+			return v, fmt.Errorf("expected %d elements for %s, got %d", t.Len(), t, len(elements))
+		}
+		if t.Kind() == reflect.Slice {
+			v.Set(reflect.MakeSlice(t, len(elements), len(elements)))
+		}
+		for i, el := range elements {
+			elem, err := toValue(el, t.Elem())
+			if err != nil {
+				return v, err
+			}
+			v.Index(i).Set(elem)
+		}
+
+	case reflect.Map:
+		dict, ok := obj.(*object.Dict)
+		if !ok {
+			return v, mismatch(obj, t)
+		}
+		v.Set(reflect.MakeMapWithSize(t, len(dict.Pairs)))
+		for _, pair := range dict.Pairs {
+			key, err := toValue(pair.Key, t.Key())
+			if err != nil {
+				return v, err
+			}
+			val, err := toValue(pair.Value, t.Elem())
+			if err != nil {
+				return v, err
+			}
+			v.SetMapIndex(key, val)
+		}
+
+	case reflect.Pointer:
+		elem, err := toValue(obj, t.Elem())
+		if err != nil {
+			return v, err
+		}
+		v.Set(reflect.New(t.Elem()))
+		v.Elem().Set(elem)
+
+	default:
+		return v, mismatch(obj, t)
+	}
+
+	return v, nil
+}
+
+func mismatch(obj object.Object, t reflect.Type) error {
+	return fmt.Errorf("cannot use %s as %s", obj.Type(), t)
+}
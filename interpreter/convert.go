@@ -0,0 +1,244 @@
+package interpreter
+
+import (
+	"fmt"
+	"math"
+	"reflect"
+	"simpyl/evaluator"
+	"simpyl/object"
+)
+
+// ToObject converts a Go value to a Simpyl object:
+//
+//   - nil becomes null, and an object.Object is returned unchanged
+//   - bools, integers, floats and strings become bool, int, float and str
+//   - []byte becomes bytes, and other slices and arrays become lists
+//   - maps become dicts, with their keys converted too
+//   - structs, and pointers to them, are wrapped so that scripts can use their
+//     exported fields and methods as attributes. A field tagged simpyl:"name"
+//     is renamed, and one tagged simpyl:"-" is hidden. Slice and map fields are
+//     read as copies, which are tuples and read-only dicts so that changing one
+//     is an error; scripts assign the field instead
+//   - functions become builtins that convert their arguments from Simpyl and
+//     their results to Simpyl. A non-nil error result raises an error in the
+//     script, and several other results are returned as a tuple
+func ToObject(value any) (object.Object, error) {
+	if value == nil {
+		return evaluator.NULL, nil
+	}
+	return toObject(reflect.ValueOf(value))
+}
+
+func toObject(v reflect.Value) (object.Object, error) {
+	return convert(v, false)
+}
+
+// convert converts v as toObject does. When readOnly is set, slices and arrays
+// become tuples and maps become frozen dicts, all the way down, so that changes
+// to a copy fail instead of being silently lost
+func convert(v reflect.Value, readOnly bool) (object.Object, error) {
+	if v.CanInterface() {
+		switch value := v.Interface().(type) {
+		case object.Object:
+			return value, nil
+		case []byte:
+			return &object.Bytes{Value: append([]byte{}, value...)}, nil
+		case func(...object.Object) object.Object:
+			return &object.Builtin{Fn: value}, nil
+		case object.BuiltinFunction:
+			return &object.Builtin{Fn: value}, nil
+		}
+	}
+
+	switch v.Kind() {
+	case reflect.Bool:
+		if v.Bool() {
+			return evaluator.TRUE, nil
+		}
+		return evaluator.FALSE, nil
+
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return &object.Integer{Value: v.Int()}, nil
+
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		if v.Uint() > math.MaxInt64 {
+			return nil, fmt.Errorf("cannot convert %d to an int: out of range", v.Uint())
+		}
+		return &object.Integer{Value: int64(v.Uint())}, nil
+
+	case reflect.Float32, reflect.Float64:
+		return &object.Float{Value: v.Float()}, nil
+
+	case reflect.String:
+		return &object.String{Value: v.String()}, nil
+
+	case reflect.Slice, reflect.Array:
+		if v.Kind() == reflect.Slice && v.IsNil() {
+			return evaluator.NULL, nil
+		}
+		elements := make([]object.Object, v.Len())
+		for i := range elements {
+			el, err := convert(v.Index(i), readOnly)
+			if err != nil {
+				return nil, err
+			}
+			elements[i] = el
+		}
+		if readOnly {
+			return &object.Tuple{Elements: elements}, nil
+		}
+		return &object.List{Elements: elements}, nil
+
+	case reflect.Map:
+		if v.IsNil() {
+			return evaluator.NULL, nil
+		}
+		dict := &object.Dict{Pairs: make(map[object.HashKey]object.HashPair, v.Len())}
+		iter := v.MapRange()
+		for iter.Next() {
+			key, err := convert(iter.Key(), readOnly)
+			if err != nil {
+				return nil, err
+			}
+			val, err := convert(iter.Value(), readOnly)
+			if err != nil {
+				return nil, err
+			}
+			if errObj := evaluator.SetItem(dict, key, val); errObj != nil {
+				_, err := result(errObj)
+				return nil, err
+			}
+		}
+		dict.Frozen = readOnly
+		return dict, nil
+
+	case reflect.Struct:
+		return wrapStruct(v), nil
+
+	case reflect.Func:
+		if v.IsNil() {
+			return evaluator.NULL, nil
+		}
+		return wrapFunc(v), nil
+
+	case reflect.Pointer, reflect.Interface:
+		if v.IsNil() {
+			return evaluator.NULL, nil
+		}
+		return convert(v.Elem(), readOnly)
+	}
+
+	return nil, fmt.Errorf("cannot convert %s to a Simpyl object", v.Type())
+}
+
+// FromObject converts a Simpyl object to a Go value:
+//
+//   - null becomes nil
+//   - bool, int, float, str and bytes become bool, int64, float64, string and []byte
+//   - lists, tuples and sets become []any. Sets are in no particular order
+//   - dicts become map[string]any when every key is a string, and map[any]any
+//     otherwise
+//   - a wrapped struct becomes a pointer to it
+//
+// Other objects, such as functions and instances, cannot be converted, and
+// neither can a container that holds itself
+func FromObject(obj object.Object) (any, error) {
+	return fromObject(obj, map[object.Object]bool{})
+}
+
+// fromObject converts obj, where seen holds the containers being converted
+// further up
+func fromObject(obj object.Object, seen map[object.Object]bool) (any, error) {
+	switch obj.(type) {
+	case *object.List, *object.Tuple, *object.Set, *object.Dict:
+		if seen[obj] {
+			return nil, fmt.Errorf("cannot convert %s: it contains itself", obj.Type())
+		}
+		seen[obj] = true
+		defer delete(seen, obj)
+	}
+
+	switch obj := obj.(type) {
+	case *object.Null:
+		return nil, nil
+	case *object.Boolean:
+		return obj.Value, nil
+	case *object.Integer:
+		return obj.Value, nil
+	case *object.Float:
+		return obj.Value, nil
+	case *object.String:
+		return obj.Value, nil
+	case *object.Bytes:
+		return append([]byte{}, obj.Value...), nil
+	case *object.List:
+		return fromObjects(obj.Elements, seen)
+	case *object.Tuple:
+		return fromObjects(obj.Elements, seen)
+
+	case *object.Set:
+		elements := make([]object.Object, 0, len(obj.Values))
+		for _, val := range obj.Values {
+			elements = append(elements, val)
+		}
+		return fromObjects(elements, seen)
+
+	case *object.Dict:
+		return fromDict(obj, seen)
+	case *goStruct:
+		return obj.ptr.Interface(), nil
+	}
+
+	return nil, fmt.Errorf("cannot convert %s to a Go value", obj.Type())
+}
+
+func fromObjects(elements []object.Object, seen map[object.Object]bool) ([]any, error) {
+	values := make([]any, len(elements))
+	for i, el := range elements {
+		val, err := fromObject(el, seen)
+		if err != nil {
+			return nil, err
+		}
+		values[i] = val
+	}
+	return values, nil
+}
+
+func fromDict(dict *object.Dict, seen map[object.Object]bool) (any, error) {
+	stringKeys := true
+	for _, pair := range dict.Pairs {
+		if _, ok := pair.Key.(*object.String); !ok {
+			stringKeys = false
+			break
+		}
+	}
+
+	if stringKeys {
+		values := make(map[string]any, len(dict.Pairs))
+		for _, pair := range dict.Pairs {
+			val, err := fromObject(pair.Value, seen)
+			if err != nil {
+				return nil, err
+			}
+			values[pair.Key.(*object.String).Value] = val
+		}
+		return values, nil
+	}
+
+	values := make(map[any]any, len(dict.Pairs))
+	for _, pair := range dict.Pairs {
+		key, err := fromObject(pair.Key, seen)
+		if err != nil {
+			return nil, err
+		}
+		if key != nil && !reflect.TypeOf(key).Comparable() {
+			return nil, fmt.Errorf("cannot convert dict key %s to a Go map key", pair.Key.Inspect())
+		}
+		val, err := fromObject(pair.Value, seen)
+		if err != nil {
+			return nil, err
+		}
+		values[key] = val
+	}
+	return values, nil
+}
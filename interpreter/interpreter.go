@@ -0,0 +1,286 @@
+// Package interpreter embeds Simpyl in Go programs. An Interpreter keeps its
+// global variables between calls, so a host can load a script once and then call
+// its functions with Go values:
+//
+//	interp := interpreter.New(interpreter.Config{Stdout: os.Stdout})
+//	if err := interp.Exec("def allow(user):\n    return user['admin']\n"); err != nil {
+//		return err
+//	}
+//	result, err := interp.Call("allow", map[string]any{"admin": true})
+//
+// An Interpreter must only be used by one goroutine at a time, but separate
+// interpreters share no mutable state and can run in parallel. To load modules
+// and functions once for many interpreters, load them into one interpreter,
+// freeze it, and pass its globals to the others in Config.Shared. Objects reached
+// through shared globals, such as lists, are not locked, so scripts running in
+// parallel must not change them
+package interpreter
+
+import (
+	"context"
+	"io"
+	"simpyl/ast"
+	"simpyl/evaluator"
+	"simpyl/lexer"
+	"simpyl/object"
+	"simpyl/parser"
+	"strings"
+)
+
+// Config sets where a script's input and output go, and the limits on each call
+// to Exec, Eval or Call
+type Config struct {
+	Stdin  io.Reader // Read by input(). A nil reader is empty
+	Stdout io.Writer // Written by print(). Output to a nil writer is discarded
+	Stderr io.Writer // Output to a nil writer is discarded
+
+	// Zero means no limit, except for MaxDepth, which defaults to
+	// evaluator.DefaultMaxDepth
+	MaxSteps int64 // Evaluation steps, roughly one per expression evaluated
+	MaxDepth int   // Nested function calls, beyond which a RecursionError is raised
+	MaxAlloc int64 // Length of any one list, string or other container
+
+	// Capabilities whitelist the builtins and modules scripts may use, so that
+	// untrusted scripts can be run in a sandbox. Nil capabilities allow all of
+	// them. Values bound with Set are always available
+	Capabilities *object.Capabilities
+
+	// Shared is an environment, usually returned by Freeze, whose names scripts
+	// can read as if they were globals. New freezes it if it is not frozen yet
+	Shared *object.Environment
+}
+
+// Interpreter runs Simpyl source in one global environment
+type Interpreter struct {
+	env     *object.Environment
+	runtime *object.Runtime
+}
+
+// SyntaxError is returned for source that does not parse. It holds every error
+// the parser found
+type SyntaxError struct {
+	Errors []string
+}
+
+func (e *SyntaxError) Error() string {
+	return strings.Join(e.Errors, "\n")
+}
+
+// ScriptError is returned when a script fails while running
+type ScriptError struct {
+	Message string
+	Err     error // The context's error when the script was interrupted, or the error a Go function returned or panicked with
+}
+
+func (e *ScriptError) Error() string {
+	return e.Message
+}
+
+func (e *ScriptError) Unwrap() error {
+	return e.Err
+}
+
+// New returns an interpreter with empty globals
+func New(config Config) *Interpreter {
+	if config.Stdin == nil {
+		config.Stdin = strings.NewReader("")
+	}
+	if config.Stdout == nil {
+		config.Stdout = io.Discard
+	}
+	if config.Stderr == nil {
+		config.Stderr = io.Discard
+	}
+
+	runtime := evaluator.NewRuntime(config.Stdin, config.Stdout, config.Stderr)
+	runtime.MaxSteps = config.MaxSteps
+	runtime.MaxAlloc = config.MaxAlloc
+	runtime.Capabilities = config.Capabilities
+	if config.MaxDepth != 0 {
+		runtime.MaxDepth = config.MaxDepth
+	}
+
+	env := object.NewEnvironment()
+	if config.Shared != nil {
+		if !config.Shared.Frozen() {
+			config.Shared.Freeze()
+		}
+		env = object.NewEnclosedEnvironment(config.Shared)
+	}
+	env.SetRuntime(runtime)
+	env.Set("__name__", &object.String{Value: "__main__"})
+
+	return &Interpreter{env: env, runtime: runtime}
+}
+
+// Exec runs statements in the global environment
+func (i *Interpreter) Exec(src string) error {
+	return i.ExecContext(context.Background(), src)
+}
+
+// ExecContext is like Exec, but stops the script once ctx is done
+func (i *Interpreter) ExecContext(ctx context.Context, src string) error {
+	if i.env.Frozen() {
+		return &ScriptError{Message: "cannot run statements: the interpreter is frozen"}
+	}
+
+	program, err := parse(src)
+	if err != nil {
+		return err
+	}
+
+	_, err = i.run(ctx, func() object.Object {
+		return evaluator.Eval(program, i.env)
+	})
+	return err
+}
+
+// Eval evaluates a single expression in the global environment and returns its
+// value
+func (i *Interpreter) Eval(expr string) (object.Object, error) {
+	return i.EvalContext(context.Background(), expr)
+}
+
+// EvalContext is like Eval, but stops the script once ctx is done
+func (i *Interpreter) EvalContext(ctx context.Context, expr string) (object.Object, error) {
+	program, err := parse(expr)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(program.Statements) != 1 {
+		return nil, &SyntaxError{Errors: []string{"expected a single expression"}}
+	}
+	if _, ok := program.Statements[0].(*ast.ExpressionStatement); !ok {
+		return nil, &SyntaxError{Errors: []string{"expected an expression, got a statement"}}
+	}
+
+	return i.run(ctx, func() object.Object {
+		return evaluator.Eval(program, i.scope())
+	})
+}
+
+// Call calls the global function name, converting each argument with ToObject
+func (i *Interpreter) Call(name string, args ...any) (object.Object, error) {
+	return i.CallContext(context.Background(), name, args...)
+}
+
+// CallContext is like Call, but stops the function once ctx is done
+func (i *Interpreter) CallContext(ctx context.Context, name string, args ...any) (object.Object, error) {
+	fn, ok := i.env.Get(name)
+	if !ok {
+		return nil, &ScriptError{Message: "identifier not found: " + name}
+	}
+
+	objects := make([]object.Object, len(args))
+	for idx, arg := range args {
+		obj, err := ToObject(arg)
+		if err != nil {
+			return nil, err
+		}
+		objects[idx] = obj
+	}
+
+	return i.run(ctx, func() object.Object {
+		return evaluator.Apply(i.runtime, fn, objects, nil)
+	})
+}
+
+// Set binds a global variable, converting value with ToObject
+func (i *Interpreter) Set(name string, value any) error {
+	obj, err := ToObject(value)
+	if err != nil {
+		return err
+	}
+
+	_, err = result(i.env.Set(name, obj))
+	return err
+}
+
+// SetCapabilities replaces the capabilities of later runs. Modules that were
+// already imported stay bound to their names
+func (i *Interpreter) SetCapabilities(capabilities *object.Capabilities) {
+	i.runtime.Capabilities = capabilities
+}
+
+// Freeze makes the globals of the interpreter immutable and returns them, to be
+// shared with other interpreters through Config.Shared. Functions defined in
+// them run in the runtime of the interpreter that calls them. Afterwards, i
+// can still Eval and Call, but Exec fails
+func (i *Interpreter) Freeze() *object.Environment {
+	i.env.Freeze()
+	return i.env
+}
+
+// scope is the environment expressions are evaluated in. A frozen environment
+// has no runtime, so it is enclosed in one that does
+func (i *Interpreter) scope() *object.Environment {
+	if !i.env.Frozen() {
+		return i.env
+	}
+
+	env := object.NewEnclosedEnvironment(i.env)
+	env.SetRuntime(i.runtime)
+	return env
+}
+
+// Get returns the value of a global variable
+func (i *Interpreter) Get(name string) (object.Object, bool) {
+	return i.env.Get(name)
+}
+
+// run evaluates a script under ctx with a fresh step budget. A script run from
+// inside another, by a host function, shares the budget of the outer one
+func (i *Interpreter) run(ctx context.Context, eval func() object.Object) (object.Object, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	runtime := i.runtime
+	outer := runtime.Context
+	if outer == nil {
+		runtime.Steps = 0
+	}
+	runtime.Context = ctx
+	defer func() { runtime.Context = outer }()
+
+	evaluated := eval()
+	if outer == nil {
+		// As in Python, a script finishes once the threads it started have
+		// finished
+		evaluator.WaitThreads(runtime)
+	}
+
+	obj, err := result(evaluated)
+	if ctx.Err() == nil {
+		return obj, err
+	}
+	// A thread interrupted by ctx reports its error to stderr, so the run
+	// fails even if the script itself finished
+	if err, ok := err.(*ScriptError); ok {
+		err.Err = ctx.Err()
+		return nil, err
+	}
+	return nil, &ScriptError{Message: "interrupted: " + ctx.Err().Error(), Err: ctx.Err()}
+}
+
+func parse(src string) (*ast.Program, error) {
+	p := parser.New(lexer.New(src))
+	program := p.ParseProgram()
+	if len(p.Errors()) != 0 {
+		return nil, &SyntaxError{Errors: p.Errors()}
+	}
+	return program, nil
+}
+
+// result turns what the evaluator returned into a value and an error. Statements
+// without a value yield null
+func result(obj object.Object) (object.Object, error) {
+	switch obj := obj.(type) {
+	case nil:
+		return evaluator.NULL, nil
+	case *object.Error:
+		return nil, &ScriptError{Message: obj.Message, Err: obj.Err}
+	}
+	return obj, nil
+}
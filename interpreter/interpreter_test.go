@@ -0,0 +1,757 @@
+package interpreter
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"reflect"
+	"runtime"
+	"simpyl/evaluator"
+	"simpyl/native"
+	"simpyl/object"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestExecAndGlobals(t *testing.T) {
+	interp := New(Config{})
+
+	if err := interp.Set("limit", 10); err != nil {
+		t.Fatal(err)
+	}
+	if err := interp.Exec("total = limit * 2\ndef double(x):\n    return x * 2\n"); err != nil {
+		t.Fatalf("Exec failed: %v", err)
+	}
+
+	total, ok := interp.Get("total")
+	if !ok {
+		t.Fatalf("total is not set")
+	}
+	if value, _ := FromObject(total); value != int64(20) {
+		t.Errorf("total wrong. expected=20, got=%v", value)
+	}
+
+	// Globals persist between calls
+	result, err := interp.Eval("double(total) + limit")
+	if err != nil {
+		t.Fatalf("Eval failed: %v", err)
+	}
+	if value, _ := FromObject(result); value != int64(50) {
+		t.Errorf("Eval wrong. expected=50, got=%v", value)
+	}
+
+	if _, ok := interp.Get("missing"); ok {
+		t.Errorf("Get found an unset name")
+	}
+}
+
+func TestCall(t *testing.T) {
+	interp := New(Config{})
+	err := interp.Exec(`
+def allow(user, roles):
+    if user["admin"]:
+        return true
+    return "editor" in roles
+
+def fail():
+    return [1][5]
+`)
+	if err != nil {
+		t.Fatalf("Exec failed: %v", err)
+	}
+
+	result, err := interp.Call("allow", map[string]any{"admin": false}, []string{"viewer", "editor"})
+	if err != nil {
+		t.Fatalf("Call failed: %v", err)
+	}
+	if result != evaluator.TRUE {
+		t.Errorf("allow wrong. got=%s", result.Inspect())
+	}
+
+	_, err = interp.Call("fail")
+	var scriptErr *ScriptError
+	if !errors.As(err, &scriptErr) || scriptErr.Message != "list index out of range" {
+		t.Errorf("wrong error from fail(). got=%v", err)
+	}
+
+	if _, err := interp.Call("missing"); err == nil || err.Error() != "identifier not found: missing" {
+		t.Errorf("wrong error for a missing function. got=%v", err)
+	}
+}
+
+func TestErrors(t *testing.T) {
+	interp := New(Config{})
+
+	err := interp.Exec("x = (\ny = 1 2\n")
+	var syntaxErr *SyntaxError
+	if !errors.As(err, &syntaxErr) || len(syntaxErr.Errors) != 1 {
+		t.Errorf("expected one syntax error, got=%v", err)
+	}
+
+	tests := []struct {
+		expr     string
+		expected string
+	}{
+		{"x = 1", "expected an expression, got a statement"},
+		{"1\n2", "expected a single expression"},
+		{"1 / 0", "integer division or modulo by zero"},
+		{"y", "identifier not found: y"},
+	}
+
+	for _, tt := range tests {
+		_, err := interp.Eval(tt.expr)
+		if err == nil || err.Error() != tt.expected {
+			t.Errorf("Eval(%q) wrong error. expected=%q, got=%v", tt.expr, tt.expected, err)
+		}
+	}
+}
+
+func TestStreams(t *testing.T) {
+	var stdout bytes.Buffer
+	interp := New(Config{Stdin: strings.NewReader("Ada\nLovelace\n"), Stdout: &stdout})
+
+	err := interp.Exec("first = input('first? ')\nlast = input()\nprint('hello ' + first + ' ' + last)\n")
+	if err != nil {
+		t.Fatalf("Exec failed: %v", err)
+	}
+	if stdout.String() != "first? hello Ada Lovelace\n" {
+		t.Errorf("wrong output. got=%q", stdout.String())
+	}
+
+	if _, err := interp.Eval("input()"); err == nil || err.Error() != "EOFError: EOF when reading a line" {
+		t.Errorf("expected EOFError, got=%v", err)
+	}
+
+	// Interpreters do not share output
+	var other bytes.Buffer
+	if err := New(Config{Stdout: &other}).Exec("print(1)"); err != nil {
+		t.Fatal(err)
+	}
+	if other.String() != "1\n" || strings.Contains(stdout.String(), "1\n") {
+		t.Errorf("output went to the wrong interpreter. got=%q and %q", stdout.String(), other.String())
+	}
+}
+
+func TestToObject(t *testing.T) {
+	type celsius float64
+
+	tests := []struct {
+		input    any
+		expected string
+	}{
+		{nil, "null"},
+		{true, "true"},
+		{uint8(7), "7"},
+		{celsius(21.5), "21.5"},
+		{"text", "text"},
+		{[]byte("ab"), "b'ab'"},
+		{[]any{1, "a", nil}, "[1, a, null]"},
+		{[2]int{3, 4}, "[3, 4]"},
+		{map[string]int{"a": 1}, "{a: 1}"},
+		{&object.Integer{Value: 3}, "3"},
+	}
+
+	for _, tt := range tests {
+		obj, err := ToObject(tt.input)
+		if err != nil {
+			t.Errorf("ToObject(%#v) failed: %v", tt.input, err)
+			continue
+		}
+		if obj.Inspect() != tt.expected {
+			t.Errorf("ToObject(%#v) wrong. expected=%q, got=%q", tt.input, tt.expected, obj.Inspect())
+		}
+	}
+
+	if _, err := ToObject(uint64(1 << 63)); err == nil {
+		t.Errorf("expected an error for an out of range integer")
+	}
+	if _, err := ToObject(make(chan int)); err == nil {
+		t.Errorf("expected an error for a channel")
+	}
+	if _, err := ToObject(map[[1]int]int{{1}: 1}); err == nil {
+		t.Errorf("expected an error for an unhashable key")
+	}
+	// Keys that are equal in scripts become one key, as in a dict literal
+	if obj, err := ToObject(map[any]int{1: 1, 1.0: 1}); err != nil || len(obj.(*object.Dict).Pairs) != 1 {
+		t.Errorf("expected equal keys to be merged. got=%v, %v", obj, err)
+	}
+
+	fn, err := ToObject(func(args ...object.Object) object.Object {
+		return &object.Integer{Value: int64(len(args))}
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	interp := New(Config{})
+	if err := interp.Set("count", fn); err != nil {
+		t.Fatal(err)
+	}
+	if result, err := interp.Eval("count(1, 2, 3)"); err != nil || result.Inspect() != "3" {
+		t.Errorf("host function wrong. got=%v, %v", result, err)
+	}
+}
+
+func TestFromObject(t *testing.T) {
+	interp := New(Config{})
+
+	tests := []struct {
+		expr     string
+		expected any
+	}{
+		{"print()", nil},
+		{"1 < 2", true},
+		{"3", int64(3)},
+		{"2.5", 2.5},
+		{"'s'", "s"},
+		{"b'ab'", []byte("ab")},
+		{"[1, (2, 'x')]", []any{int64(1), []any{int64(2), "x"}}},
+		{"{'a': [1]}", map[string]any{"a": []any{int64(1)}}},
+		{"{1: 'one', 'two': 2}", map[any]any{int64(1): "one", "two": int64(2)}},
+	}
+
+	for _, tt := range tests {
+		obj, err := interp.Eval(tt.expr)
+		if err != nil {
+			t.Fatalf("Eval(%q) failed: %v", tt.expr, err)
+		}
+		value, err := FromObject(obj)
+		if err != nil {
+			t.Errorf("FromObject(%s) failed: %v", tt.expr, err)
+			continue
+		}
+		if !reflect.DeepEqual(value, tt.expected) {
+			t.Errorf("FromObject(%s) wrong. expected=%#v, got=%#v", tt.expr, tt.expected, value)
+		}
+	}
+
+	if err := interp.Exec("loop = [1]\nloop.append(loop)"); err != nil {
+		t.Fatal(err)
+	}
+	for _, expr := range []string{"len", "{(1, 2): 3}", "loop"} {
+		obj, err := interp.Eval(expr)
+		if err != nil {
+			t.Fatalf("Eval(%q) failed: %v", expr, err)
+		}
+		if _, err := FromObject(obj); err == nil {
+			t.Errorf("FromObject(%s) should have failed", expr)
+		}
+	}
+}
+
+func TestLimits(t *testing.T) {
+	tests := []struct {
+		config   Config
+		src      string
+		expected string
+	}{
+		{Config{MaxSteps: 1000}, "while true:\n    x = 1", "step limit of 1000 exceeded"},
+		{Config{}, "def f(n):\n    return f(n + 1)\nf(0)", "RecursionError: maximum recursion depth exceeded"},
+		{Config{MaxDepth: 10}, "def f(n):\n    if n == 0:\n        return 0\n    return f(n - 1)\nf(10)", "RecursionError: maximum recursion depth exceeded"},
+		{Config{MaxAlloc: 1000}, "'ab' * 501", "MemoryError: cannot allocate 1002 items, the limit is 1000"},
+		{Config{MaxAlloc: 1000}, "[0, 0] * 9223372036854775807", "MemoryError: cannot allocate 9223372036854775807 items, the limit is 1000"},
+		{Config{MaxAlloc: 1000}, "range(0, 10000, 2)", "MemoryError: cannot allocate 5000 items, the limit is 1000"},
+		{Config{MaxAlloc: 1000}, "'x'.zfill(100000)", "MemoryError: cannot allocate 100000 items, the limit is 1000"},
+		{Config{MaxAlloc: 1000}, "range(-9223372036854775807, true)", "MemoryError: cannot allocate 9223372036854775807 items, the limit is 1000"},
+		{Config{MaxAlloc: 1000}, "f'{1:>50000000}'", "MemoryError: cannot allocate 50000000 items, the limit is 1000"},
+		{Config{MaxAlloc: 1000}, "'{:>50000000}'.format(1)", "MemoryError: cannot allocate 50000000 items, the limit is 1000"},
+		{Config{MaxAlloc: 1000}, "format(1.5, '.50000000f')", "MemoryError: cannot allocate 50000000 items, the limit is 1000"},
+		{Config{MaxAlloc: 1000}, "'%50000000d' % 1", "MemoryError: cannot allocate 50000000 items, the limit is 1000"},
+		{Config{MaxAlloc: 1000}, "'%.*f' % (50000000, 1.5)", "MemoryError: cannot allocate 50000000 items, the limit is 1000"},
+		{Config{MaxAlloc: 1000}, "s = 'ab'\nwhile true:\n    s = s + s", "MemoryError: cannot allocate 1024 items, the limit is 1000"},
+		{Config{MaxAlloc: 1000}, "xs = []\nwhile true:\n    xs.append(1)", "MemoryError: cannot allocate 1001 items, the limit is 1000"},
+	}
+
+	for _, tt := range tests {
+		err := New(tt.config).Exec(tt.src)
+		if err == nil || err.Error() != tt.expected {
+			t.Errorf("%q: wrong error. expected=%q, got=%v", tt.src, tt.expected, err)
+		}
+	}
+
+	// Limits apply to each run, and the recursion depth recovers after an error
+	interp := New(Config{MaxSteps: 10000, MaxDepth: 50})
+	if err := interp.Exec("def f(n):\n    if n == 0:\n        return 0\n    return f(n - 1)\n"); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := interp.Call("f", 100); err == nil {
+		t.Errorf("expected a RecursionError")
+	}
+	for i := 0; i < 10; i++ {
+		if result, err := interp.Call("f", 40); err != nil || result.Inspect() != "0" {
+			t.Fatalf("run %d failed: %v", i, err)
+		}
+	}
+}
+
+func TestContext(t *testing.T) {
+	interp := New(Config{})
+
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	err := interp.ExecContext(ctx, "while true:\n    x = 1")
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("expected a deadline error, got=%v", err)
+	}
+
+	cancelled, cancel := context.WithCancel(context.Background())
+	cancel()
+	if _, err := interp.EvalContext(cancelled, "1"); !errors.Is(err, context.Canceled) {
+		t.Errorf("expected a cancelled error, got=%v", err)
+	}
+
+	// The interpreter is usable after an interrupted run
+	if result, err := interp.Eval("x + 1"); err != nil || result.Inspect() != "2" {
+		t.Errorf("Eval after interruption wrong. got=%v, %v", result, err)
+	}
+}
+
+func TestSandbox(t *testing.T) {
+	// A host module that exposes the process environment and filesystem
+	if _, ok := native.Lookup("hostos"); !ok {
+		native.MustRegister("hostos", native.Members{
+			"getenv": &object.Builtin{Fn: func(args ...object.Object) object.Object {
+				return &object.String{Value: os.Getenv(args[0].Inspect())}
+			}},
+			"read": &object.Builtin{Fn: func(args ...object.Object) object.Object {
+				data, _ := os.ReadFile(args[0].Inspect())
+				return &object.String{Value: string(data)}
+			}},
+		})
+	}
+
+	dir := t.TempDir()
+	secret := filepath.Join(dir, "secret.py")
+	if err := os.WriteFile(secret, []byte("token = 'hunter2'\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv("SIMPYLPATH", dir)
+	t.Setenv("SIMPYL_SECRET", "hunter2")
+
+	// Without capabilities, a script can reach all of it
+	open := New(Config{})
+	if err := open.Set("path", secret); err != nil {
+		t.Fatal(err)
+	}
+	for _, expr := range []string{"hostos.getenv('SIMPYL_SECRET')", "hostos.read(path)", "secret.token"} {
+		if err := open.Exec("import hostos\nimport secret\nleaked = " + expr); err != nil {
+			t.Fatalf("%s failed: %v", expr, err)
+		}
+		if leaked, _ := open.Get("leaked"); !strings.Contains(leaked.Inspect(), "hunter2") {
+			t.Errorf("%s wrong. got=%s", expr, leaked.Inspect())
+		}
+	}
+
+	var stdout bytes.Buffer
+	sandbox := New(Config{
+		Stdout:       &stdout,
+		Stdin:        strings.NewReader("input\n"),
+		Capabilities: &object.Capabilities{Builtins: []string{"len", "print"}, Modules: []string{"math"}},
+	})
+	if err := sandbox.Set("path", secret); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		src      string
+		expected string
+	}{
+		{"import hostos", "PermissionError: import of module 'hostos' is not allowed"},
+		{"from hostos import getenv", "PermissionError: import of module 'hostos' is not allowed"},
+		{"import secret", "PermissionError: import of module 'secret' is not allowed"},
+		{"x = input()", "PermissionError: use of builtin 'input' is not allowed"},
+		{"f = sorted", "PermissionError: use of builtin 'sorted' is not allowed"},
+		{"def f():\n    return str(1)\nf()", "PermissionError: use of builtin 'str' is not allowed"},
+	}
+
+	for _, tt := range tests {
+		err := sandbox.Exec(tt.src)
+		var scriptErr *ScriptError
+		if !errors.As(err, &scriptErr) || scriptErr.Message != tt.expected {
+			t.Errorf("%q: wrong error. expected=%q, got=%v", tt.src, tt.expected, err)
+		}
+	}
+
+	// Whitelisted builtins and modules still work
+	if err := sandbox.Exec("import math\nprint(len(path) > 0, math.sqrt(16))"); err != nil {
+		t.Fatalf("allowed script failed: %v", err)
+	}
+	if stdout.String() != "true\n4.0\n" {
+		t.Errorf("wrong output. got=%q", stdout.String())
+	}
+
+	// Capabilities can change between runs
+	sandbox.SetCapabilities(nil)
+	if err := sandbox.Exec("import secret\nx = sorted([2, 1])"); err != nil {
+		t.Errorf("unrestricted run failed: %v", err)
+	}
+}
+
+var errOverdrawn = errors.New("insufficient funds")
+
+type account struct {
+	Owner   string
+	Balance float64
+	Limit   int `simpyl:"limit"`
+	PIN     int `simpyl:"-"`
+	Tags    []string
+	Limits  map[string]int
+	history []float64
+}
+
+func (a *account) Withdraw(amount float64) (float64, error) {
+	if amount > a.Balance {
+		return a.Balance, errOverdrawn
+	}
+	a.Balance -= amount
+	a.history = append(a.history, -amount)
+	return a.Balance, nil
+}
+
+func (a account) Describe() string {
+	return a.Owner + " has " + strconv.FormatFloat(a.Balance, 'f', -1, 64)
+}
+
+func TestBridge(t *testing.T) {
+	acct := &account{Owner: "ada", Balance: 100, Limit: 10, PIN: 1234, Tags: []string{"new"}, Limits: map[string]int{"day": 200}}
+	interp := New(Config{})
+
+	values := map[string]any{
+		"acct":     acct,
+		"accounts": []account{{Owner: "bob"}, {Owner: "cy"}},
+		"split":    strings.Split,
+		"total": func(xs ...int) int {
+			sum := 0
+			for _, x := range xs {
+				sum += x
+			}
+			return sum
+		},
+		"lookup": func(m map[string]int, key string) (int, bool) {
+			val, ok := m[key]
+			return val, ok
+		},
+		"owner":  func(a account) string { return a.Owner },
+		"divide": func(a, b int) int { return a / b },
+		"fail":   func() { panic("broken") },
+	}
+	for name, value := range values {
+		if err := interp.Set(name, value); err != nil {
+			t.Fatalf("Set(%s) failed: %v", name, err)
+		}
+	}
+
+	// Scripts change the struct the host passed in
+	if err := interp.Exec("acct.Withdraw(30)\nacct.Owner = 'grace'\nacct.limit = acct.limit + 1"); err != nil {
+		t.Fatalf("Exec failed: %v", err)
+	}
+	if acct.Owner != "grace" || acct.Balance != 70 || acct.Limit != 11 || len(acct.history) != 1 {
+		t.Errorf("account not updated. got=%+v", *acct)
+	}
+
+	// Slice and map fields are copies, which scripts change by assigning the field
+	if err := interp.Exec("acct.Tags = list(acct.Tags) + ['vip']\nacct.Limits = dict(acct.Limits) | {'atm': 50}"); err != nil {
+		t.Fatalf("Exec failed: %v", err)
+	}
+	if !reflect.DeepEqual(acct.Tags, []string{"new", "vip"}) || !reflect.DeepEqual(acct.Limits, map[string]int{"day": 200, "atm": 50}) {
+		t.Errorf("slice and map fields not updated. got=%v, %v", acct.Tags, acct.Limits)
+	}
+
+	tests := []struct {
+		expr     string
+		expected any
+	}{
+		{"acct.Describe()", "grace has 70"},
+		{"acct.Balance / 2", 35.0},
+		{"accounts[1].Owner", "cy"},
+		{"owner(acct)", "grace"},
+		{"split('a,b', ',')", []any{"a", "b"}},
+		{"total()", int64(0)},
+		{"total(1, 2, 3)", int64(6)},
+		{"lookup({'a': 1}, 'a')", []any{int64(1), true}},
+		{"lookup({}, 'a')", []any{int64(0), false}},
+		{"acct.Tags", []any{"new", "vip"}},
+		{"acct.Limits['day'] + acct.Limits.get('atm', 0)", int64(250)},
+	}
+
+	for _, tt := range tests {
+		obj, err := interp.Eval(tt.expr)
+		if err != nil {
+			t.Errorf("Eval(%q) failed: %v", tt.expr, err)
+			continue
+		}
+		value, err := FromObject(obj)
+		if err != nil {
+			t.Errorf("FromObject(%s) failed: %v", tt.expr, err)
+			continue
+		}
+		if !reflect.DeepEqual(value, tt.expected) {
+			t.Errorf("Eval(%q) wrong. expected=%#v, got=%#v", tt.expr, tt.expected, value)
+		}
+	}
+
+	errorTests := []struct {
+		expr     string
+		expected string
+	}{
+		{"acct.PIN", "<Go interpreter.account> has no attribute 'PIN'"},
+		{"acct.history", "<Go interpreter.account> has no attribute 'history'"},
+		{"acct.Balance = 'lots'", "attribute 'Balance': cannot use STRING as float64"},
+		{"split('a')", "wrong number of arguments. got=1, want=2"},
+		{"split(1, ',')", "argument 1: cannot use INTEGER as string"},
+		{"total(1, 'two')", "argument 2: cannot use STRING as int"},
+		{"acct.Withdraw(1000)", "insufficient funds"},
+		{"divide(1, 0)", "panic in Go function: runtime error: integer divide by zero"},
+		{"fail()", "panic in Go function: broken"},
+		{"acct.Tags.append('x')", "TUPLE object has no attribute 'append'"},
+		{"acct.Limits['day'] = 1", "TypeError: read-only dict does not support item assignment"},
+		{"del acct.Limits['day']", "TypeError: read-only dict does not support item deletion"},
+		{"acct.Limits.update(day=1)", "DICT object has no attribute 'update'"},
+	}
+
+	for _, tt := range errorTests {
+		err := interp.Exec(tt.expr)
+		if err == nil || err.Error() != tt.expected {
+			t.Errorf("%q: wrong error. expected=%q, got=%v", tt.expr, tt.expected, err)
+		}
+	}
+
+	// Errors returned by Go functions can be matched by the host
+	if err := interp.Exec("acct.Withdraw(1000)"); !errors.Is(err, errOverdrawn) {
+		t.Errorf("expected errOverdrawn, got=%v", err)
+	}
+	var runtimeErr runtime.Error
+	if err := interp.Exec("divide(1, 0)"); !errors.As(err, &runtimeErr) {
+		t.Errorf("expected a runtime.Error, got=%v", err)
+	}
+
+	// Wrapped structs convert back to the pointer they came from
+	obj, _ := interp.Get("acct")
+	if value, err := FromObject(obj); err != nil || value != acct {
+		t.Errorf("FromObject wrong. got=%v, %v", value, err)
+	}
+	if names := evaluator.Attributes(obj); !reflect.DeepEqual(names, []string{"Balance", "Describe", "Limits", "Owner", "Tags", "Withdraw", "limit"}) {
+		t.Errorf("wrong attributes. got=%v", names)
+	}
+}
+
+// TestParallel runs many interpreters at once over shared, frozen globals. Run it
+// with -race to check that they share no mutable state
+func TestParallel(t *testing.T) {
+	lib := New(Config{})
+	err := lib.Exec(`
+import math
+
+def norm(xs):
+    total = 0
+    for x in xs:
+        total = total + x * x
+    return math.sqrt(total)
+
+def greet(name):
+    print("hello " + name)
+
+def spin():
+    while true:
+        x = 1
+
+class Point:
+    def __init__(self, x, y):
+        self.x = x
+        self.y = y
+
+    def norm(self):
+        return norm([self.x, self.y])
+`)
+	if err != nil {
+		t.Fatalf("Exec failed: %v", err)
+	}
+	shared := lib.Freeze()
+
+	const workers = 32
+	var wg sync.WaitGroup
+	errs := make(chan error, workers)
+
+	for n := 0; n < workers; n++ {
+		wg.Add(1)
+		go func(n int) {
+			defer wg.Done()
+
+			var stdout bytes.Buffer
+			interp := New(Config{Stdout: &stdout, Shared: shared, MaxSteps: 100000})
+			if err := interp.Set("n", n); err != nil {
+				errs <- err
+				return
+			}
+
+			err := interp.Exec(`
+import math
+greet(str(n))
+p = Point(3 * n, 4 * n)
+result = p.norm() + norm([n]) + math.floor(0.5)
+def fib(k):
+    if k < 2:
+        return k
+    return fib(k - 1) + fib(k - 2)
+f = fib(15)
+`)
+			if err != nil {
+				errs <- fmt.Errorf("worker %d: %w", n, err)
+				return
+			}
+
+			result, _ := interp.Get("result")
+			f, _ := interp.Get("f")
+			if result.Inspect() != strconv.Itoa(6*n)+".0" || f.Inspect() != "610" {
+				errs <- fmt.Errorf("worker %d: wrong results %s and %s", n, result.Inspect(), f.Inspect())
+			}
+			if stdout.String() != "hello "+strconv.Itoa(n)+"\n" {
+				errs <- fmt.Errorf("worker %d: wrong output %q", n, stdout.String())
+			}
+
+			// Shared functions count against the limits of the caller
+			if _, err := interp.Call("spin"); err == nil || err.Error() != "step limit of 100000 exceeded" {
+				errs <- fmt.Errorf("worker %d: expected a step limit error, got %v", n, err)
+			}
+		}(n)
+	}
+
+	wg.Wait()
+	close(errs)
+	for err := range errs {
+		t.Error(err)
+	}
+}
+
+func TestFreeze(t *testing.T) {
+	lib := New(Config{})
+	if err := lib.Exec("import math\nlimit = 10\ndef double(x):\n    return x * 2\n"); err != nil {
+		t.Fatal(err)
+	}
+	shared := lib.Freeze()
+
+	if err := lib.Exec("limit = 11"); err == nil || err.Error() != "cannot run statements: the interpreter is frozen" {
+		t.Errorf("expected Exec to fail on a frozen interpreter, got=%v", err)
+	}
+	if err := lib.Set("limit", 11); err == nil || err.Error() != "cannot assign to 'limit': the environment is frozen" {
+		t.Errorf("expected Set to fail on a frozen interpreter, got=%v", err)
+	}
+	if result, err := lib.Eval("double(limit)"); err != nil || result.Inspect() != "20" {
+		t.Errorf("Eval on a frozen interpreter wrong. got=%v, %v", result, err)
+	}
+
+	interp := New(Config{Shared: shared})
+	tests := []struct {
+		src      string
+		expected string
+	}{
+		{"math.pi = 3", "cannot assign to 'pi': the environment is frozen"},
+		{"limit = limit + 1\ndouble = 5\nresult = limit", ""},
+	}
+	for _, tt := range tests {
+		err := interp.Exec(tt.src)
+		if (err == nil && tt.expected != "") || (err != nil && err.Error() != tt.expected) {
+			t.Errorf("%q: wrong error. expected=%q, got=%v", tt.src, tt.expected, err)
+		}
+	}
+
+	// Names bound by a script shadow the shared ones without changing them
+	if result, _ := interp.Get("result"); result.Inspect() != "11" {
+		t.Errorf("result wrong. got=%s", result.Inspect())
+	}
+	if limit, _ := shared.Get("limit"); limit.Inspect() != "10" {
+		t.Errorf("shared limit changed. got=%s", limit.Inspect())
+	}
+}
+
+func TestThreads(t *testing.T) {
+	var stderr bytes.Buffer
+	interp := New(Config{Stderr: &stderr})
+
+	// A run waits for the threads it started
+	err := interp.Exec(`
+import threading
+results = []
+def work(n):
+    for i in range(100):
+        x = i
+    results.append(n)
+def fail():
+    return [][1]
+for n in range(3):
+    threading.Thread(target=work, args=[n]).start()
+threading.Thread(target=fail, name="failing").start()
+`)
+	if err != nil {
+		t.Fatalf("Exec failed: %v", err)
+	}
+	if result, _ := interp.Eval("sorted(results)"); result.Inspect() != "[0, 1, 2]" {
+		t.Errorf("results wrong. got=%s", result.Inspect())
+	}
+	if stderr.String() != "Exception in thread failing:\nlist index out of range\n" {
+		t.Errorf("wrong stderr. got=%q", stderr.String())
+	}
+
+	// Threads blocked forever are interrupted with the run
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	err = interp.ExecContext(ctx, `
+import queue
+jobs = queue.Queue()
+worker = threading.Thread(target=jobs.get)
+worker.start()
+worker.join()
+`)
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("expected a deadline error, got=%v", err)
+	}
+
+	// A run whose threads outlive its deadline fails, even though the script
+	// itself finished in time
+	ctx, cancel = context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	err = interp.ExecContext(ctx, `
+def spin():
+    while true:
+        x = 1
+threading.Thread(target=spin).start()
+`)
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("expected a deadline error, got=%v", err)
+	}
+
+	// Interpreters running threads in parallel do not share a GIL
+	var wg sync.WaitGroup
+	for n := 0; n < 4; n++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			interp := New(Config{})
+			err := interp.Exec(`
+import threading
+import queue
+done = queue.Queue()
+def work():
+    done.put(sum(range(1000)))
+for i in range(4):
+    threading.Thread(target=work).start()
+total = 0
+for i in range(4):
+    total = total + done.get()
+`)
+			if err != nil {
+				t.Error(err)
+				return
+			}
+			if total, _ := interp.Get("total"); total.Inspect() != "1998000" {
+				t.Errorf("total wrong. got=%s", total.Inspect())
+			}
+		}()
+	}
+	wg.Wait()
+}
@@ -1,25 +1,72 @@
 package lexer
 
-import "simpyl/token"
+import (
+	"fmt"
+	"simpyl/token"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
 
 type Lexer struct {
 	input        string
 	position     int  // current position in input (points to current char)
 	readPosition int  // current reading position in input (after current char)
 	ch           byte // current char under examination
+	errors       []string
+
+	indents     []string      // indentation of each open block, outermost first
+	pending     []token.Token // INDENT and DEDENT tokens waiting to be returned
+	atLineStart bool
+	brackets    []byte          // open brackets, innermost last, inside which lines are joined
+	last        token.TokenType // type of the token returned last
+
+	line        int // line of the current char, counted from 1
+	lineStart   int // position of the first char of the current line
+	indentError int // line of the first indentation error, or 0
 }
 
 func New(input string) *Lexer {
-	l := &Lexer{input: input}
+	l := &Lexer{input: input, indents: []string{""}, atLineStart: true, line: 1}
 	l.readChar()
 	return l
 }
 
+// Errors returns the malformed literals found so far, with their positions
+func (l *Lexer) Errors() []string {
+	return l.errors
+}
+
+// IndentErrorLine returns the line of the first indentation error, or 0 when the
+// indentation has been consistent so far
+func (l *Lexer) IndentErrorLine() int {
+	return l.indentError
+}
+
+// NextToken returns the next token, marked with the line and column it starts at
 func (l *Lexer) NextToken() token.Token {
-	var tok token.Token
+	if l.atLineStart {
+		l.atLineStart = false
+		l.readIndentation()
+	}
+	if len(l.pending) > 0 {
+		tok := l.pending[0]
+		l.pending = l.pending[1:]
+		return tok
+	}
 
 	l.skipWhitespace()
 
+	line, column := l.line, l.column()
+	tok := l.readToken()
+	tok.Line, tok.Column = line, column
+	l.last = tok.Type
+	return tok
+}
+
+func (l *Lexer) readToken() token.Token {
+	var tok token.Token
+
 	switch l.ch {
 	case '=':
 		if l.peekChar() == '=' {
@@ -47,35 +94,60 @@ func (l *Lexer) NextToken() token.Token {
 		tok = newToken(token.SLASH, l.ch)
 	case '*':
 		tok = newToken(token.ASTERISK, l.ch)
+	case '%':
+		tok = newToken(token.PERCENT, l.ch)
+	case '|':
+		tok = newToken(token.PIPE, l.ch)
+	case '&':
+		tok = newToken(token.AMP, l.ch)
+	case '^':
+		tok = newToken(token.CARET, l.ch)
 	case '<':
-		tok = newToken(token.LT, l.ch)
+		if l.peekChar() == '=' {
+			l.readChar()
+			tok = token.Token{Type: token.LT_EQ, Literal: "<="}
+		} else {
+			tok = newToken(token.LT, l.ch)
+		}
 	case '>':
-		tok = newToken(token.GT, l.ch)
+		if l.peekChar() == '=' {
+			l.readChar()
+			tok = token.Token{Type: token.GT_EQ, Literal: ">="}
+		} else {
+			tok = newToken(token.GT, l.ch)
+		}
 	case ';':
 		tok = newToken(token.SEMICOLON, l.ch)
 	case ',':
 		tok = newToken(token.COMMA, l.ch)
 	case '{':
 		tok = newToken(token.LBRACE, l.ch)
+		l.brackets = append(l.brackets, l.ch)
 	case '}':
 		tok = newToken(token.RBRACE, l.ch)
+		l.closeBracket()
 	case '(':
 		tok = newToken(token.LPAREN, l.ch)
+		l.brackets = append(l.brackets, l.ch)
 	case ')':
 		tok = newToken(token.RPAREN, l.ch)
-	case '\t':
-		tok = newToken(token.TAB, l.ch)
+		l.closeBracket()
 	case '\n':
 		tok = newToken(token.NEWLINE, l.ch)
+		l.atLineStart = true
 	case ':':
 		tok = newToken(token.COLON, l.ch)
-	case '"':
-		tok.Type = token.STRING
-		tok.Literal = l.readString()
+	case '"', '\'':
+		tok = l.readString(l.position, "")
 	case '[':
 		tok = newToken(token.LBRACKET, l.ch)
+		l.brackets = append(l.brackets, l.ch)
 	case ']':
 		tok = newToken(token.RBRACKET, l.ch)
+		l.closeBracket()
+	case '\\':
+		l.errorAt(l.position, "unexpected character after line continuation character")
+		tok = newToken(token.ILLEGAL, l.ch)
 	case '.':
 		if isDigit(l.peekChar()) {
 			tok = l.readNumber(tok)
@@ -84,10 +156,17 @@ func (l *Lexer) NextToken() token.Token {
 			tok = newToken(token.DOT, l.ch)
 		}
 	case 0:
+		// Close the blocks still open at the end of the input
+		if len(l.indents) > 1 {
+			l.indents = l.indents[:len(l.indents)-1]
+			return token.Token{Type: token.DEDENT}
+		}
 		tok.Literal = ""
 		tok.Type = token.EOF
 	default:
-		if isLetter(l.ch) {
+		if start, prefix, ok := l.readStringPrefix(); ok {
+			tok = l.readString(start, prefix)
+		} else if isIdentifierStart(l.currentRune()) {
 			tok.Literal = l.readIdentifier()
 			tok.Type = token.LookupIdent(tok.Literal)
 			return tok
@@ -95,7 +174,15 @@ func (l *Lexer) NextToken() token.Token {
 			tok = l.readNumber(tok)
 			return tok
 		} else {
-			tok = newToken(token.ILLEGAL, l.ch)
+			// Keep every byte of a multi-byte character in one illegal token
+			r, size := l.currentRune(), utf8.RuneLen(l.currentRune())
+			if r == utf8.RuneError {
+				size = 1
+			}
+			tok = token.Token{Type: token.ILLEGAL, Literal: l.input[l.position : l.position+size]}
+			for i := 1; i < size; i++ {
+				l.readChar()
+			}
 		}
 	}
 
@@ -103,19 +190,147 @@ func (l *Lexer) NextToken() token.Token {
 	return tok
 }
 
+// skipWhitespace skips spaces and comments. Inside brackets line breaks are
+// skipped too, and a backslash at the end of a line joins it to the next, so in
+// both cases the lines form one logical line without NEWLINE or INDENT tokens
 func (l *Lexer) skipWhitespace() {
-	for l.ch == ' ' || l.ch == '\r' {
-		l.readChar()
+	for {
+		switch {
+		case l.ch == ' ' || l.ch == '\t' || l.ch == '\r' || l.ch == '\f':
+			l.readChar()
+		case l.ch == '\n' && len(l.brackets) > 0:
+			if l.unclosed() {
+				l.brackets = l.brackets[:0]
+				return
+			}
+			l.readChar()
+		case l.ch == '\\' && l.peekChar() == '\n':
+			l.readChar()
+			l.readChar()
+		case l.ch == '\\' && l.peekChar() == '\r' && l.peekAhead(2) == '\n':
+			l.readChar()
+			l.readChar()
+			l.readChar()
+		case l.ch == '#':
+			for l.ch != '\n' && l.ch != 0 {
+				l.readChar()
+			}
+		default:
+			return
+		}
+	}
+}
+
+// closeBracket leaves a bracket. Unbalanced closers are left to the parser
+func (l *Lexer) closeBracket() {
+	if len(l.brackets) > 0 {
+		l.brackets = l.brackets[:len(l.brackets)-1]
+	}
+}
+
+// unclosed reports whether the parentheses or square brackets open at a line
+// break were left open by mistake, so that the line ends the statement after
+// all. That is the case when the next line starts with a keyword that only
+// begins statements, or when this line is a block header ending with a colon and
+// the next line is indented. Otherwise one unclosed bracket would join the rest
+// of the file into a single line, hiding every later syntax error. Braces are
+// left alone, since the legacy brace blocks hold whole statements
+func (l *Lexer) unclosed() bool {
+	if l.brackets[len(l.brackets)-1] == '{' {
+		return false
 	}
-	if l.ch == '#' {
-		for l.ch != '\n' || l.ch == 0 {
+
+	rest := l.input[l.readPosition:]
+	for {
+		line, after, found := strings.Cut(rest, "\n")
+		code := strings.TrimLeft(line, " \t\f")
+		if strings.TrimSpace(code) == "" || code[0] == '#' {
+			if !found {
+				return false
+			}
+			rest = after
+			continue
+		}
+
+		end := 0
+		for end < len(code) && (isLetter(code[end]) || isDigit(code[end]) || code[end] >= utf8.RuneSelf) {
+			end++
+		}
+		switch token.LookupIdent(code[:end]) {
+		case token.FUNCTION, token.CLASS, token.RETURN, token.WHILE, token.IMPORT, token.FROM, token.DEL, token.LET:
+			return true
+		}
+
+		indent := len(line) - len(code)
+		return l.last == token.COLON && indent > len(l.indents[len(l.indents)-1])
+	}
+}
+
+// readIndentation measures the leading whitespace of the next line that holds
+// code, skipping blank and comment-only lines, and queues an INDENT token when it
+// is deeper than the enclosing block or a DEDENT for each block it closes
+func (l *Lexer) readIndentation() {
+	for {
+		start := l.position
+		for l.ch == ' ' || l.ch == '\t' || l.ch == '\f' {
 			l.readChar()
 		}
-		l.readChar()
+		indent := l.input[start:l.position]
+
+		if l.ch == '#' {
+			for l.ch != '\n' && l.ch != 0 {
+				l.readChar()
+			}
+		}
+		if l.ch == '\r' {
+			l.readChar()
+		}
+		if l.ch == '\n' {
+			l.readChar()
+			continue
+		}
+		if l.ch == 0 {
+			return
+		}
+
+		l.indentTo(start, indent)
+		return
+	}
+}
+
+// indentTo compares the indentation of a line with the open blocks. Indentation
+// is compared as text, so a line must begin with the exact whitespace of the block
+// it belongs to; a mix of tabs and spaces that only matches by width is an error
+func (l *Lexer) indentTo(position int, indent string) {
+	current := l.indents[len(l.indents)-1]
+
+	switch {
+	case indent == current:
+		return
+
+	case strings.HasPrefix(indent, current):
+		l.indents = append(l.indents, indent)
+		l.pending = append(l.pending, l.positioned(token.Token{Type: token.INDENT, Literal: indent}))
+
+	case strings.HasPrefix(current, indent):
+		for len(l.indents) > 1 && len(l.indents[len(l.indents)-1]) > len(indent) {
+			l.indents = l.indents[:len(l.indents)-1]
+			l.pending = append(l.pending, l.positioned(token.Token{Type: token.DEDENT}))
+		}
+		if l.indents[len(l.indents)-1] != indent {
+			l.indentErrorAt(position, "inconsistent dedent: unindent does not match any outer indentation level")
+		}
+
+	default:
+		l.indentErrorAt(position, "inconsistent use of tabs and spaces in indentation")
 	}
 }
 
 func (l *Lexer) readChar() {
+	if l.ch == '\n' {
+		l.line++
+		l.lineStart = l.readPosition
+	}
 	if l.readPosition >= len(l.input) {
 		l.ch = 0
 	} else {
@@ -133,14 +348,34 @@ func (l *Lexer) peekChar() byte {
 	}
 }
 
+// peekAhead returns the char n positions after the current one
+func (l *Lexer) peekAhead(n int) byte {
+	if l.position+n >= len(l.input) {
+		return 0
+	}
+	return l.input[l.position+n]
+}
+
 func newToken(tokenType token.TokenType, ch byte) token.Token {
 	return token.Token{Type: tokenType, Literal: string(ch)}
 }
 
+// currentRune decodes the UTF-8 character starting at the current byte
+func (l *Lexer) currentRune() rune {
+	if l.ch < utf8.RuneSelf {
+		return rune(l.ch)
+	}
+	r, _ := utf8.DecodeRuneInString(l.input[l.position:])
+	return r
+}
+
 func (l *Lexer) readIdentifier() string {
 	position := l.position
-	for isLetter(l.ch) {
-		l.readChar()
+	for l.ch != 0 && isIdentifierPart(l.currentRune()) {
+		size := utf8.RuneLen(l.currentRune())
+		for i := 0; i < size; i++ {
+			l.readChar()
+		}
 	}
 	return l.input[position:l.position]
 }
@@ -168,18 +403,284 @@ func isLetter(ch byte) bool {
 	return 'a' <= ch && ch <= 'z' || 'A' <= ch && ch <= 'Z' || ch == '_'
 }
 
+// Identifiers may use letters from any script, as in Python, and continue with
+// digits, combining marks and connector punctuation
+func isIdentifierStart(r rune) bool {
+	if r < utf8.RuneSelf {
+		return isLetter(byte(r))
+	}
+	return unicode.In(r, unicode.Letter, unicode.Nl, unicode.Other_ID_Start)
+}
+
+func isIdentifierPart(r rune) bool {
+	if r < utf8.RuneSelf {
+		return isLetter(byte(r)) || isDigit(byte(r))
+	}
+	return isIdentifierStart(r) || unicode.In(r, unicode.Mn, unicode.Mc, unicode.Nd, unicode.Pc, unicode.Other_ID_Continue)
+}
+
 func isDigit(ch byte) bool {
 	return '0' <= ch && ch <= '9'
 }
 
-func (l *Lexer) readString() string {
-	position := l.position + 1
+/*
+String Literals
+*/
+
+// readStringPrefix consumes a raw, bytes or formatted prefix such as r, b, rb or f
+// when it is directly followed by a quote. The legacy u prefix makes a plain string
+func (l *Lexer) readStringPrefix() (int, string, bool) {
+	start := l.position
+
+	n := 0
+	for n < 2 && strings.ContainsRune("rRbBfFuU", rune(l.peekAhead(n))) {
+		n++
+	}
+	if quote := l.peekAhead(n); n == 0 || quote != '"' && quote != '\'' {
+		return 0, "", false
+	}
+
+	prefix := strings.ToLower(l.input[start : start+n])
+	switch prefix {
+	case "r", "b", "f", "rb", "br", "rf", "fr", "u":
+	default:
+		return 0, "", false
+	}
+
+	for i := 0; i < n; i++ {
+		l.readChar()
+	}
+	return start, prefix, true
+}
+
+// readString reads a quoted literal starting at the current quote and leaves the
+// lexer on its closing quote. Escape sequences are decoded unless the literal is raw.
+// The replacement fields of f-strings are left for the parser.
+func (l *Lexer) readString(start int, prefix string) token.Token {
+	raw := strings.Contains(prefix, "r")
+	tok := token.Token{Type: token.STRING}
+	switch {
+	case strings.Contains(prefix, "b"):
+		tok.Type = token.BYTES
+	case strings.Contains(prefix, "f"):
+		tok.Type = token.FSTRING
+	}
+
+	quote := l.ch
+	triple := l.peekAhead(1) == quote && l.peekAhead(2) == quote
+	if triple {
+		l.readChar()
+		l.readChar()
+	}
+
+	var out strings.Builder
+	nonASCII := false
 	for {
+		next := l.peekChar()
+		if next == 0 || next == '\n' && !triple {
+			if triple {
+				l.errorAt(start, "unterminated triple-quoted string literal")
+			} else {
+				l.errorAt(start, "unterminated string literal")
+			}
+			break
+		}
 		l.readChar()
-		if l.ch == '"' || l.ch == 0 {
+
+		if l.ch == quote && (!triple || l.peekAhead(1) == quote && l.peekAhead(2) == quote) {
+			if triple {
+				l.readChar()
+				l.readChar()
+			}
 			break
 		}
+
+		switch {
+		case l.ch == '\\' && raw:
+			// A raw backslash still keeps the next quote or backslash from ending the literal
+			out.WriteByte(l.ch)
+			if next := l.peekChar(); next == quote || next == '\\' {
+				l.readChar()
+				out.WriteByte(l.ch)
+			}
+		case l.ch == '\\':
+			l.readEscape(&out, tok.Type == token.BYTES)
+		case tok.Type == token.BYTES && l.ch >= utf8.RuneSelf:
+			if !nonASCII {
+				l.errorAt(l.position, "bytes can only contain ASCII literal characters")
+			}
+			nonASCII = true
+		default:
+			out.WriteByte(l.ch)
+		}
 	}
 
-	return l.input[position:l.position]
+	tok.Literal = out.String()
+	return tok
+}
+
+// readEscape decodes the escape sequence after the backslash under the lexer.
+// Unknown escapes are kept as written, as in Python.
+func (l *Lexer) readEscape(out *strings.Builder, bytes bool) {
+	position := l.position
+	if l.peekChar() == 0 {
+		return
+	}
+	l.readChar()
+
+	switch l.ch {
+	case '\n':
+		// A backslash at the end of a line continues the literal on the next one
+	case '\\', '\'', '"':
+		out.WriteByte(l.ch)
+	case 'a':
+		out.WriteByte('\a')
+	case 'b':
+		out.WriteByte('\b')
+	case 'f':
+		out.WriteByte('\f')
+	case 'n':
+		out.WriteByte('\n')
+	case 'r':
+		out.WriteByte('\r')
+	case 't':
+		out.WriteByte('\t')
+	case 'v':
+		out.WriteByte('\v')
+	case '0', '1', '2', '3', '4', '5', '6', '7':
+		value := rune(l.ch - '0')
+		for i := 0; i < 2 && isOctal(l.peekChar()); i++ {
+			l.readChar()
+			value = value*8 + rune(l.ch-'0')
+		}
+		writeCodePoint(out, value, bytes)
+	case 'x':
+		if value, ok := l.readHex(2); ok {
+			writeCodePoint(out, value, bytes)
+		} else {
+			l.errorAt(position, "truncated \\xXX escape")
+		}
+	case 'u', 'U':
+		if bytes {
+			out.WriteByte('\\')
+			out.WriteByte(l.ch)
+			return
+		}
+
+		digits, name := 4, "\\uXXXX"
+		if l.ch == 'U' {
+			digits, name = 8, "\\UXXXXXXXX"
+		}
+
+		value, ok := l.readHex(digits)
+		switch {
+		case !ok:
+			l.errorAt(position, "truncated %s escape", name)
+		case value > utf8.MaxRune:
+			l.errorAt(position, "illegal Unicode character")
+		default:
+			out.WriteRune(value)
+		}
+	case 'N':
+		if bytes {
+			out.WriteByte('\\')
+			out.WriteByte(l.ch)
+			return
+		}
+
+		// Decoding needs the Unicode name table, which the standard library lacks
+		if l.peekChar() != '{' {
+			l.errorAt(position, "malformed \\N character escape")
+			return
+		}
+		l.readChar()
+		for isNameChar(l.peekChar()) {
+			l.readChar()
+		}
+		if l.peekChar() != '}' {
+			l.errorAt(position, "malformed \\N character escape")
+			return
+		}
+		l.readChar()
+		l.errorAt(position, "\\N{...} escapes are not supported, use \\u or \\U with the code point")
+	default:
+		out.WriteByte('\\')
+		out.WriteByte(l.ch)
+	}
+}
+
+// readHex consumes exactly n hex digits
+func (l *Lexer) readHex(n int) (rune, bool) {
+	value := rune(0)
+	for i := 0; i < n; i++ {
+		digit, ok := hexValue(l.peekChar())
+		if !ok {
+			return 0, false
+		}
+		l.readChar()
+		value = value*16 + digit
+	}
+	return value, true
+}
+
+// writeCodePoint writes an escaped value as a raw byte in bytes literals and as
+// a UTF-8 encoded code point in strings
+func writeCodePoint(out *strings.Builder, value rune, bytes bool) {
+	if bytes {
+		out.WriteByte(byte(value))
+	} else {
+		out.WriteRune(value)
+	}
+}
+
+// isNameChar reports whether ch can appear in a Unicode character name
+func isNameChar(ch byte) bool {
+	return 'A' <= ch && ch <= 'Z' || 'a' <= ch && ch <= 'z' || isDigit(ch) || ch == ' ' || ch == '-'
+}
+
+func isOctal(ch byte) bool {
+	return '0' <= ch && ch <= '7'
+}
+
+func hexValue(ch byte) (rune, bool) {
+	switch {
+	case isDigit(ch):
+		return rune(ch - '0'), true
+	case 'a' <= ch && ch <= 'f':
+		return rune(ch-'a') + 10, true
+	case 'A' <= ch && ch <= 'F':
+		return rune(ch-'A') + 10, true
+	default:
+		return 0, false
+	}
+}
+
+// column returns the column of the current char, counted in characters from 1
+func (l *Lexer) column() int {
+	end := min(l.position, len(l.input))
+	return utf8.RuneCountInString(l.input[l.lineStart:end]) + 1
+}
+
+// positioned marks tok with the current line and column
+func (l *Lexer) positioned(tok token.Token) token.Token {
+	tok.Line, tok.Column = l.line, l.column()
+	return tok
+}
+
+// errorAt records an error at the line and column of position, both counted from 1
+// indentErrorAt records an indentation error on the current line
+func (l *Lexer) indentErrorAt(position int, msg string) {
+	if l.indentError == 0 {
+		l.indentError = l.line
+	}
+	l.errorAt(position, msg)
+}
+
+func (l *Lexer) errorAt(position int, format string, a ...interface{}) {
+	before := l.input[:position]
+	line := strings.Count(before, "\n") + 1
+	column := utf8.RuneCountInString(before[strings.LastIndex(before, "\n")+1:]) + 1
+
+	msg := fmt.Sprintf(format, a...)
+	l.errors = append(l.errors, fmt.Sprintf("%s at line %d, column %d", msg, line, column))
 }
@@ -34,6 +34,9 @@ while true:
 3.14
 .50
 val in obj
+class Dog(Animal):
+from shapes import area as a
+log10(x2)
 # Comment
 `
 
@@ -41,7 +44,6 @@ val in obj
 		expectedType    token.TokenType
 		expectedLiteral string
 	}{
-		{token.NEWLINE, "\n"},
 		{token.LET, "let"},
 		{token.IDENT, "five"},
 		{token.ASSIGN, "="},
@@ -62,13 +64,10 @@ val in obj
 		{token.IDENT, "y"},
 		{token.RPAREN, ")"},
 		{token.LBRACE, "{"},
-		{token.NEWLINE, "\n"},
-		{token.TAB, "\t"},
 		{token.IDENT, "x"},
 		{token.PLUS, "+"},
 		{token.IDENT, "y"},
 		{token.SEMICOLON, ";"},
-		{token.NEWLINE, "\n"},
 		{token.RBRACE, "}"},
 		{token.SEMICOLON, ";"},
 		{token.NEWLINE, "\n"},
@@ -97,7 +96,6 @@ val in obj
 		{token.INT, "5"},
 		{token.SEMICOLON, ";"},
 		{token.NEWLINE, "\n"},
-		{token.NEWLINE, "\n"},
 		{token.IF, "if"},
 		{token.LPAREN, "("},
 		{token.INT, "5"},
@@ -105,24 +103,17 @@ val in obj
 		{token.INT, "10"},
 		{token.RPAREN, ")"},
 		{token.LBRACE, "{"},
-		{token.NEWLINE, "\n"},
-		{token.TAB, "\t"},
 		{token.RETURN, "return"},
 		{token.TRUE, "true"},
 		{token.SEMICOLON, ";"},
-		{token.NEWLINE, "\n"},
 		{token.RBRACE, "}"},
 		{token.ELSE, "else"},
 		{token.LBRACE, "{"},
-		{token.NEWLINE, "\n"},
-		{token.TAB, "\t"},
 		{token.RETURN, "return"},
 		{token.FALSE, "false"},
 		{token.SEMICOLON, ";"},
-		{token.NEWLINE, "\n"},
 		{token.RBRACE, "}"},
 		{token.NEWLINE, "\n"},
-		{token.NEWLINE, "\n"},
 		{token.INT, "10"},
 		{token.EQ, "=="},
 		{token.INT, "10"},
@@ -172,6 +163,158 @@ val in obj
 		{token.IN, "in"},
 		{token.IDENT, "obj"},
 		{token.NEWLINE, "\n"},
+		{token.CLASS, "class"},
+		{token.IDENT, "Dog"},
+		{token.LPAREN, "("},
+		{token.IDENT, "Animal"},
+		{token.RPAREN, ")"},
+		{token.COLON, ":"},
+		{token.NEWLINE, "\n"},
+		{token.FROM, "from"},
+		{token.IDENT, "shapes"},
+		{token.IMPORT, "import"},
+		{token.IDENT, "area"},
+		{token.AS, "as"},
+		{token.IDENT, "a"},
+		{token.NEWLINE, "\n"},
+		{token.IDENT, "log10"},
+		{token.LPAREN, "("},
+		{token.IDENT, "x2"},
+		{token.RPAREN, ")"},
+		{token.NEWLINE, "\n"},
+		{token.EOF, ""},
+	}
+
+	l := New(input)
+	for i, tt := range tests {
+		tok := l.NextToken()
+
+		if tok.Type != tt.expectedType {
+			t.Fatalf("tests[%d] - tokentype wrong. expected=%q, got=%q",
+				i, tt.expectedType, tok.Type)
+		}
+		if tok.Literal != tt.expectedLiteral {
+			t.Fatalf("tests[%d] - literal wrong. expected=%q, got=%q",
+				i, tt.expectedLiteral, tok.Literal)
+		}
+	}
+}
+
+func TestStringLiterals(t *testing.T) {
+	tests := []struct {
+		input           string
+		expectedType    token.TokenType
+		expectedLiteral string
+	}{
+		{`"hello"`, token.STRING, "hello"},
+		{`'hello'`, token.STRING, "hello"},
+		{`'say "hi"'`, token.STRING, `say "hi"`},
+		{`"it's"`, token.STRING, "it's"},
+		{`""`, token.STRING, ""},
+		{`"a\nb\tc"`, token.STRING, "a\nb\tc"},
+		{`"quote \" and \\ backslash"`, token.STRING, `quote " and \ backslash`},
+		{`'\''`, token.STRING, "'"},
+		{`"\x41\101é\U0001F600"`, token.STRING, "AAé😀"},
+		{`"\0"`, token.STRING, "\x00"},
+		{`"keep \q"`, token.STRING, `keep \q`},
+		{"\"line \\\ncontinued\"", token.STRING, "line continued"},
+		{"\"\"\"first\nsecond\"\"\"", token.STRING, "first\nsecond"},
+		{"'''has \"\"\" inside'''", token.STRING, `has """ inside`},
+		{`"""a "quoted" word"""`, token.STRING, `a "quoted" word`},
+		{`r"\d+\n"`, token.STRING, `\d+\n`},
+		{`R'\''`, token.STRING, `\'`},
+		{`b"abc"`, token.BYTES, "abc"},
+		{`B'\x00\xff'`, token.BYTES, "\x00\xff"},
+		{`rb"\x00"`, token.BYTES, `\x00`},
+		{`Br"\n"`, token.BYTES, `\n`},
+		{`f"a{b}\t"`, token.FSTRING, "a{b}\t"},
+		{`rf'{x}\n'`, token.FSTRING, `{x}\n`},
+		{`u"caf\xe9"`, token.STRING, "café"},
+		{`U'x'`, token.STRING, "x"},
+		{`b"\N{DASH}"`, token.BYTES, `\N{DASH}`},
+	}
+
+	for i, tt := range tests {
+		l := New(tt.input)
+		tok := l.NextToken()
+
+		if tok.Type != tt.expectedType {
+			t.Errorf("tests[%d] - tokentype wrong. expected=%q, got=%q",
+				i, tt.expectedType, tok.Type)
+		}
+		if tok.Literal != tt.expectedLiteral {
+			t.Errorf("tests[%d] - literal wrong. expected=%q, got=%q",
+				i, tt.expectedLiteral, tok.Literal)
+		}
+		if len(l.Errors()) != 0 {
+			t.Errorf("tests[%d] - unexpected errors: %v", i, l.Errors())
+		}
+		if next := l.NextToken(); next.Type != token.EOF {
+			t.Errorf("tests[%d] - literal not fully consumed. next=%q", i, next.Literal)
+		}
+	}
+}
+
+func TestStringPrefixesAreIdentifiers(t *testing.T) {
+	l := New(`rb b r f bf u ur = "x"`)
+
+	for _, expected := range []string{"rb", "b", "r", "f", "bf", "u", "ur"} {
+		tok := l.NextToken()
+		if tok.Type != token.IDENT || tok.Literal != expected {
+			t.Fatalf("expected identifier %q. got=%q (%q)", expected, tok.Type, tok.Literal)
+		}
+	}
+}
+
+func TestStringErrors(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{`x = "abc`, "unterminated string literal at line 1, column 5"},
+		{"x = 1\ny = 'abc\nz = 2", "unterminated string literal at line 2, column 5"},
+		{"\"\"\"never\nclosed", "unterminated triple-quoted string literal at line 1, column 1"},
+		{`r"\"`, "unterminated string literal at line 1, column 1"},
+		{`"\x4"`, `truncated \xXX escape at line 1, column 2`},
+		{`"\u12"`, `truncated \uXXXX escape at line 1, column 2`},
+		{`"\U00110000"`, "illegal Unicode character at line 1, column 2"},
+		{`b"é"`, "bytes can only contain ASCII literal characters at line 1, column 3"},
+		{`"\N{LATIN SMALL LETTER E WITH ACUTE}"`, `\N{...} escapes are not supported, use \u or \U with the code point at line 1, column 2`},
+		{`"\N{oops"`, `malformed \N character escape at line 1, column 2`},
+		{`"a\N"`, `malformed \N character escape at line 1, column 3`},
+	}
+
+	for _, tt := range tests {
+		l := New(tt.input)
+		for tok := l.NextToken(); tok.Type != token.EOF; tok = l.NextToken() {
+		}
+
+		errors := l.Errors()
+		if len(errors) != 1 {
+			t.Errorf("%q: expected 1 error. got=%v", tt.input, errors)
+			continue
+		}
+		if errors[0] != tt.expected {
+			t.Errorf("%q: wrong error. expected=%q, got=%q", tt.input, tt.expected, errors[0])
+		}
+	}
+}
+
+func TestUnicodeIdentifiers(t *testing.T) {
+	input := "café = naïve_2 + π\nx€"
+
+	tests := []struct {
+		expectedType    token.TokenType
+		expectedLiteral string
+	}{
+		{token.IDENT, "café"},
+		{token.ASSIGN, "="},
+		{token.IDENT, "naïve_2"},
+		{token.PLUS, "+"},
+		{token.IDENT, "π"},
+		{token.NEWLINE, "\n"},
+		{token.IDENT, "x"},
+		{token.ILLEGAL, "€"},
 		{token.EOF, ""},
 	}
 
@@ -189,3 +332,216 @@ val in obj
 		}
 	}
 }
+
+func TestComparisonAndBitwiseOperators(t *testing.T) {
+	input := "a <= b >= c < d | e & f ^ g"
+
+	expected := []token.TokenType{
+		token.IDENT, token.LT_EQ, token.IDENT, token.GT_EQ, token.IDENT, token.LT,
+		token.IDENT, token.PIPE, token.IDENT, token.AMP, token.IDENT, token.CARET,
+		token.IDENT, token.EOF,
+	}
+
+	l := New(input)
+	for i, tt := range expected {
+		tok := l.NextToken()
+		if tok.Type != tt {
+			t.Fatalf("tests[%d] - tokentype wrong. expected=%q, got=%q", i, tt, tok.Type)
+		}
+	}
+}
+
+func TestIndentation(t *testing.T) {
+	input := "if a:\n  b = '    '\n\n  # comment\n  while c:\n\td\n  e\nf\n"
+
+	tests := []struct {
+		expectedType    token.TokenType
+		expectedLiteral string
+	}{
+		{token.IF, "if"},
+		{token.IDENT, "a"},
+		{token.COLON, ":"},
+		{token.NEWLINE, "\n"},
+		{token.INDENT, "  "},
+		{token.IDENT, "b"},
+		{token.ASSIGN, "="},
+		{token.STRING, "    "},
+		{token.NEWLINE, "\n"},
+		{token.WHILE, "while"},
+		{token.IDENT, "c"},
+		{token.COLON, ":"},
+		{token.NEWLINE, "\n"},
+	}
+
+	l := New(input)
+	for i, tt := range tests {
+		tok := l.NextToken()
+		if tok.Type != tt.expectedType || tok.Literal != tt.expectedLiteral {
+			t.Fatalf("tests[%d] - token wrong. expected=%q (%q), got=%q (%q)",
+				i, tt.expectedType, tt.expectedLiteral, tok.Type, tok.Literal)
+		}
+	}
+
+	for tok := l.NextToken(); tok.Type != token.EOF; tok = l.NextToken() {
+	}
+
+	// "\td" is neither a continuation of "  " nor an outer level
+	if len(l.Errors()) != 1 || l.Errors()[0] != "inconsistent use of tabs and spaces in indentation at line 6, column 1" {
+		t.Errorf("wrong errors for mixed indentation: %v", l.Errors())
+	}
+}
+
+func TestIndentAndDedentTokens(t *testing.T) {
+	input := "a\n    b\n        c\n\n    d\ne\n    f"
+
+	expected := []token.TokenType{
+		token.IDENT, token.NEWLINE,
+		token.INDENT, token.IDENT, token.NEWLINE,
+		token.INDENT, token.IDENT, token.NEWLINE,
+		token.DEDENT, token.IDENT, token.NEWLINE,
+		token.DEDENT, token.IDENT, token.NEWLINE,
+		token.INDENT, token.IDENT,
+		token.DEDENT, token.EOF,
+	}
+
+	l := New(input)
+	for i, tt := range expected {
+		tok := l.NextToken()
+		if tok.Type != tt {
+			t.Fatalf("tests[%d] - tokentype wrong. expected=%q, got=%q", i, tt, tok.Type)
+		}
+	}
+	if len(l.Errors()) != 0 {
+		t.Errorf("unexpected errors: %v", l.Errors())
+	}
+}
+
+func TestIndentationErrors(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{"if a:\n    b\n  c", "inconsistent dedent: unindent does not match any outer indentation level at line 3, column 1"},
+		{"if a:\n    b\n\tc", "inconsistent use of tabs and spaces in indentation at line 3, column 1"},
+		{"if a:\n\tif b:\n\t    c\n\t  d", "inconsistent dedent: unindent does not match any outer indentation level at line 4, column 1"},
+	}
+
+	for _, tt := range tests {
+		l := New(tt.input)
+		for tok := l.NextToken(); tok.Type != token.EOF; tok = l.NextToken() {
+		}
+
+		errors := l.Errors()
+		if len(errors) != 1 || errors[0] != tt.expected {
+			t.Errorf("%q: wrong errors. expected=%q, got=%v", tt.input, tt.expected, errors)
+		}
+	}
+}
+
+func TestLineJoining(t *testing.T) {
+	input := "x = {\n    'a': [1,\n  2],  # note\n\n}\ny = 1 + \\\n        2\nf(a,\n\tb)\n"
+
+	expected := []token.TokenType{
+		token.IDENT, token.ASSIGN, token.LBRACE, token.STRING, token.COLON,
+		token.LBRACKET, token.INT, token.COMMA, token.INT, token.RBRACKET, token.COMMA,
+		token.RBRACE, token.NEWLINE,
+		token.IDENT, token.ASSIGN, token.INT, token.PLUS, token.INT, token.NEWLINE,
+		token.IDENT, token.LPAREN, token.IDENT, token.COMMA, token.IDENT, token.RPAREN,
+		token.NEWLINE, token.EOF,
+	}
+
+	l := New(input)
+	for i, tt := range expected {
+		tok := l.NextToken()
+		if tok.Type != tt {
+			t.Fatalf("tests[%d] - tokentype wrong. expected=%q, got=%q", i, tt, tok.Type)
+		}
+	}
+	if len(l.Errors()) != 0 {
+		t.Errorf("unexpected errors: %v", l.Errors())
+	}
+}
+
+func TestLineContinuationErrors(t *testing.T) {
+	l := New("x = 1 \\ 2")
+	for tok := l.NextToken(); tok.Type != token.EOF; tok = l.NextToken() {
+	}
+
+	expected := "unexpected character after line continuation character at line 1, column 7"
+	if errors := l.Errors(); len(errors) != 1 || errors[0] != expected {
+		t.Errorf("wrong errors. expected=%q, got=%v", expected, errors)
+	}
+}
+
+func TestTokenPositions(t *testing.T) {
+	input := "x = 1\nif x:\n    s = 'é' + \"\"\"a\nb\"\"\"\n    y"
+
+	tests := []struct {
+		expectedType   token.TokenType
+		expectedLine   int
+		expectedColumn int
+	}{
+		{token.IDENT, 1, 1},
+		{token.ASSIGN, 1, 3},
+		{token.INT, 1, 5},
+		{token.NEWLINE, 1, 6},
+		{token.IF, 2, 1},
+		{token.IDENT, 2, 4},
+		{token.COLON, 2, 5},
+		{token.NEWLINE, 2, 6},
+		{token.INDENT, 3, 5},
+		{token.IDENT, 3, 5},
+		{token.ASSIGN, 3, 7},
+		{token.STRING, 3, 9},
+		{token.PLUS, 3, 13},
+		{token.STRING, 3, 15},
+		{token.NEWLINE, 4, 5},
+		{token.IDENT, 5, 5},
+		{token.DEDENT, 5, 6},
+		{token.EOF, 5, 6},
+		{token.EOF, 5, 6},
+	}
+
+	l := New(input)
+	for i, tt := range tests {
+		tok := l.NextToken()
+		if tok.Type != tt.expectedType || tok.Line != tt.expectedLine || tok.Column != tt.expectedColumn {
+			t.Fatalf("tests[%d] - token wrong. expected=%q at %d:%d, got=%q at %d:%d", i,
+				tt.expectedType, tt.expectedLine, tt.expectedColumn, tok.Type, tok.Line, tok.Column)
+		}
+	}
+}
+
+var fuzzSeeds = []string{
+	"let x = 5;\nx + 10\n",
+	"def f(a, b):\n    return a * b\n",
+	"if x:\n\ty = 1\n  z = 2\n",
+	"s = f\"{x!r:>10}\" + '''doc\nstring''' + b'\\x00'\n",
+	"xs = [1,\n  2, \\\n 3]\n",
+	"\\",
+	"((]",
+	"\"unterminated",
+}
+
+// FuzzNextToken checks that the lexer terminates on any input without panicking
+func FuzzNextToken(f *testing.F) {
+	for _, seed := range fuzzSeeds {
+		f.Add(seed)
+	}
+
+	f.Fuzz(func(t *testing.T, input string) {
+		l := New(input)
+
+		// Every token consumes input, apart from INDENT, DEDENT and NEWLINE
+		// tokens, which are bounded by the number of characters too
+		limit := 4*len(input) + 8
+		for i := 0; ; i++ {
+			if i > limit {
+				t.Fatalf("lexer did not reach EOF after %d tokens", limit)
+			}
+			if l.NextToken().Type == token.EOF {
+				break
+			}
+		}
+	})
+}
@@ -12,7 +12,10 @@ func main() {
 	flag.Parse()
 
 	if *file != "" {
-		repl.StartInterpreter(*file)
+		if err := repl.StartInterpreter(*file); err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			os.Exit(1)
+		}
 	} else {
 		fmt.Print("Welcome to the Simpyl programming language!\n")
 		repl.StartInteractive(os.Stdin, os.Stdout)
@@ -0,0 +1,446 @@
+package native
+
+import (
+	"fmt"
+	"math"
+	"math/big"
+	"simpyl/object"
+	"sort"
+)
+
+// The math module mirrors CPython: functions that return integral values, such as
+// floor and factorial, return integers, and everything else returns floats.
+func init() {
+	MustRegister("math", Members{
+		"pi":  &object.Float{Value: math.Pi},
+		"e":   &object.Float{Value: math.E},
+		"tau": &object.Float{Value: 2 * math.Pi},
+		"inf": &object.Float{Value: math.Inf(1)},
+		"nan": &object.Float{Value: math.NaN()},
+
+		"sqrt":    unaryMath(math.Sqrt),
+		"exp":     unaryMath(math.Exp),
+		"log":     &object.Builtin{Fn: mathLog},
+		"log2":    unaryMath(logarithm(math.Log2)),
+		"log10":   unaryMath(logarithm(math.Log10)),
+		"sin":     unaryMath(math.Sin),
+		"cos":     unaryMath(math.Cos),
+		"tan":     unaryMath(math.Tan),
+		"asin":    unaryMath(math.Asin),
+		"acos":    unaryMath(math.Acos),
+		"atan":    unaryMath(math.Atan),
+		"sinh":    unaryMath(math.Sinh),
+		"cosh":    unaryMath(math.Cosh),
+		"tanh":    unaryMath(math.Tanh),
+		"fabs":    unaryMath(math.Abs),
+		"degrees": unaryMath(func(x float64) float64 { return x * 180 / math.Pi }),
+		"radians": unaryMath(func(x float64) float64 { return x * math.Pi / 180 }),
+		"atan2":   binaryMath(math.Atan2),
+		"pow":     binaryMath(power),
+		"hypot":   &object.Builtin{Fn: mathHypot},
+
+		"floor": integralMath(math.Floor),
+		"ceil":  integralMath(math.Ceil),
+		"trunc": integralMath(math.Trunc),
+
+		"gcd":       &object.Builtin{Fn: mathGcd},
+		"factorial": &object.Builtin{Fn: mathFactorial},
+		"comb":      &object.Builtin{Fn: mathComb},
+		"perm":      &object.Builtin{Fn: mathPerm},
+
+		"isclose":  &object.Builtin{KwFn: mathIsclose},
+		"isnan":    predicateMath(math.IsNaN),
+		"isinf":    predicateMath(func(x float64) bool { return math.IsInf(x, 0) }),
+		"isfinite": predicateMath(func(x float64) bool { return !math.IsNaN(x) && !math.IsInf(x, 0) }),
+	})
+}
+
+/*
+Float Functions
+*/
+func unaryMath(fn func(float64) float64) *object.Builtin {
+	return &object.Builtin{
+		Fn: func(args ...object.Object) object.Object {
+			if len(args) != 1 {
+				return newError("wrong number of arguments. got=%d, want=1", len(args))
+			}
+			x, err := toFloat(args[0])
+			if err != nil {
+				return err
+			}
+			return checkedFloat(fn(x), x)
+		},
+	}
+}
+
+func binaryMath(fn func(float64, float64) float64) *object.Builtin {
+	return &object.Builtin{
+		Fn: func(args ...object.Object) object.Object {
+			if len(args) != 2 {
+				return newError("wrong number of arguments. got=%d, want=2", len(args))
+			}
+			x, err := toFloat(args[0])
+			if err != nil {
+				return err
+			}
+			y, err := toFloat(args[1])
+			if err != nil {
+				return err
+			}
+			return checkedFloat(fn(x, y), x, y)
+		},
+	}
+}
+
+// checkedFloat reports results that CPython rejects: a NaN produced from numbers
+// is outside the domain of the function, and an infinity produced from finite
+// inputs has overflowed
+func checkedFloat(result float64, inputs ...float64) object.Object {
+	for _, x := range inputs {
+		if math.IsNaN(x) {
+			return &object.Float{Value: result}
+		}
+	}
+	if math.IsNaN(result) {
+		return newError("math domain error")
+	}
+
+	if math.IsInf(result, 0) {
+		for _, x := range inputs {
+			if math.IsInf(x, 0) {
+				return &object.Float{Value: result}
+			}
+		}
+		return newError("math range error")
+	}
+
+	return &object.Float{Value: result}
+}
+
+// logarithm rejects non-positive arguments, which Go maps to -Inf or NaN
+func logarithm(fn func(float64) float64) func(float64) float64 {
+	return func(x float64) float64 {
+		if x <= 0 {
+			return math.NaN()
+		}
+		return fn(x)
+	}
+}
+
+func power(x, y float64) float64 {
+	if x == 0 && y < 0 {
+		return math.NaN()
+	}
+	return math.Pow(x, y)
+}
+
+func mathLog(args ...object.Object) object.Object {
+	if len(args) != 1 && len(args) != 2 {
+		return newError("wrong number of arguments. got=%d, want=1 or 2", len(args))
+	}
+
+	x, err := toFloat(args[0])
+	if err != nil {
+		return err
+	}
+	result := logarithm(math.Log)(x)
+
+	if len(args) == 2 {
+		base, err := toFloat(args[1])
+		if err != nil {
+			return err
+		}
+		if base == 1 {
+			return newError("float division by zero")
+		}
+		result /= logarithm(math.Log)(base)
+		return checkedFloat(result, x, base)
+	}
+
+	return checkedFloat(result, x)
+}
+
+func mathHypot(args ...object.Object) object.Object {
+	result := 0.0
+	inputs := make([]float64, len(args))
+
+	for i, arg := range args {
+		x, err := toFloat(arg)
+		if err != nil {
+			return err
+		}
+		inputs[i] = x
+		result = math.Hypot(result, x)
+	}
+
+	return checkedFloat(result, inputs...)
+}
+
+/*
+Integer Functions
+*/
+func integralMath(fn func(float64) float64) *object.Builtin {
+	return &object.Builtin{
+		Fn: func(args ...object.Object) object.Object {
+			if len(args) != 1 {
+				return newError("wrong number of arguments. got=%d, want=1", len(args))
+			}
+
+			// Integers are already integral and may not survive a round trip through float64
+			if n, err := toInt(args[0]); err == nil {
+				return &object.Integer{Value: n}
+			}
+
+			x, err := toFloat(args[0])
+			if err != nil {
+				return err
+			}
+			return floatToInteger(fn(x))
+		},
+	}
+}
+
+func floatToInteger(x float64) object.Object {
+	switch {
+	case math.IsNaN(x):
+		return newError("cannot convert float NaN to integer")
+	case math.IsInf(x, 0):
+		return newError("cannot convert float infinity to integer")
+	case x < math.MinInt64 || x >= math.MaxInt64:
+		return newError("integer overflow: %g does not fit in 64 bits", x)
+	}
+	return &object.Integer{Value: int64(x)}
+}
+
+func mathGcd(args ...object.Object) object.Object {
+	result := int64(0)
+
+	for _, arg := range args {
+		n, err := toInt(arg)
+		if err != nil {
+			return err
+		}
+		if n < 0 {
+			n = -n
+		}
+		for n != 0 {
+			result, n = n, result%n
+		}
+	}
+
+	return &object.Integer{Value: result}
+}
+
+func mathFactorial(args ...object.Object) object.Object {
+	if len(args) != 1 {
+		return newError("wrong number of arguments. got=%d, want=1", len(args))
+	}
+
+	n, err := toInt(args[0])
+	if err != nil {
+		return err
+	}
+	if n < 0 {
+		return newError("factorial() not defined for negative values")
+	}
+	if n > maxFactorial {
+		return overflowError()
+	}
+
+	return bigToInteger(new(big.Int).MulRange(1, n))
+}
+
+func mathComb(args ...object.Object) object.Object {
+	if len(args) != 2 {
+		return newError("wrong number of arguments. got=%d, want=2", len(args))
+	}
+
+	n, k, err := combinatoricArgs(args[0], args[1])
+	if err != nil {
+		return err
+	}
+	if k > n {
+		return &object.Integer{Value: 0}
+	}
+	// comb(n, k) is at least 2**k for k up to n/2, so it overflows for k of 63 or more
+	if min(k, n-k) >= 63 {
+		return overflowError()
+	}
+
+	return bigToInteger(new(big.Int).Binomial(n, k))
+}
+
+func mathPerm(args ...object.Object) object.Object {
+	if len(args) != 1 && len(args) != 2 {
+		return newError("wrong number of arguments. got=%d, want=1 or 2", len(args))
+	}
+	if len(args) == 1 {
+		return mathFactorial(args...)
+	}
+
+	n, k, err := combinatoricArgs(args[0], args[1])
+	if err != nil {
+		return err
+	}
+	if k > n {
+		return &object.Integer{Value: 0}
+	}
+	// perm(n, k) is a multiple of k!, so it overflows whenever k! does
+	if k > maxFactorial {
+		return overflowError()
+	}
+
+	return bigToInteger(new(big.Int).MulRange(n-k+1, n))
+}
+
+func combinatoricArgs(nArg, kArg object.Object) (int64, int64, *object.Error) {
+	n, err := toInt(nArg)
+	if err != nil {
+		return 0, 0, err
+	}
+	k, err := toInt(kArg)
+	if err != nil {
+		return 0, 0, err
+	}
+
+	if n < 0 {
+		return 0, 0, newError("n must be a non-negative integer")
+	}
+	if k < 0 {
+		return 0, 0, newError("k must be a non-negative integer")
+	}
+
+	return n, k, nil
+}
+
+// maxFactorial is the largest n whose factorial fits in 64 bits. Larger arguments
+// are rejected before anything is multiplied, since their products can be huge
+const maxFactorial = 20
+
+// bigToInteger narrows exact results to the interpreter's 64 bit integers
+func bigToInteger(n *big.Int) object.Object {
+	if !n.IsInt64() {
+		return overflowError()
+	}
+	return &object.Integer{Value: n.Int64()}
+}
+
+func overflowError() *object.Error {
+	return newError("integer overflow: result does not fit in 64 bits")
+}
+
+/*
+Predicates
+*/
+func predicateMath(fn func(float64) bool) *object.Builtin {
+	return &object.Builtin{
+		Fn: func(args ...object.Object) object.Object {
+			if len(args) != 1 {
+				return newError("wrong number of arguments. got=%d, want=1", len(args))
+			}
+			x, err := toFloat(args[0])
+			if err != nil {
+				return err
+			}
+			return nativeBool(fn(x))
+		},
+	}
+}
+
+// mathIsclose follows CPython's isclose(a, b, *, rel_tol=1e-09, abs_tol=0.0)
+func mathIsclose(kwargs map[string]object.Object, args ...object.Object) object.Object {
+	if len(args) != 2 {
+		return newError("wrong number of arguments. got=%d, want=2", len(args))
+	}
+
+	a, err := toFloat(args[0])
+	if err != nil {
+		return err
+	}
+	b, err := toFloat(args[1])
+	if err != nil {
+		return err
+	}
+
+	relTol, absTol := 1e-09, 0.0
+	names := make([]string, 0, len(kwargs))
+	for name := range kwargs {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	for _, name := range names {
+		val, err := toFloat(kwargs[name])
+		if err != nil {
+			return err
+		}
+
+		switch name {
+		case "rel_tol":
+			relTol = val
+		case "abs_tol":
+			absTol = val
+		default:
+			return newError("isclose() got an unexpected keyword argument '%s'", name)
+		}
+	}
+
+	if relTol < 0 || absTol < 0 {
+		return newError("tolerances must be non-negative")
+	}
+
+	if a == b {
+		return object.True
+	}
+	if math.IsInf(a, 0) || math.IsInf(b, 0) {
+		return object.False
+	}
+
+	diff := math.Abs(b - a)
+	isClose := diff <= math.Abs(relTol*b) || diff <= math.Abs(relTol*a) || diff <= absTol
+	return nativeBool(isClose)
+}
+
+/*
+Conversions
+*/
+func nativeBool(b bool) *object.Boolean {
+	if b {
+		return object.True
+	}
+	return object.False
+}
+
+func toFloat(obj object.Object) (float64, *object.Error) {
+	switch obj := obj.(type) {
+	case *object.Float:
+		return obj.Value, nil
+	case *object.Integer:
+		return float64(obj.Value), nil
+	case *object.Boolean:
+		if obj.Value {
+			return 1, nil
+		}
+		return 0, nil
+	default:
+		return 0, newError("must be real number, not %s", obj.Type())
+	}
+}
+
+func toInt(obj object.Object) (int64, *object.Error) {
+	switch obj := obj.(type) {
+	case *object.Integer:
+		return obj.Value, nil
+	case *object.Boolean:
+		if obj.Value {
+			return 1, nil
+		}
+		return 0, nil
+	default:
+		return 0, newError("%s object cannot be interpreted as an integer", obj.Type())
+	}
+}
+
+func newError(format string, a ...interface{}) *object.Error {
+	return &object.Error{Message: fmt.Sprintf(format, a...)}
+}
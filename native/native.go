@@ -0,0 +1,72 @@
+// Package native holds the registry of modules implemented in Go. Scripts load
+// registered modules with import, before any .py file of the same name is searched.
+package native
+
+import (
+	"fmt"
+	"simpyl/object"
+	"sort"
+	"sync"
+)
+
+// Members maps the names a native module exports to their values, usually
+// *object.Builtin functions and constants
+type Members map[string]object.Object
+
+var (
+	mu       sync.RWMutex
+	registry = map[string]Members{}
+)
+
+// Register makes a module importable under name. Each name may only be registered once.
+func Register(name string, members Members) error {
+	if name == "" {
+		return fmt.Errorf("native module name must not be empty")
+	}
+
+	mu.Lock()
+	defer mu.Unlock()
+
+	if _, ok := registry[name]; ok {
+		return fmt.Errorf("native module %q is already registered", name)
+	}
+
+	copied := make(Members, len(members))
+	for key, val := range members {
+		copied[key] = val
+	}
+	registry[name] = copied
+
+	return nil
+}
+
+// MustRegister is like Register but panics if the module cannot be registered.
+// It is intended for use in package init functions.
+func MustRegister(name string, members Members) {
+	if err := Register(name, members); err != nil {
+		panic(err)
+	}
+}
+
+// Lookup returns the members of a registered module
+func Lookup(name string) (Members, bool) {
+	mu.RLock()
+	defer mu.RUnlock()
+
+	members, ok := registry[name]
+	return members, ok
+}
+
+// Names returns the names of all registered modules in sorted order
+func Names() []string {
+	mu.RLock()
+	defer mu.RUnlock()
+
+	names := make([]string, 0, len(registry))
+	for name := range registry {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	return names
+}
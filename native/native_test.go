@@ -0,0 +1,56 @@
+package native
+
+import (
+	"simpyl/object"
+	"testing"
+)
+
+func TestRegister(t *testing.T) {
+	members := Members{
+		"answer": &object.Integer{Value: 42},
+	}
+	if err := Register("testmod", members); err != nil {
+		t.Fatalf("Register returned error: %s", err)
+	}
+
+	// Later changes to the caller's map must not leak into the registry
+	members["extra"] = &object.Integer{Value: 1}
+
+	got, ok := Lookup("testmod")
+	if !ok {
+		t.Fatalf("module testmod was not registered")
+	}
+	if len(got) != 1 {
+		t.Fatalf("module has wrong number of members. got=%d", len(got))
+	}
+	if got["answer"].(*object.Integer).Value != 42 {
+		t.Errorf("member answer has wrong value. got=%s", got["answer"].Inspect())
+	}
+
+	if _, ok := Lookup("missing"); ok {
+		t.Errorf("Lookup found unregistered module")
+	}
+}
+
+func TestRegisterErrors(t *testing.T) {
+	if err := Register("", Members{}); err == nil {
+		t.Errorf("expected error registering empty name")
+	}
+
+	MustRegister("twice", Members{})
+	if err := Register("twice", Members{}); err == nil {
+		t.Errorf("expected error registering duplicate module")
+	}
+}
+
+func TestNames(t *testing.T) {
+	MustRegister("zeta", Members{})
+	MustRegister("alpha", Members{})
+
+	names := Names()
+	for i := 1; i < len(names); i++ {
+		if names[i-1] > names[i] {
+			t.Fatalf("names not sorted. got=%v", names)
+		}
+	}
+}
@@ -1,5 +1,10 @@
 package object
 
+import (
+	"fmt"
+	"sort"
+)
+
 func NewEnclosedEnvironment(outer *Environment) *Environment {
 	env := NewEnvironment()
 	env.outer = outer
@@ -10,9 +15,55 @@ func NewEnvironment() *Environment {
 	return &Environment{store: s, outer: nil}
 }
 
+// Environment binds names to objects. It is not safe for concurrent use: a live
+// environment is used by one goroutine at a time, or by threads that take turns
+// under their runtime's interpreter lock, and only frozen environments, which
+// cannot change, are shared between interpreters running in parallel
 type Environment struct {
-	store map[string]Object
-	outer *Environment
+	store   map[string]Object
+	outer   *Environment
+	runtime *Runtime
+	frozen  bool
+}
+
+// Runtime returns the interpreter state this environment runs in, which is
+// inherited from the enclosing environments. It is nil when none was set
+func (e *Environment) Runtime() *Runtime {
+	for env := e; env != nil; env = env.outer {
+		if env.runtime != nil {
+			return env.runtime
+		}
+	}
+	return nil
+}
+
+// SetRuntime sets the interpreter state for this environment and for the
+// environments enclosed by it. A frozen environment has no runtime, and setting
+// one has no effect
+func (e *Environment) SetRuntime(runtime *Runtime) {
+	if !e.frozen {
+		e.runtime = runtime
+	}
+}
+
+// Freeze makes the environment immutable, so that it can be shared between
+// interpreters running in parallel. Modules bound in it are frozen too. Names
+// can no longer be bound or deleted, although the objects they refer to can
+// still change, and the environment drops its runtime, so functions defined in
+// it run in the runtime of whichever script calls them
+func (e *Environment) Freeze() {
+	e.frozen = true
+	e.runtime = nil
+	for _, val := range e.store {
+		if module, ok := val.(*Module); ok && !module.Env.Frozen() {
+			module.Env.Freeze()
+		}
+	}
+}
+
+// Frozen reports whether Freeze was called
+func (e *Environment) Frozen() bool {
+	return e.frozen
 }
 
 func (e *Environment) Get(name string) (Object, bool) {
@@ -22,7 +73,50 @@ func (e *Environment) Get(name string) (Object, bool) {
 	}
 	return obj, ok
 }
+
+// Set binds name in this environment. It returns val, or an *Error when the
+// environment is frozen
 func (e *Environment) Set(name string, val Object) Object {
+	if e.frozen {
+		return &Error{Message: fmt.Sprintf("cannot assign to '%s': the environment is frozen", name)}
+	}
 	e.store[name] = val
 	return val
 }
+
+// Delete unbinds a name in this environment, reporting whether it was bound.
+// Nothing is deleted from a frozen environment
+func (e *Environment) Delete(name string) bool {
+	_, ok := e.store[name]
+	if ok && !e.frozen {
+		delete(e.store, name)
+	}
+	return ok && !e.frozen
+}
+
+// Locals returns the names bound directly in this environment
+func (e *Environment) Locals() map[string]Object {
+	locals := make(map[string]Object, len(e.store))
+	for name, val := range e.store {
+		locals[name] = val
+	}
+	return locals
+}
+
+// Names returns every name visible from this environment, including those bound
+// in enclosing scopes
+func (e *Environment) Names() []string {
+	seen := make(map[string]bool)
+	for env := e; env != nil; env = env.outer {
+		for name := range env.store {
+			seen[name] = true
+		}
+	}
+
+	names := make([]string, 0, len(seen))
+	for name := range seen {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
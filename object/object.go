@@ -2,11 +2,16 @@ package object
 
 import (
 	"bytes"
+	"cmp"
 	"fmt"
 	"hash/fnv"
 	"math"
 	"simpyl/ast"
+	"sort"
+	"strconv"
 	"strings"
+	"sync/atomic"
+	"unicode/utf8"
 )
 
 type ObjectType string
@@ -21,6 +26,7 @@ const (
 	FLOAT_OBJ        = "FLOAT"
 	BOOLEAN_OBJ      = "BOOLEAN"
 	STRING_OBJ       = "STRING"
+	BYTES_OBJ        = "BYTES"
 	NULL_OBJ         = "NULL"
 	RETURN_VALUE_OBJ = "RETURN_VALUE"
 	ERROR_OBJ        = "ERROR"
@@ -30,6 +36,15 @@ const (
 	LIST_OBJ         = "LIST"
 	DICT_OBJ         = "DICT"
 	SET_OBJ          = "SET"
+	FROZENSET_OBJ    = "FROZENSET"
+	TUPLE_OBJ        = "TUPLE"
+	SLICE_OBJ        = "SLICE"
+	CLASS_OBJ        = "CLASS"
+	INSTANCE_OBJ     = "INSTANCE"
+	BOUND_METHOD_OBJ = "BOUND_METHOD"
+	SUPER_OBJ        = "SUPER"
+	MODULE_OBJ       = "MODULE"
+	HOST_OBJ         = "HOST"
 )
 
 /*
@@ -49,6 +64,8 @@ func (i *Integer) Numeric() bool    { return true }
 func (i *Integer) Inspect() string  { return fmt.Sprintf("%d", i.Value) }
 func (i *Integer) Type() ObjectType { return INTEGER_OBJ }
 
+// HashKey is shared by all equal numbers, so that 1, 1.0 and true are the same
+// dict key as in Python
 func (i *Integer) HashKey() HashKey {
 	return HashKey{Type: i.Type(), Value: uint64(i.Value)}
 }
@@ -58,20 +75,62 @@ type Float struct {
 }
 
 func (f *Float) Numeric() bool    { return true }
-func (f *Float) Inspect() string  { return fmt.Sprintf("%f", f.Value) }
+func (f *Float) Inspect() string  { return FloatRepr(f.Value) }
 func (f *Float) Type() ObjectType { return FLOAT_OBJ }
 
+// HashKey hashes whole floats like the integer they equal and other floats by
+// their bits, so that only equal numbers share a hash
 func (f *Float) HashKey() HashKey {
-	return HashKey{Type: f.Type(), Value: uint64(math.Round(f.Value))}
+	if f.Value == math.Trunc(f.Value) && math.Abs(f.Value) < math.MaxInt64 {
+		return HashKey{Type: INTEGER_OBJ, Value: uint64(int64(f.Value))}
+	}
+	return HashKey{Type: f.Type(), Value: math.Float64bits(f.Value)}
+}
+
+// FloatRepr formats a float like Python's repr: the shortest digits that round
+// trip, switching to exponent notation for very large or small magnitudes
+func FloatRepr(value float64) string {
+	switch {
+	case math.IsNaN(value):
+		return "nan"
+	case math.IsInf(value, 1):
+		return "inf"
+	case math.IsInf(value, -1):
+		return "-inf"
+	}
+
+	sci := strconv.FormatFloat(value, 'e', -1, 64)
+	mantissa, exp, _ := strings.Cut(sci, "e")
+	decpt, _ := strconv.Atoi(exp)
+	decpt++
+
+	if decpt > -4 && decpt <= 16 {
+		str := strconv.FormatFloat(value, 'f', -1, 64)
+		if !strings.Contains(str, ".") {
+			str += ".0"
+		}
+		return str
+	}
+
+	// Python writes at least two exponent digits and no ".0" for integral mantissas
+	return mantissa + "e" + exp[:1] + fmt.Sprintf("%02s", strings.TrimLeft(exp[1:], "0"))
 }
 
 type Boolean struct {
 	Value bool
 }
 
+// True and False are the only booleans scripts should see. The evaluator compares
+// booleans by identity, so packages outside it return these instead of new ones
+var (
+	True  = &Boolean{Value: true}
+	False = &Boolean{Value: false}
+)
+
 func (b *Boolean) Type() ObjectType { return BOOLEAN_OBJ }
 func (b *Boolean) Inspect() string  { return fmt.Sprintf("%t", b.Value) }
 
+// HashKey hashes booleans like the integers 0 and 1, which they equal
 func (b *Boolean) HashKey() HashKey {
 	var value uint64
 	if b.Value {
@@ -79,22 +138,107 @@ func (b *Boolean) HashKey() HashKey {
 	} else {
 		value = 0
 	}
-	return HashKey{Type: b.Type(), Value: value}
+	return HashKey{Type: INTEGER_OBJ, Value: value}
 }
 
 type String struct {
 	Value string
+	runes atomic.Pointer[runeIndex] // Built by the first Len or Char
 }
 
 func (s *String) Type() ObjectType { return STRING_OBJ }
 func (s *String) Inspect() string  { return s.Value }
 
+// runeIndex holds the code points of a string, so that a loop indexing it does
+// not decode the whole string at every step. ASCII strings are indexed by byte
+type runeIndex struct {
+	ascii bool
+	runes []rune
+}
+
+func (s *String) index() *runeIndex {
+	if idx := s.runes.Load(); idx != nil {
+		return idx
+	}
+
+	idx := &runeIndex{ascii: true}
+	for i := 0; i < len(s.Value); i++ {
+		if s.Value[i] >= utf8.RuneSelf {
+			idx.ascii = false
+			idx.runes = []rune(s.Value)
+			break
+		}
+	}
+	s.runes.Store(idx)
+	return idx
+}
+
+// Len is the number of code points in the string
+func (s *String) Len() int {
+	if idx := s.index(); !idx.ascii {
+		return len(idx.runes)
+	}
+	return len(s.Value)
+}
+
+// Char returns the code point at index i, which must be less than Len
+func (s *String) Char(i int) string {
+	if idx := s.index(); !idx.ascii {
+		return string(idx.runes[i])
+	}
+	return s.Value[i : i+1]
+}
+
 func (s *String) HashKey() HashKey {
 	h := fnv.New64a()
 	h.Write([]byte(s.Value))
 	return HashKey{Type: s.Type(), Value: h.Sum64()}
 }
 
+type Bytes struct {
+	Value []byte
+}
+
+func (b *Bytes) Type() ObjectType { return BYTES_OBJ }
+
+// Inspect quotes the bytes the way Python does, escaping anything that is not printable ASCII
+func (b *Bytes) Inspect() string {
+	quote := byte('\'')
+	if bytes.IndexByte(b.Value, '\'') >= 0 && bytes.IndexByte(b.Value, '"') < 0 {
+		quote = '"'
+	}
+
+	var out bytes.Buffer
+	out.WriteByte('b')
+	out.WriteByte(quote)
+	for _, c := range b.Value {
+		switch {
+		case c == quote || c == '\\':
+			out.WriteByte('\\')
+			out.WriteByte(c)
+		case c == '\t':
+			out.WriteString("\\t")
+		case c == '\n':
+			out.WriteString("\\n")
+		case c == '\r':
+			out.WriteString("\\r")
+		case c < ' ' || c >= 0x7f:
+			fmt.Fprintf(&out, "\\x%02x", c)
+		default:
+			out.WriteByte(c)
+		}
+	}
+	out.WriteByte(quote)
+
+	return out.String()
+}
+
+func (b *Bytes) HashKey() HashKey {
+	h := fnv.New64a()
+	h.Write(b.Value)
+	return HashKey{Type: b.Type(), Value: h.Sum64()}
+}
+
 type Null struct{}
 
 func (n *Null) Type() ObjectType { return NULL_OBJ }
@@ -108,19 +252,25 @@ type List struct {
 }
 
 func (lo *List) Type() ObjectType { return LIST_OBJ }
-func (lo *List) Inspect() string {
-	var out bytes.Buffer
+func (lo *List) Inspect() string  { return inspectContainer(lo, map[Object]bool{}) }
 
-	elements := []string{}
-	for _, e := range lo.Elements {
-		elements = append(elements, e.Inspect())
-	}
+type Tuple struct {
+	Elements []Object
+}
 
-	out.WriteString("[")
-	out.WriteString(strings.Join(elements, ", "))
-	out.WriteString("]")
+func (t *Tuple) Type() ObjectType { return TUPLE_OBJ }
+func (t *Tuple) Inspect() string  { return inspectContainer(t, map[Object]bool{}) }
 
-	return out.String()
+// Slice holds the bounds of a slice expression. Omitted bounds are Null
+type Slice struct {
+	Start Object
+	Stop  Object
+	Step  Object
+}
+
+func (s *Slice) Type() ObjectType { return SLICE_OBJ }
+func (s *Slice) Inspect() string {
+	return "slice(" + s.Start.Inspect() + ", " + s.Stop.Inspect() + ", " + s.Step.Inspect() + ")"
 }
 
 type Hashable interface {
@@ -130,51 +280,234 @@ type Hashable interface {
 type HashKey struct {
 	Type  ObjectType
 	Value uint64
+	Slot  int // Tells apart unequal keys whose hashes collide
 }
 
 type HashPair struct {
 	Key   Object
 	Value Object
+	order uint64 // When the key was inserted, since dicts keep insertion order
 }
 
 type Dict struct {
-	Pairs map[HashKey]HashPair
+	Pairs  map[HashKey]HashPair
+	Frozen bool   // Frozen dicts are read-only, such as the copy of a Go map field
+	next   uint64 // The order of the next key inserted
 }
 
-func (d *Dict) Type() ObjectType { return DICT_OBJ }
-func (d *Dict) Inspect() string {
-	var out bytes.Buffer
+// Set stores key and value under hash. A new key goes after the others, and a
+// key that is already present keeps its place
+func (d *Dict) Set(hash HashKey, key, value Object) {
+	pair := HashPair{Key: key, Value: value}
+	if old, ok := d.Pairs[hash]; ok {
+		pair.order = old.order
+	} else {
+		d.next++
+		pair.order = d.next
+	}
+	d.Pairs[hash] = pair
+}
 
-	pairs := []string{}
-	for _, pair := range d.Pairs {
-		pairs = append(pairs, fmt.Sprintf("%s: %s", pair.Key.Inspect(), pair.Value.Inspect()))
+// Keys returns the hashes of the keys in the order they were inserted
+func (d *Dict) Keys() []HashKey {
+	keys := make([]HashKey, 0, len(d.Pairs))
+	for hash := range d.Pairs {
+		keys = append(keys, hash)
 	}
+	sort.Slice(keys, func(i, j int) bool { return d.Pairs[keys[i]].order < d.Pairs[keys[j]].order })
+	return keys
+}
 
-	out.WriteString("{")
-	out.WriteString(strings.Join(pairs, ", "))
-	out.WriteString("}")
+// Items returns the pairs in the order their keys were inserted
+func (d *Dict) Items() []HashPair {
+	pairs := make([]HashPair, 0, len(d.Pairs))
+	for _, hash := range d.Keys() {
+		pairs = append(pairs, d.Pairs[hash])
+	}
+	return pairs
+}
 
-	return out.String()
+// Copy returns a mutable dict with the same pairs in the same order
+func (d *Dict) Copy() *Dict {
+	pairs := make(map[HashKey]HashPair, len(d.Pairs))
+	for hash, pair := range d.Pairs {
+		pairs[hash] = pair
+	}
+	return &Dict{Pairs: pairs, next: d.next}
 }
 
+func (d *Dict) Type() ObjectType { return DICT_OBJ }
+func (d *Dict) Inspect() string  { return inspectContainer(d, map[Object]bool{}) }
+
 type Set struct {
 	Values map[HashKey]Object
+	Frozen bool // Frozen sets are immutable and hashable
 }
 
-func (s *Set) Type() ObjectType { return SET_OBJ }
-func (s *Set) Inspect() string {
-	var out bytes.Buffer
+func (s *Set) Type() ObjectType {
+	if s.Frozen {
+		return FROZENSET_OBJ
+	}
+	return SET_OBJ
+}
 
-	vals := []string{}
+func (s *Set) Inspect() string { return inspectContainer(s, map[Object]bool{}) }
+
+// Elements returns the elements of the set in a stable order, so that equal sets
+// always show and iterate the same way: numbers by value, then strings, then
+// other objects by type and repr
+func (s *Set) Elements() []Object {
+	elements := make([]Object, 0, len(s.Values))
 	for _, val := range s.Values {
-		vals = append(vals, val.Inspect())
+		elements = append(elements, val)
 	}
+	sort.Slice(elements, func(i, j int) bool { return compareElements(elements[i], elements[j]) < 0 })
+	return elements
+}
 
-	out.WriteString("{")
-	out.WriteString(strings.Join(vals, ", "))
-	out.WriteString("}")
+func compareElements(a, b Object) int {
+	if rankA, rankB := elementRank(a), elementRank(b); rankA != rankB {
+		return rankA - rankB
+	}
 
-	return out.String()
+	switch a := a.(type) {
+	case *Integer:
+		if b, ok := b.(*Integer); ok {
+			return cmp.Compare(a.Value, b.Value)
+		}
+	case *String:
+		return strings.Compare(a.Value, b.(*String).Value)
+	}
+	if elementRank(a) == 0 {
+		if order := cmp.Compare(numberValue(a), numberValue(b)); order != 0 {
+			return order
+		}
+	}
+
+	if order := strings.Compare(string(a.Type()), string(b.Type())); order != 0 {
+		return order
+	}
+	return strings.Compare(a.Inspect(), b.Inspect())
+}
+
+func elementRank(obj Object) int {
+	switch obj.(type) {
+	case *Boolean, *Integer, *Float:
+		return 0
+	case *String:
+		return 1
+	}
+	return 2
+}
+
+func numberValue(obj Object) float64 {
+	switch obj := obj.(type) {
+	case *Boolean:
+		if obj.Value {
+			return 1
+		}
+	case *Integer:
+		return float64(obj.Value)
+	case *Float:
+		return obj.Value
+	}
+	return 0
+}
+
+// FormatSet writes set elements the way Python does, which needs a call syntax for
+// frozen sets and for the empty set, since "{}" is an empty dict
+func FormatSet(s *Set, elements []string) string {
+	name := "set"
+	if s.Frozen {
+		name = "frozenset"
+	}
+
+	switch {
+	case len(elements) == 0:
+		return name + "()"
+	case s.Frozen:
+		return name + "({" + strings.Join(elements, ", ") + "})"
+	default:
+		return "{" + strings.Join(elements, ", ") + "}"
+	}
+}
+
+// RecursiveRepr is how a container is shown inside itself
+func RecursiveRepr(obj Object) string {
+	switch obj.(type) {
+	case *List:
+		return "[...]"
+	case *Tuple:
+		return "(...)"
+	}
+	return "{...}"
+}
+
+// inspectContainer inspects a list, tuple, dict or set. seen holds the containers
+// being inspected further up, which are shown as "[...]" and the like rather than
+// recursing forever when a container holds itself
+func inspectContainer(obj Object, seen map[Object]bool) string {
+	if seen[obj] {
+		return RecursiveRepr(obj)
+	}
+	seen[obj] = true
+	defer delete(seen, obj)
+
+	inspect := func(el Object) string {
+		switch el.(type) {
+		case *List, *Tuple, *Dict, *Set:
+			return inspectContainer(el, seen)
+		}
+		return el.Inspect()
+	}
+
+	switch obj := obj.(type) {
+	case *List:
+		var out bytes.Buffer
+		elements := []string{}
+		for _, e := range obj.Elements {
+			elements = append(elements, inspect(e))
+		}
+
+		out.WriteString("[")
+		out.WriteString(strings.Join(elements, ", "))
+		out.WriteString("]")
+
+		return out.String()
+
+	case *Tuple:
+		elements := []string{}
+		for _, e := range obj.Elements {
+			elements = append(elements, inspect(e))
+		}
+
+		if len(elements) == 1 {
+			return "(" + elements[0] + ",)"
+		}
+		return "(" + strings.Join(elements, ", ") + ")"
+
+	case *Dict:
+		var out bytes.Buffer
+		pairs := []string{}
+		for _, pair := range obj.Items() {
+			pairs = append(pairs, fmt.Sprintf("%s: %s", inspect(pair.Key), inspect(pair.Value)))
+		}
+
+		out.WriteString("{")
+		out.WriteString(strings.Join(pairs, ", "))
+		out.WriteString("}")
+
+		return out.String()
+
+	case *Set:
+		vals := []string{}
+		for _, val := range obj.Elements() {
+			vals = append(vals, inspect(val))
+		}
+		return FormatSet(obj, vals)
+	}
+
+	return obj.Inspect()
 }
 
 /*
@@ -189,6 +522,7 @@ func (rv *ReturnValue) Inspect() string  { return rv.Value.Inspect() }
 
 type Error struct {
 	Message string
+	Err     error // The Go error a host function returned, if any
 }
 
 func (e *Error) Type() ObjectType { return ERROR_OBJ }
@@ -199,6 +533,7 @@ type Function struct {
 	Parameters []*ast.Identifier
 	Body       *ast.BlockStatement
 	Env        *Environment
+	Class      *Class // Set when the function is defined in a class body
 }
 
 func (f *Function) Type() ObjectType { return FUNCTION_OBJ }
@@ -219,8 +554,19 @@ func (f *Function) Inspect() string {
 
 type BuiltinFunction func(args ...Object) Object
 
+// BuiltinKeywordFunction is a builtin that also accepts keyword arguments
+type BuiltinKeywordFunction func(kwargs map[string]Object, args ...Object) Object
+
+// BuiltinRuntimeFunction is a builtin that needs the runtime of the script
+// calling it, such as format, whose output is bounded by the runtime's limits.
+// The runtime is nil when the caller is not a script
+type BuiltinRuntimeFunction func(runtime *Runtime, kwargs map[string]Object, args ...Object) Object
+
 type Builtin struct {
-	Fn BuiltinFunction
+	Fn        BuiltinFunction
+	KwFn      BuiltinKeywordFunction // Called instead of Fn when set
+	RuntimeFn BuiltinRuntimeFunction // Called instead of KwFn and Fn when set
+	Attrs     map[string]Object      // Attributes such as dict.fromkeys
 }
 
 func (b *Builtin) Type() ObjectType { return BUILTIN_OBJ }
@@ -228,9 +574,112 @@ func (b *Builtin) Inspect() string  { return "builtin function" }
 
 type BuiltinObjectMethod func(obj Object, args ...Object) Object
 
+// BuiltinKeywordMethod is a builtin method that also accepts keyword arguments
+type BuiltinKeywordMethod func(obj Object, kwargs map[string]Object, args ...Object) Object
+
+// BuiltinRuntimeMethod is a builtin method that needs the runtime of the script
+// calling it, which is nil when the caller is not a script
+type BuiltinRuntimeMethod func(runtime *Runtime, obj Object, kwargs map[string]Object, args ...Object) Object
+
 type BuiltinMethod struct {
-	Fn BuiltinObjectMethod
+	Fn        BuiltinObjectMethod
+	KwFn      BuiltinKeywordMethod // Called instead of Fn when set
+	RuntimeFn BuiltinRuntimeMethod // Called instead of KwFn and Fn when set
 }
 
 func (b *BuiltinMethod) Type() ObjectType { return BUILTIN_OBJ }
 func (b *BuiltinMethod) Inspect() string  { return "builtin object-specific function" }
+
+/*
+Classes
+*/
+type Class struct {
+	Name  string
+	Bases []*Class
+	MRO   []*Class // Method resolution order, starting with the class itself
+	Attrs map[string]Object
+}
+
+func (c *Class) Type() ObjectType { return CLASS_OBJ }
+func (c *Class) Inspect() string  { return fmt.Sprintf("<class '%s'>", c.Name) }
+
+// Lookup searches the method resolution order for an attribute
+func (c *Class) Lookup(name string) (Object, bool) {
+	for _, cls := range c.MRO {
+		if val, ok := cls.Attrs[name]; ok {
+			return val, true
+		}
+	}
+	return nil, false
+}
+
+func (c *Class) IsSubclass(other *Class) bool {
+	for _, cls := range c.MRO {
+		if cls == other {
+			return true
+		}
+	}
+	return false
+}
+
+type Instance struct {
+	Class *Class
+	Attrs map[string]Object
+}
+
+func (i *Instance) Type() ObjectType { return INSTANCE_OBJ }
+func (i *Instance) Inspect() string  { return fmt.Sprintf("<%s object>", i.Class.Name) }
+
+type BoundMethod struct {
+	Self   Object
+	Method Object // *Function or *BuiltinMethod
+}
+
+func (bm *BoundMethod) Type() ObjectType { return BOUND_METHOD_OBJ }
+func (bm *BoundMethod) Inspect() string {
+	if fn, ok := bm.Method.(*Function); ok {
+		return fmt.Sprintf("<bound method %s of %s>", fn.Name, bm.Self.Inspect())
+	}
+	return fmt.Sprintf("<bound builtin method of %s>", bm.Self.Inspect())
+}
+
+// Super proxies attribute lookups to the classes after Class in the MRO of Self
+type Super struct {
+	Class *Class
+	Self  Object
+}
+
+func (s *Super) Type() ObjectType { return SUPER_OBJ }
+func (s *Super) Inspect() string {
+	return fmt.Sprintf("<super: <class '%s'>, %s>", s.Class.Name, s.Self.Inspect())
+}
+
+/*
+Host Objects
+*/
+
+// HostObject is implemented by values of the host program that scripts use
+// through attributes, such as the Go structs exposed by the interpreter package
+type HostObject interface {
+	Object
+	GetAttr(name string) Object             // An *Error when there is no such attribute
+	SetAttr(name string, val Object) Object // An *Error when it cannot be set
+	AttrNames() []string
+}
+
+/*
+Modules
+*/
+type Module struct {
+	Name string
+	Path string       // Empty for modules implemented in Go
+	Env  *Environment // Global scope the module was executed in
+}
+
+func (m *Module) Type() ObjectType { return MODULE_OBJ }
+func (m *Module) Inspect() string {
+	if m.Path == "" {
+		return fmt.Sprintf("<module '%s' (native)>", m.Name)
+	}
+	return fmt.Sprintf("<module '%s' from '%s'>", m.Name, m.Path)
+}
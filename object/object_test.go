@@ -1,6 +1,11 @@
 package object
 
-import "testing"
+import (
+	"fmt"
+	"strings"
+	"sync"
+	"testing"
+)
 
 func TestStringHashKey(t *testing.T) {
 	hello1 := &String{Value: "Hello World"}
@@ -17,3 +22,156 @@ func TestStringHashKey(t *testing.T) {
 		t.Errorf("strings with different content have same hash keys")
 	}
 }
+
+func TestStringChars(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected []string
+	}{
+		{"", []string{}},
+		{"abc", []string{"a", "b", "c"}},
+		{"héllo", []string{"h", "é", "l", "l", "o"}},
+		{"日本", []string{"日", "本"}},
+	}
+
+	for _, tt := range tests {
+		str := &String{Value: tt.input}
+		if str.Len() != len(tt.expected) {
+			t.Errorf("%q: Len wrong. expected=%d, got=%d", tt.input, len(tt.expected), str.Len())
+			continue
+		}
+		for i, char := range tt.expected {
+			if got := str.Char(i); got != char {
+				t.Errorf("%q: Char(%d) wrong. expected=%q, got=%q", tt.input, i, char, got)
+			}
+		}
+	}
+}
+
+func BenchmarkStringIndex(b *testing.B) {
+	str := &String{Value: strings.Repeat("héllo ", 10000)}
+	for i := 0; i < b.N; i++ {
+		for j := 0; j < str.Len(); j++ {
+			str.Char(j)
+		}
+	}
+}
+
+func TestFloatRepr(t *testing.T) {
+	tests := []struct {
+		input    float64
+		expected string
+	}{
+		{1, "1.0"},
+		{0.1, "0.1"},
+		{-2.5, "-2.5"},
+		{1.0 / 3, "0.3333333333333333"},
+		{1234000000000000, "1234000000000000.0"},
+		{1e16, "1e+16"},
+		{0.0001, "0.0001"},
+		{0.00001, "1e-05"},
+		{1.5e-7, "1.5e-07"},
+		{1e100, "1e+100"},
+	}
+
+	for _, tt := range tests {
+		if got := FloatRepr(tt.input); got != tt.expected {
+			t.Errorf("FloatRepr(%v) wrong. expected=%q, got=%q", tt.input, tt.expected, got)
+		}
+	}
+}
+
+func TestRecursiveInspect(t *testing.T) {
+	list := &List{Elements: []Object{&Integer{Value: 1}}}
+	list.Elements = append(list.Elements, list)
+	if got := list.Inspect(); got != "[1, [...]]" {
+		t.Errorf("list.Inspect() wrong. expected=%q, got=%q", "[1, [...]]", got)
+	}
+
+	tuple := &Tuple{Elements: []Object{&List{}}}
+	inner := tuple.Elements[0].(*List)
+	inner.Elements = append(inner.Elements, tuple)
+	if got := tuple.Inspect(); got != "([(...)],)" {
+		t.Errorf("tuple.Inspect() wrong. expected=%q, got=%q", "([(...)],)", got)
+	}
+
+	// The same list twice is not a cycle
+	shared := &List{}
+	pair := &Tuple{Elements: []Object{shared, shared}}
+	if got := pair.Inspect(); got != "([], [])" {
+		t.Errorf("pair.Inspect() wrong. expected=%q, got=%q", "([], [])", got)
+	}
+}
+
+func TestEnvironmentFreeze(t *testing.T) {
+	moduleEnv := NewEnvironment()
+	moduleEnv.Set("pi", &Float{Value: 3.14})
+
+	env := NewEnvironment()
+	env.SetRuntime(&Runtime{})
+	env.Set("x", &Integer{Value: 1})
+	env.Set("m", &Module{Name: "m", Env: moduleEnv})
+	env.Freeze()
+
+	if !env.Frozen() || !moduleEnv.Frozen() {
+		t.Fatalf("environment or its module not frozen")
+	}
+	if env.Runtime() != nil {
+		t.Errorf("frozen environment kept its runtime")
+	}
+
+	if err, ok := env.Set("x", &Integer{Value: 2}).(*Error); !ok || err.Message != "cannot assign to 'x': the environment is frozen" {
+		t.Errorf("Set on a frozen environment wrong. got=%v", err)
+	}
+	if _, ok := moduleEnv.Set("pi", &Integer{Value: 3}).(*Error); !ok {
+		t.Errorf("Set on a frozen module succeeded")
+	}
+	if env.Delete("x") {
+		t.Errorf("Delete on a frozen environment succeeded")
+	}
+	if x, _ := env.Get("x"); x.Inspect() != "1" {
+		t.Errorf("x changed. got=%s", x.Inspect())
+	}
+
+	// Enclosed environments can still bind names and hold a runtime
+	inner := NewEnclosedEnvironment(env)
+	runtime := &Runtime{}
+	inner.SetRuntime(runtime)
+	inner.Set("x", &Integer{Value: 3})
+	if x, _ := inner.Get("x"); x.Inspect() != "3" || inner.Runtime() != runtime {
+		t.Errorf("enclosed environment wrong. got=%s", x.Inspect())
+	}
+}
+
+// TestEnvironmentConcurrency checks with -race that a frozen environment can be
+// read from several goroutines, each binding names in its own enclosed scope
+func TestEnvironmentConcurrency(t *testing.T) {
+	env := NewEnvironment()
+	for i := 0; i < 10; i++ {
+		env.Set(fmt.Sprintf("v%d", i), &Integer{Value: int64(i)})
+	}
+	env.Freeze()
+	var wg sync.WaitGroup
+
+	for n := 0; n < 8; n++ {
+		wg.Add(1)
+		go func(n int) {
+			defer wg.Done()
+			inner := NewEnclosedEnvironment(env)
+			inner.SetRuntime(&Runtime{})
+			for i := 0; i < 100; i++ {
+				name := fmt.Sprintf("v%d", i%10)
+				if _, ok := inner.Get(name); !ok {
+					t.Errorf("%s not found", name)
+				}
+				inner.Set(name+"_local", &Integer{Value: int64(n)})
+				inner.Names()
+				inner.Delete(name + "_local")
+				if env.Set(name, &Integer{Value: int64(n)}).Type() != ERROR_OBJ {
+					t.Errorf("frozen environment accepted %s", name)
+				}
+			}
+		}(n)
+	}
+	wg.Wait()
+}
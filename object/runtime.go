@@ -0,0 +1,74 @@
+package object
+
+import (
+	"context"
+	"io"
+	"slices"
+	"sync"
+)
+
+// Runtime is the state of one interpreter, shared by every environment its
+// scripts run in. It must only be used by one script at a time
+type Runtime struct {
+	Stdin  io.Reader
+	Stdout io.Writer
+	Stderr io.Writer
+
+	// Context stops the running script when it is cancelled or its deadline
+	// passes. A nil context never stops it
+	Context context.Context
+
+	// The limits of a run. Zero means no limit
+	MaxSteps int64 // Evaluation steps
+	MaxDepth int   // Nested function calls
+	MaxAlloc int64 // Length of any one list, string or other container
+
+	Steps int64 // Steps taken so far
+	Depth int   // Function calls in progress
+
+	// Capabilities limit the builtins and modules scripts may use. Nil
+	// capabilities allow all of them
+	Capabilities *Capabilities
+
+	// Modules caches imported modules, by absolute path for source modules and
+	// by name for native ones
+	Modules map[string]*Module
+
+	// Importing holds the paths of the modules whose import is in progress
+	Importing []string
+
+	// Threads coordinates the threads started by scripts
+	Threads Threads
+
+	// Builtins take precedence over the global builtins of the same name. They
+	// hold the builtins that are bound to this runtime, such as print
+	Builtins map[string]*Builtin
+}
+
+// Threads is the state of the threads a runtime's scripts start. Only the
+// goroutine holding the GIL runs script code, so the objects that threads share
+// need no locks of their own
+type Threads struct {
+	GIL     sync.Mutex     // The global interpreter lock
+	Active  bool           // Whether threads were started, so the GIL is held
+	Current int64          // The thread holding the GIL. The main thread is 0
+	Started int64          // Number of threads created, for their names and identifiers
+	Running sync.WaitGroup // Threads that have not finished
+}
+
+// Capabilities whitelist what a sandboxed script may use. Anything not listed
+// raises a PermissionError
+type Capabilities struct {
+	Builtins []string // Builtin functions, including print and input
+	Modules  []string // Native and source modules
+}
+
+// AllowsBuiltin reports whether the builtin function name may be used
+func (c *Capabilities) AllowsBuiltin(name string) bool {
+	return c == nil || slices.Contains(c.Builtins, name)
+}
+
+// AllowsModule reports whether the module name may be imported
+func (c *Capabilities) AllowsModule(name string) bool {
+	return c == nil || slices.Contains(c.Modules, name)
+}
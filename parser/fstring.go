@@ -0,0 +1,175 @@
+package parser
+
+import (
+	"simpyl/ast"
+	"simpyl/lexer"
+	"simpyl/token"
+	"strings"
+)
+
+/*
+Formatted String Literals
+*/
+func (p *Parser) parseFormattedString() ast.Expression {
+	fs, _ := p.parseFormatParts(p.curToken, p.curToken.Literal, false)
+	return fs
+}
+
+// parseFormatParts splits f-string text into literal text and replacement fields.
+// Inside a format spec the text ends at the first unmatched '}', and the number
+// of bytes consumed is returned.
+func (p *Parser) parseFormatParts(tok token.Token, src string, spec bool) (*ast.FormattedString, int) {
+	fs := &ast.FormattedString{Token: tok}
+	var text strings.Builder
+
+	flush := func() {
+		if text.Len() > 0 {
+			literal := token.Token{Type: token.STRING, Literal: text.String()}
+			fs.Parts = append(fs.Parts, &ast.StringLiteral{Token: literal, Value: literal.Literal})
+			text.Reset()
+		}
+	}
+
+	i := 0
+	for i < len(src) {
+		ch := src[i]
+
+		switch {
+		case ch == '{' && !spec && strings.HasPrefix(src[i:], "{{"):
+			text.WriteByte('{')
+			i += 2
+		case ch == '}' && !spec && strings.HasPrefix(src[i:], "}}"):
+			text.WriteByte('}')
+			i += 2
+		case ch == '}' && spec:
+			flush()
+			return fs, i
+		case ch == '}':
+			p.errorAt(tok, "f-string: single '}' is not allowed")
+			return fs, len(src)
+		case ch == '{':
+			flush()
+			field, n := p.parseFormatField(tok, src[i+1:])
+			if field == nil {
+				return fs, len(src)
+			}
+			fs.Parts = append(fs.Parts, field)
+			i += n + 1
+		default:
+			text.WriteByte(ch)
+			i++
+		}
+	}
+
+	flush()
+	return fs, i
+}
+
+// parseFormatField parses a replacement field following its '{' and returns the
+// number of bytes consumed, including the closing '}'
+func (p *Parser) parseFormatField(tok token.Token, src string) (*ast.FormattedValue, int) {
+	end, ok := formatExpressionEnd(src)
+	if !ok {
+		p.errorAt(tok, "f-string: expecting '}'")
+		return nil, 0
+	}
+
+	source := src[:end]
+	if strings.TrimSpace(source) == "" {
+		p.errorAt(tok, "f-string: empty expression not allowed")
+		return nil, 0
+	}
+
+	field := &ast.FormattedValue{Token: tok, Value: p.parseFormatExpression(tok, source)}
+	if field.Value == nil {
+		return nil, 0
+	}
+
+	i := end
+	if src[i] == '!' {
+		if i+1 >= len(src) || src[i+1] != 's' && src[i+1] != 'r' {
+			p.errorAt(tok, "f-string: invalid conversion character: expected 's' or 'r'")
+			return nil, 0
+		}
+		field.Conversion = src[i+1]
+		i += 2
+	}
+
+	if i < len(src) && src[i] == ':' {
+		spec, n := p.parseFormatParts(tok, src[i+1:], true)
+		field.FormatSpec = spec
+		i += n + 1
+	}
+
+	if i >= len(src) || src[i] != '}' {
+		p.errorAt(tok, "f-string: expecting '}'")
+		return nil, 0
+	}
+
+	return field, i + 1
+}
+
+// parseFormatExpression parses the expression of a replacement field on its own.
+// Errors are reported at the f-string token, since the expression has no position
+// of its own in the file
+func (p *Parser) parseFormatExpression(tok token.Token, source string) ast.Expression {
+	// Surrounding spaces are allowed and must not be read as indentation
+	sub := New(lexer.New(strings.TrimSpace(source)))
+	sub.origin = &tok
+	exp := sub.parseExpression(LOWEST)
+
+	if !sub.peekTokenIs(token.EOF) {
+		sub.errorAt(sub.peekToken, "f-string: expecting '}'")
+	}
+
+	errors := sub.Errors()
+	if len(errors) == 0 {
+		return exp
+	}
+
+	msg := errors[0]
+	if !strings.HasPrefix(msg, "f-string: ") {
+		msg = "f-string: " + msg
+	}
+	if !p.failed {
+		p.failed = true
+		p.errors = append(p.errors, msg)
+	}
+	return nil
+}
+
+// formatExpressionEnd finds where the expression of a replacement field ends: at
+// the first '}', ':' or conversion '!' outside brackets and string literals
+func formatExpressionEnd(src string) (int, bool) {
+	depth := 0
+
+	for i := 0; i < len(src); i++ {
+		switch ch := src[i]; ch {
+		case '(', '[', '{':
+			depth++
+		case ')', ']':
+			depth--
+		case '}':
+			if depth == 0 {
+				return i, true
+			}
+			depth--
+		case ':':
+			if depth == 0 {
+				return i, true
+			}
+		case '!':
+			if depth == 0 && (i+1 >= len(src) || src[i+1] != '=') {
+				return i, true
+			}
+		case '"', '\'':
+			closing := strings.IndexByte(src[i+1:], ch)
+			if closing < 0 {
+				return 0, false
+			}
+			i += closing + 1
+		}
+	}
+
+	return 0, false
+}
@@ -6,6 +6,7 @@ import (
 	"simpyl/lexer"
 	"simpyl/token"
 	"strconv"
+	"strings"
 )
 
 type Parser struct {
@@ -13,8 +14,8 @@ type Parser struct {
 	curToken  token.Token
 	peekToken token.Token
 	errors    []string
-	spacing   int
-	skipFlag  bool
+	failed    bool         // an error was found in the current statement
+	origin    *token.Token // reported as the position of every error, when set
 
 	prefixParseFns map[token.TokenType]prefixParseFn
 	infixParseFns  map[token.TokenType]infixParseFn
@@ -30,6 +31,9 @@ const (
 	LOWEST
 	EQUALS      // ==
 	LESSGREATER // > or <
+	BITOR       // |
+	BITXOR      // ^
+	BITAND      // &
 	SUM         // +
 	PRODUCT     // *
 	PREFIX      // -X or !X
@@ -43,10 +47,16 @@ var precedences = map[token.TokenType]int{
 	token.NOT_EQ:   EQUALS,
 	token.LT:       LESSGREATER,
 	token.GT:       LESSGREATER,
+	token.LT_EQ:    LESSGREATER,
+	token.GT_EQ:    LESSGREATER,
+	token.PIPE:     BITOR,
+	token.CARET:    BITXOR,
+	token.AMP:      BITAND,
 	token.PLUS:     SUM,
 	token.MINUS:    SUM,
 	token.SLASH:    PRODUCT,
 	token.ASTERISK: PRODUCT,
+	token.PERCENT:  PRODUCT,
 	token.LPAREN:   CALL,
 	token.DOT:      CALL,
 	token.LBRACKET: INDEX,
@@ -54,10 +64,8 @@ var precedences = map[token.TokenType]int{
 
 func New(l *lexer.Lexer) *Parser {
 	p := &Parser{
-		l:        l,
-		errors:   []string{},
-		spacing:  0,
-		skipFlag: true,
+		l:      l,
+		errors: []string{},
 	}
 
 	p.prefixParseFns = make(map[token.TokenType]prefixParseFn)
@@ -71,6 +79,8 @@ func New(l *lexer.Lexer) *Parser {
 	p.registerPrefix(token.LPAREN, p.parseGroupedExpression)
 	p.registerPrefix(token.IF, p.parseIfExpression)
 	p.registerPrefix(token.STRING, p.parseStringLiteral)
+	p.registerPrefix(token.BYTES, p.parseBytesLiteral)
+	p.registerPrefix(token.FSTRING, p.parseFormattedString)
 	p.registerPrefix(token.LBRACKET, p.parseListLiteral)
 	p.registerPrefix(token.LBRACE, p.parseDictLiteral)
 
@@ -79,10 +89,16 @@ func New(l *lexer.Lexer) *Parser {
 	p.registerInfix(token.MINUS, p.parseInfixExpression)
 	p.registerInfix(token.SLASH, p.parseInfixExpression)
 	p.registerInfix(token.ASTERISK, p.parseInfixExpression)
+	p.registerInfix(token.PERCENT, p.parseInfixExpression)
 	p.registerInfix(token.EQ, p.parseInfixExpression)
 	p.registerInfix(token.NOT_EQ, p.parseInfixExpression)
 	p.registerInfix(token.LT, p.parseInfixExpression)
 	p.registerInfix(token.GT, p.parseInfixExpression)
+	p.registerInfix(token.LT_EQ, p.parseInfixExpression)
+	p.registerInfix(token.GT_EQ, p.parseInfixExpression)
+	p.registerInfix(token.PIPE, p.parseInfixExpression)
+	p.registerInfix(token.CARET, p.parseInfixExpression)
+	p.registerInfix(token.AMP, p.parseInfixExpression)
 	p.registerInfix(token.LPAREN, p.parseCallExpression)
 	p.registerInfix(token.LBRACKET, p.parseIndexExpression)
 	p.registerInfix(token.DOT, p.parseObjectMethod)
@@ -99,16 +115,10 @@ func (p *Parser) ParseProgram() *ast.Program {
 	program.Statements = []ast.Statement{}
 
 	for p.curToken.Type != token.EOF {
-		stmt := p.parseStatement()
-		if stmt != nil {
+		if stmt := p.parseStatementLine(); stmt != nil {
 			program.Statements = append(program.Statements, stmt)
 		}
-
-		if p.skipFlag {
-			p.nextToken()
-		} else {
-			p.skipFlag = true
-		}
+		p.nextToken()
 	}
 	return program
 }
@@ -117,14 +127,65 @@ func (p *Parser) ParseProgram() *ast.Program {
 Statement Parsing
 */
 
+// parseStatementLine parses a statement and checks that nothing but a semicolon
+// follows it on the line. After a syntax error the statement is dropped and
+// parsing resumes at the next statement
+func (p *Parser) parseStatementLine() ast.Statement {
+	stmt := p.parseStatement()
+
+	if !p.failed && !p.curTokenIs(token.NEWLINE) && !p.curTokenIs(token.DEDENT) && !p.curTokenIs(token.SEMICOLON) {
+		switch p.peekToken.Type {
+		case token.NEWLINE, token.DEDENT, token.EOF, token.SEMICOLON:
+		default:
+			p.errorAt(p.peekToken, "expected end of line, got %s", describeToken(p.peekToken))
+		}
+	}
+
+	if p.failed {
+		p.synchronize()
+		return nil
+	}
+	return stmt
+}
+
+// synchronize recovers from a syntax error by skipping to the end of the current
+// statement, along with any indented block that belongs to it. curToken is left on
+// the final NEWLINE or DEDENT, so that parsing resumes at the next statement
+func (p *Parser) synchronize() {
+	p.failed = false
+
+	depth := 0
+	for !p.curTokenIs(token.EOF) {
+		switch p.curToken.Type {
+		case token.INDENT:
+			depth++
+		case token.DEDENT:
+			depth--
+			if depth <= 0 {
+				return
+			}
+		case token.NEWLINE:
+			if depth == 0 && !p.peekTokenIs(token.INDENT) {
+				return
+			}
+		}
+		p.nextToken()
+	}
+}
+
+// parseStatement parses the statement starting at curToken, leaving curToken on
+// its last token. A bare NEWLINE is an empty statement
 func (p *Parser) parseStatement() ast.Statement {
 	switch {
-	case p.curToken.Type == token.TAB || p.curToken.Type == token.NEWLINE:
-		p.parseSpacing()
-		return p.parseStatement()
-		// Error if before EOF
-		// Error if inside let statement
-		// Error if before semicolon
+	case p.curToken.Type == token.NEWLINE:
+		return nil
+
+	case p.curToken.Type == token.INDENT:
+		p.errorAt(p.curToken, "unexpected indent")
+		return nil
+
+	case p.curToken.Type == token.DEDENT:
+		return nil
 
 	case p.curToken.Type == token.LET:
 		return p.parseLetStatement()
@@ -144,6 +205,22 @@ func (p *Parser) parseStatement() ast.Statement {
 	case p.curToken.Type == token.WHILE:
 		return p.parseWhileStatement()
 
+	case p.curToken.Type == token.CLASS:
+		return p.parseClassStatement()
+
+	case p.curToken.Type == token.IMPORT:
+		return p.parseImportStatement()
+
+	case p.curToken.Type == token.FROM:
+		return p.parseFromImportStatement()
+
+	case p.curToken.Type == token.DEL:
+		return p.parseDelStatement()
+
+	// An if statement ends with its block, so it must not continue as an expression
+	case p.curToken.Type == token.IF:
+		return &ast.ExpressionStatement{Token: p.curToken, Expression: p.parseIfExpression()}
+
 	default:
 		return p.parseExpressionStatement()
 	}
@@ -152,13 +229,13 @@ func (p *Parser) parseStatement() ast.Statement {
 func (p *Parser) parseLetStatement() *ast.LetStatement {
 	stmt := &ast.LetStatement{Token: p.curToken}
 
-	if !p.expectPeek(token.IDENT) {
+	if !p.expect(token.IDENT) {
 		return nil
 	}
 
 	stmt.Name = &ast.Identifier{Token: p.curToken, Value: p.curToken.Literal}
 
-	if !p.expectPeek(token.ASSIGN) {
+	if !p.expect(token.ASSIGN) {
 		return nil
 	}
 
@@ -177,7 +254,7 @@ func (p *Parser) parseIdentStatement() *ast.LetStatement {
 	stmt := &ast.LetStatement{Token: token.Token{Type: token.LET, Literal: string("let")}}
 
 	stmt.Name = &ast.Identifier{Token: p.curToken, Value: p.curToken.Literal}
-	if !p.expectPeek(token.ASSIGN) {
+	if !p.expect(token.ASSIGN) {
 		return nil
 	}
 
@@ -209,18 +286,18 @@ func (p *Parser) parseReturnStatement() *ast.ReturnStatement {
 func (p *Parser) parseFunctionStatement() *ast.FunctionStatement {
 	lit := &ast.FunctionStatement{Token: p.curToken}
 
-	if !p.expectPeek(token.IDENT) {
+	if !p.expect(token.IDENT) {
 		return nil
 	}
 
 	lit.Name = p.curToken.Literal
 
-	if !p.expectPeek(token.LPAREN) {
+	if !p.expect(token.LPAREN) {
 		return nil
 	}
 
 	lit.Parameters = p.parseFunctionParameters()
-	if !p.expectPeek(token.COLON) {
+	if !p.expect(token.COLON) {
 		return nil
 	}
 
@@ -231,20 +308,20 @@ func (p *Parser) parseFunctionStatement() *ast.FunctionStatement {
 func (p *Parser) parseForStatement() *ast.ForStatement {
 	loop := &ast.ForStatement{Token: p.curToken}
 
-	if !p.expectPeek(token.IDENT) {
+	if !p.expect(token.IDENT) {
 		return nil
 	}
 
 	loop.Iterator = &ast.Identifier{Token: p.curToken, Value: p.curToken.Literal}
 
-	if !p.expectPeek(token.IN) {
+	if !p.expect(token.IN) {
 		return nil
 	}
 	p.nextToken()
 
 	loop.Iterable = p.parseExpression(LOWEST)
 
-	if !p.expectPeek(token.COLON) {
+	if !p.expect(token.COLON) {
 		return nil
 	}
 
@@ -258,7 +335,7 @@ func (p *Parser) parseWhileStatement() *ast.WhileStatement {
 	p.nextToken()
 	loop.Condition = p.parseExpression(LOWEST)
 
-	if !p.expectPeek(token.COLON) {
+	if !p.expect(token.COLON) {
 		return nil
 	}
 
@@ -266,6 +343,113 @@ func (p *Parser) parseWhileStatement() *ast.WhileStatement {
 	return loop
 }
 
+func (p *Parser) parseClassStatement() *ast.ClassStatement {
+	stmt := &ast.ClassStatement{Token: p.curToken}
+
+	if !p.expect(token.IDENT) {
+		return nil
+	}
+
+	stmt.Name = p.curToken.Literal
+
+	if p.expectPeek(token.LPAREN) {
+		stmt.Bases = p.parseExpressionList(token.RPAREN)
+	}
+
+	if !p.expect(token.COLON) {
+		return nil
+	}
+
+	stmt.Body = p.parseBlockStatement()
+	return stmt
+}
+
+func (p *Parser) parseImportStatement() *ast.ImportStatement {
+	stmt := &ast.ImportStatement{Token: p.curToken}
+
+	if !p.expect(token.IDENT) {
+		return nil
+	}
+	stmt.Module = &ast.Identifier{Token: p.curToken, Value: p.curToken.Literal}
+
+	if p.expectPeek(token.AS) {
+		if !p.expect(token.IDENT) {
+			return nil
+		}
+		stmt.Alias = &ast.Identifier{Token: p.curToken, Value: p.curToken.Literal}
+	}
+
+	return stmt
+}
+
+// parseDelStatement parses "del a, b[i], c.attr". Only names, subscriptions and
+// attributes can be deleted
+func (p *Parser) parseDelStatement() *ast.DelStatement {
+	stmt := &ast.DelStatement{Token: p.curToken}
+
+	for {
+		p.nextToken()
+		errors := len(p.errors)
+		target := p.parseExpression(LOWEST)
+
+		// A target that failed to parse may be missing parts, so it cannot be shown
+		if len(p.errors) > errors {
+			return nil
+		}
+
+		switch target.(type) {
+		case *ast.Identifier, *ast.IndexExpression, *ast.AttributeExpression:
+			stmt.Targets = append(stmt.Targets, target)
+		case nil:
+			return nil
+		default:
+			p.errorAt(stmt.Token, "cannot delete %s", target.String())
+			return nil
+		}
+
+		if !p.expectPeek(token.COMMA) {
+			break
+		}
+	}
+
+	return stmt
+}
+
+func (p *Parser) parseFromImportStatement() *ast.FromImportStatement {
+	stmt := &ast.FromImportStatement{Token: p.curToken}
+
+	if !p.expect(token.IDENT) {
+		return nil
+	}
+	stmt.Module = &ast.Identifier{Token: p.curToken, Value: p.curToken.Literal}
+
+	if !p.expect(token.IMPORT) {
+		return nil
+	}
+
+	for {
+		if !p.expect(token.IDENT) {
+			return nil
+		}
+		stmt.Names = append(stmt.Names, &ast.Identifier{Token: p.curToken, Value: p.curToken.Literal})
+
+		var alias *ast.Identifier
+		if p.expectPeek(token.AS) {
+			if !p.expect(token.IDENT) {
+				return nil
+			}
+			alias = &ast.Identifier{Token: p.curToken, Value: p.curToken.Literal}
+		}
+		stmt.Aliases = append(stmt.Aliases, alias)
+
+		if !p.expectPeek(token.COMMA) {
+			break
+		}
+	}
+
+	return stmt
+}
+
 /*
 Expression Parsing
 */
@@ -293,7 +477,7 @@ func (p *Parser) parseExpression(precedence int) ast.Expression {
 
 	prefix := p.prefixParseFns[p.curToken.Type]
 	if prefix == nil {
-		p.noPrefixParseFnError(p.curToken.Type)
+		p.noPrefixParseFnError(p.curToken)
 		return nil
 	}
 	leftExp := prefix()
@@ -322,8 +506,7 @@ func (p *Parser) parseIntegerLiteral() ast.Expression {
 
 	value, err := strconv.ParseInt(p.curToken.Literal, 0, 64)
 	if err != nil {
-		msg := fmt.Sprintf("could not parse %q as integer", p.curToken.Literal)
-		p.errors = append(p.errors, msg)
+		p.errorAt(p.curToken, "could not parse %q as integer", p.curToken.Literal)
 		return nil
 	}
 
@@ -338,8 +521,7 @@ func (p *Parser) parseFloatLiteral() ast.Expression {
 
 	value, err := strconv.ParseFloat(p.curToken.Literal, 64)
 	if err != nil {
-		msg := fmt.Sprintf("could not parse %q as float", p.curToken.Literal)
-		p.errors = append(p.errors, msg)
+		p.errorAt(p.curToken, "could not parse %q as float", p.curToken.Literal)
 		return nil
 	}
 
@@ -356,43 +538,118 @@ func (p *Parser) parseStringLiteral() ast.Expression {
 	return &ast.StringLiteral{Token: p.curToken, Value: p.curToken.Literal}
 }
 
+func (p *Parser) parseBytesLiteral() ast.Expression {
+	return &ast.BytesLiteral{Token: p.curToken, Value: []byte(p.curToken.Literal)}
+}
+
 func (p *Parser) parseListLiteral() ast.Expression {
 	array := &ast.ListLiteral{Token: p.curToken}
 	array.Elements = p.parseExpressionList(token.RBRACKET)
 	return array
 }
 
+// parseDictLiteral parses the braced displays. The first entry decides the kind:
+// "{}" and "{k: v}" are dicts, "{a, b}" is a set and "{a for a in b}" a set comprehension
 func (p *Parser) parseDictLiteral() ast.Expression {
-	dict := &ast.DictLiteral{Token: p.curToken}
+	tok := p.curToken
 
-	dict.Pairs = make(map[ast.Expression]ast.Expression)
-	for !p.peekTokenIs(token.RBRACE) {
-		p.nextToken()
-		p.advanceWhitespace()
+	if p.expectPeek(token.RBRACE) {
+		return &ast.DictLiteral{Token: tok, Pairs: make(map[ast.Expression]ast.Expression)}
+	}
+
+	p.nextToken()
+	first := p.parseExpression(LOWEST)
+
+	switch {
+	case p.peekTokenIs(token.COLON):
+		return p.parseDictPairs(tok, first)
+	case p.peekTokenIs(token.FOR):
+		return p.parseSetComprehension(tok, first)
+	default:
+		return p.parseSetElements(tok, first)
+	}
+}
 
-		key := p.parseExpression(LOWEST)
-		p.advanceWhitespace()
-		if !p.expectPeek(token.COLON) {
+func (p *Parser) parseDictPairs(tok token.Token, key ast.Expression) ast.Expression {
+	dict := &ast.DictLiteral{Token: tok, Pairs: make(map[ast.Expression]ast.Expression)}
+
+	for {
+		if !p.expect(token.COLON) {
 			return nil
 		}
 		p.nextToken()
-		p.advanceWhitespace()
 
 		value := p.parseExpression(LOWEST)
-		p.advanceWhitespace()
 		dict.Pairs[key] = value
-		if !p.peekTokenIs(token.RBRACE) && !p.expectPeek(token.COMMA) {
+		dict.Keys = append(dict.Keys, key)
+		if !p.peekTokenIs(token.RBRACE) && !p.expect(token.COMMA) {
 			return nil
 		}
+		if p.peekTokenIs(token.RBRACE) {
+			break
+		}
+
+		p.nextToken()
+		key = p.parseExpression(LOWEST)
 	}
 
-	if !p.expectPeek(token.RBRACE) {
+	if !p.expect(token.RBRACE) {
 		return nil
 	}
 
 	return dict
 }
 
+func (p *Parser) parseSetElements(tok token.Token, first ast.Expression) ast.Expression {
+	set := &ast.SetLiteral{Token: tok, Elements: []ast.Expression{first}}
+
+	for p.expectPeek(token.COMMA) {
+		if p.peekTokenIs(token.RBRACE) {
+			break
+		}
+		p.nextToken()
+		set.Elements = append(set.Elements, p.parseExpression(LOWEST))
+	}
+
+	if !p.expect(token.RBRACE) {
+		return nil
+	}
+
+	return set
+}
+
+func (p *Parser) parseSetComprehension(tok token.Token, element ast.Expression) ast.Expression {
+	comp := &ast.SetComprehension{Token: tok, Element: element}
+
+	for p.expectPeek(token.FOR) {
+		clause := &ast.ComprehensionClause{Token: p.curToken}
+
+		if !p.expect(token.IDENT) {
+			return nil
+		}
+		clause.Iterator = &ast.Identifier{Token: p.curToken, Value: p.curToken.Literal}
+
+		if !p.expect(token.IN) {
+			return nil
+		}
+		p.nextToken()
+		clause.Iterable = p.parseExpression(LOWEST)
+
+		for p.expectPeek(token.IF) {
+			p.nextToken()
+			clause.Conditions = append(clause.Conditions, p.parseExpression(LOWEST))
+		}
+
+		comp.Clauses = append(comp.Clauses, clause)
+	}
+
+	if !p.expect(token.RBRACE) {
+		return nil
+	}
+
+	return comp
+}
+
 func (p *Parser) parseExpressionList(end token.TokenType) []ast.Expression {
 	list := []ast.Expression{}
 
@@ -406,23 +663,48 @@ func (p *Parser) parseExpressionList(end token.TokenType) []ast.Expression {
 
 	for p.peekTokenIs(token.COMMA) {
 		p.nextToken()
+		// A trailing comma may follow the last element
+		if p.peekTokenIs(end) {
+			break
+		}
 		p.nextToken()
 		list = append(list, p.parseExpression(LOWEST))
 	}
 
-	if !p.expectPeek(end) {
+	if !p.expect(end) {
 		return nil
 	}
 
 	return list
 }
 
+// parseGroupedExpression parses a parenthesized expression, or a tuple when the
+// parentheses are empty or contain a comma
 func (p *Parser) parseGroupedExpression() ast.Expression {
-	p.nextToken()
+	tok := p.curToken
+
+	if p.peekTokenIs(token.RPAREN) {
+		p.nextToken()
+		return &ast.TupleLiteral{Token: tok, Elements: []ast.Expression{}}
+	}
 
+	p.nextToken()
 	exp := p.parseExpression(LOWEST)
 
-	if !p.expectPeek(token.RPAREN) {
+	if p.peekTokenIs(token.COMMA) {
+		tuple := &ast.TupleLiteral{Token: tok, Elements: []ast.Expression{exp}}
+		for p.peekTokenIs(token.COMMA) {
+			p.nextToken()
+			if p.peekTokenIs(token.RPAREN) {
+				break
+			}
+			p.nextToken()
+			tuple.Elements = append(tuple.Elements, p.parseExpression(LOWEST))
+		}
+		exp = tuple
+	}
+
+	if !p.expect(token.RPAREN) {
 		return nil
 	}
 
@@ -435,73 +717,58 @@ func (p *Parser) parseIfExpression() ast.Expression {
 	p.nextToken()
 	expression.Condition = p.parseExpression(LOWEST)
 
-	if !p.expectPeek(token.COLON) {
+	if !p.expect(token.COLON) {
 		return nil
 	}
 
 	expression.Consequence = p.parseBlockStatement()
-	p.advanceWhitespace()
 
-	if p.curTokenIs(token.ELSE) {
-		p.skipFlag = true
-		p.nextToken()
-		if !p.curTokenIs(token.COLON) {
+	if p.expectPeek(token.ELSE) {
+		if !p.expect(token.COLON) {
 			return nil
 		}
-		p.nextToken()
 		expression.Alternative = p.parseBlockStatement()
 	}
 
 	return expression
 }
 
+// parseBlockStatement parses the body following a colon. The body is either the
+// rest of the line, or an indented block of lines ending at its DEDENT. In both
+// cases curToken is left on the final NEWLINE or DEDENT, so the next statement
+// starts on the following token
 func (p *Parser) parseBlockStatement() *ast.BlockStatement {
 	block := &ast.BlockStatement{Token: p.curToken}
 	block.Statements = []ast.Statement{}
 
-	indentLevel := p.spacing
-
-	p.nextToken()
-	p.advanceWhitespace()
+	// A header at the very end of the input has an empty body
+	if p.peekTokenIs(token.EOF) {
+		return block
+	}
 
-	for p.spacing > indentLevel && !p.curTokenIs(token.EOF) {
-		stmt := p.parseStatement()
-		if stmt != nil {
+	if !p.expectPeek(token.NEWLINE) {
+		p.nextToken()
+		if stmt := p.parseStatementLine(); stmt != nil {
 			block.Statements = append(block.Statements, stmt)
 		}
-		if p.skipFlag {
-			p.nextToken()
-		} else {
-			p.skipFlag = true
-		}
-		p.advanceWhitespace()
+		p.expectPeek(token.NEWLINE)
+		return block
 	}
 
-	p.skipFlag = false
-
-	return block
-}
-
-func (p *Parser) advanceWhitespace() {
-	for p.curTokenIs(token.TAB) || p.curTokenIs(token.NEWLINE) {
-		p.parseSpacing()
+	if !p.expectPeek(token.INDENT) {
+		p.errorAt(p.peekToken, "expected an indented block, got %s", describeToken(p.peekToken))
+		return block
 	}
-}
-
-func (p *Parser) parseSpacing() {
-	switch p.curToken.Type {
-	case token.TAB:
-		p.spacing += 1
-		p.nextToken()
+	p.nextToken()
 
-	case token.NEWLINE:
-		p.spacing = 0
+	for !p.curTokenIs(token.DEDENT) && !p.curTokenIs(token.EOF) {
+		if stmt := p.parseStatementLine(); stmt != nil {
+			block.Statements = append(block.Statements, stmt)
+		}
 		p.nextToken()
-	default:
-		msg := fmt.Sprintf("expected next token to be %s, got %s instead",
-			token.TAB, p.peekToken.Type)
-		p.errors = append(p.errors, msg)
 	}
+
+	return block
 }
 
 func (p *Parser) parseFunctionParameters() []*ast.Identifier {
@@ -512,19 +779,25 @@ func (p *Parser) parseFunctionParameters() []*ast.Identifier {
 		return identifiers
 	}
 
-	p.nextToken()
-
+	if !p.expect(token.IDENT) {
+		return nil
+	}
 	ident := &ast.Identifier{Token: p.curToken, Value: p.curToken.Literal}
 	identifiers = append(identifiers, ident)
 
 	for p.peekTokenIs(token.COMMA) {
 		p.nextToken()
-		p.nextToken()
+		if p.peekTokenIs(token.RPAREN) {
+			break
+		}
+		if !p.expect(token.IDENT) {
+			return nil
+		}
 		ident := &ast.Identifier{Token: p.curToken, Value: p.curToken.Literal}
 		identifiers = append(identifiers, ident)
 	}
 
-	if !p.expectPeek(token.RPAREN) {
+	if !p.expect(token.RPAREN) {
 		return nil
 	}
 
@@ -533,48 +806,115 @@ func (p *Parser) parseFunctionParameters() []*ast.Identifier {
 
 func (p *Parser) parseCallExpression(function ast.Expression) ast.Expression {
 	exp := &ast.CallExpression{Token: p.curToken, Function: function}
-	exp.Arguments = p.parseExpressionList(token.RPAREN)
+	exp.Arguments, exp.Keywords = p.parseCallArguments()
 
 	return exp
 }
 
+// parseCallArguments parses positional arguments followed by name=value keyword arguments
+func (p *Parser) parseCallArguments() ([]ast.Expression, []*ast.KeywordArgument) {
+	args := []ast.Expression{}
+	keywords := []*ast.KeywordArgument{}
+
+	if p.peekTokenIs(token.RPAREN) {
+		p.nextToken()
+		return args, keywords
+	}
+
+	for {
+		p.nextToken()
+
+		if p.curTokenIs(token.IDENT) && p.peekTokenIs(token.ASSIGN) {
+			keyword := &ast.KeywordArgument{Token: p.curToken, Name: p.curToken.Literal}
+			p.nextToken()
+			p.nextToken()
+			keyword.Value = p.parseExpression(LOWEST)
+			keywords = append(keywords, keyword)
+		} else {
+			if len(keywords) > 0 {
+				p.errorAt(p.curToken, "positional argument follows keyword argument")
+			}
+			args = append(args, p.parseExpression(LOWEST))
+		}
+
+		if !p.peekTokenIs(token.COMMA) {
+			break
+		}
+		p.nextToken()
+		if p.peekTokenIs(token.RPAREN) {
+			break
+		}
+	}
+
+	if !p.expect(token.RPAREN) {
+		return nil, nil
+	}
+
+	return args, keywords
+}
+
 func (p *Parser) parseIndexExpression(left ast.Expression) ast.Expression {
 	exp := &ast.IndexExpression{Token: p.curToken, Left: left}
 
-	p.nextToken()
-	exp.Index = p.parseExpression(LOWEST)
+	if !p.peekTokenIs(token.COLON) {
+		p.nextToken()
+		exp.Index = p.parseExpression(LOWEST)
+	}
 
 	if p.expectPeek(token.COLON) {
-		p.nextToken()
 		exp.Colon = true
-		exp.EndIndex = p.parseExpression(LOWEST)
-	} else {
-		exp.Colon = false
+		exp.EndIndex = p.parseSliceBound()
+
+		if p.expectPeek(token.COLON) {
+			exp.Step = p.parseSliceBound()
+		}
 	}
 
-	if !p.expectPeek(token.RBRACKET) {
+	if !p.expect(token.RBRACKET) {
 		return nil
 	}
 
 	if p.expectPeek(token.ASSIGN) {
 		p.nextToken()
 		stmt := &ast.IndexAssignExpression{Token: exp.Token, Left: exp.Left, Index: exp.Index,
-			Colon: exp.Colon, EndIndex: exp.EndIndex}
+			Colon: exp.Colon, EndIndex: exp.EndIndex, Step: exp.Step}
 		stmt.Value = p.parseExpression(LOWEST)
 		return stmt
 	}
 	return exp
 }
 
+// parseSliceBound parses the stop or step of a slice, which is nil when omitted
+func (p *Parser) parseSliceBound() ast.Expression {
+	if p.peekTokenIs(token.COLON) || p.peekTokenIs(token.RBRACKET) {
+		return nil
+	}
+	p.nextToken()
+	return p.parseExpression(LOWEST)
+}
+
 func (p *Parser) parseObjectMethod(left ast.Expression) ast.Expression {
-	expression := &ast.ObjectMethod{Obj: left, Token: p.curToken}
+	dot := p.curToken
 
-	if !p.expectPeek(token.IDENT) {
+	if !p.expect(token.IDENT) {
 		return nil
 	}
-	expression.Method = p.parseExpression(LOWEST)
+	attribute := &ast.Identifier{Token: p.curToken, Value: p.curToken.Literal}
 
-	return expression
+	switch {
+	case p.expectPeek(token.LPAREN):
+		method := p.parseCallExpression(attribute)
+		return &ast.ObjectMethod{Obj: left, Token: dot, Method: method}
+
+	case p.expectPeek(token.ASSIGN):
+		p.nextToken()
+		stmt := &ast.AttributeAssignExpression{Obj: left, Token: dot, Attribute: attribute}
+		stmt.Value = p.parseExpression(LOWEST)
+		return stmt
+
+	default:
+		return &ast.AttributeExpression{Obj: left, Token: dot, Attribute: attribute}
+	}
 }
 
 func (p *Parser) parseInExpression(left ast.Expression) ast.Expression {
@@ -649,6 +989,16 @@ func (p *Parser) expectPeek(t token.TokenType) bool {
 	}
 }
 
+// expect is expectPeek for tokens the grammar requires, recording a syntax
+// error when the next token is anything else
+func (p *Parser) expect(t token.TokenType) bool {
+	if p.expectPeek(t) {
+		return true
+	}
+	p.peekError(t)
+	return false
+}
+
 func (p *Parser) peekPrecedence() int {
 	if p, ok := precedences[p.peekToken.Type]; ok {
 		return p
@@ -667,19 +1017,80 @@ func (p *Parser) curPrecedence() int {
 Error functions
 */
 
+// Errors returns the errors of the lexer, such as unterminated strings, followed
+// by the errors of the parser
 func (p *Parser) Errors() []string {
-	return p.errors
+	return append(append([]string{}, p.l.Errors()...), p.errors...)
 }
 
-/*
-func (p *Parser) peekError(t token.TokenType) {
-	msg := fmt.Sprintf("expected next token to be %s, got %s instead",
-		t, p.peekToken.Type)
+// errorAt records a syntax error at the position of tok. Only the first error of
+// a statement is kept, since the ones after it are usually caused by it
+func (p *Parser) errorAt(tok token.Token, format string, a ...interface{}) {
+	if p.failed {
+		return
+	}
+	p.failed = true
+
+	if p.origin != nil {
+		tok = *p.origin
+	}
+	// Blocks are recovered by their indentation, so once it is broken the lexer's
+	// error is the only reliable one
+	if line := p.l.IndentErrorLine(); line > 0 && tok.Line >= line {
+		return
+	}
+	msg := fmt.Sprintf(format, a...)
+	if tok.Line > 0 {
+		msg = fmt.Sprintf("%s at line %d, column %d", msg, tok.Line, tok.Column)
+	}
 	p.errors = append(p.errors, msg)
 }
-*/
 
-func (p *Parser) noPrefixParseFnError(t token.TokenType) {
-	msg := fmt.Sprintf("no prefix parse function for %s found", t)
-	p.errors = append(p.errors, msg)
+func (p *Parser) peekError(t token.TokenType) {
+	p.errorAt(p.peekToken, "expected %s, got %s", describeType(t), describeToken(p.peekToken))
+}
+
+func (p *Parser) noPrefixParseFnError(tok token.Token) {
+	p.errorAt(tok, "expected an expression, got %s", describeToken(tok))
+}
+
+// describeType names a kind of token in an error message
+func describeType(t token.TokenType) string {
+	switch t {
+	case token.IDENT:
+		return "a name"
+	case token.NEWLINE:
+		return "end of line"
+	case token.INDENT:
+		return "an indented block"
+	case token.EOF:
+		return "end of input"
+	}
+
+	// Keyword types are their keyword in capitals
+	if lower := strings.ToLower(string(t)); lower != string(t) {
+		return "'" + lower + "'"
+	}
+	return "'" + string(t) + "'"
+}
+
+// describeToken names the token found in an error message
+func describeToken(tok token.Token) string {
+	switch tok.Type {
+	case token.IDENT:
+		return fmt.Sprintf("name '%s'", tok.Literal)
+	case token.INT, token.FLOAT:
+		return fmt.Sprintf("number %s", tok.Literal)
+	case token.STRING, token.BYTES, token.FSTRING:
+		return "string"
+	case token.NEWLINE:
+		return "end of line"
+	case token.INDENT:
+		return "unexpected indent"
+	case token.DEDENT:
+		return "unindent"
+	case token.EOF:
+		return "end of input"
+	}
+	return "'" + tok.Literal + "'"
 }
@@ -4,6 +4,7 @@ import (
 	"fmt"
 	"simpyl/ast"
 	"simpyl/lexer"
+	"strings"
 	"testing"
 )
 
@@ -11,33 +12,171 @@ import (
 Statements Testing
 */
 
-func TestSpacing(t *testing.T) {
+func TestIndentedBlocks(t *testing.T) {
 	tests := []struct {
 		input         string
-		expectedCount int
+		expectedCount int // statements in the top level
+		expectedBody  int // statements in the body of the first statement
 	}{
-		{"let x = 5;", 0},
-		{"	let y = true;", 1},
-		{"		foobar = y;", 2},
-		{`	value = other;
-5;`, 0},
-		{"\t\t\n\t5;", 1},
+		{"def f():\n\treturn 1\nf()", 2, 1},
+		{"def f():\n    x = 1\n    return x\nf()", 2, 2},
+		{"def f():\n  x = '    '\n\n  # comment\n  return x\n", 1, 2},
+		{"while a:\n  for i in b:\n    c\n  d\ne", 2, 2},
+		{"while a:\n\tfor i in b:\n\t\tc\n\n\n\td\ne", 2, 2},
+		{"while a: b\nc", 2, 1},
+		{"def f():\n\tif a:\n\t\treturn 1\n\telse:\n\t\treturn 2", 1, 1},
 	}
+
 	for _, tt := range tests {
 		l := lexer.New(tt.input)
 		p := New(l)
 		program := p.ParseProgram()
 		checkParserErrors(t, p)
 
-		if !(len(program.Statements) > 0) {
-			t.Fatal("program.Statements does not contain statements",
-				len(program.Statements))
+		if len(program.Statements) != tt.expectedCount {
+			t.Fatalf("%q: program.Statements has %d statements, want %d", tt.input,
+				len(program.Statements), tt.expectedCount)
+		}
+
+		var body *ast.BlockStatement
+		switch stmt := program.Statements[0].(type) {
+		case *ast.FunctionStatement:
+			body = stmt.Body
+		case *ast.WhileStatement:
+			body = stmt.Body
+		}
+		if len(body.Statements) != tt.expectedBody {
+			t.Errorf("%q: body has %d statements, want %d", tt.input, len(body.Statements), tt.expectedBody)
 		}
+	}
+}
+
+func TestIndentationErrors(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected []string
+	}{
+		{"\tx = 1", []string{"unexpected indent at line 1, column 2"}},
+		{"x = 1\n    y = 2", []string{"unexpected indent at line 2, column 5"}},
+		{"def f():\nreturn 1", []string{"expected an indented block, got 'return' at line 2, column 1"}},
+		{"if a:\n    b\n  c", []string{"inconsistent dedent: unindent does not match any outer indentation level at line 3, column 1"}},
+		{"if a:\n  if b:\n\tc = 1\nd = = 2\n", []string{"inconsistent use of tabs and spaces in indentation at line 3, column 1"}},
+		{"x = = 1\nif a:\n  b\n\tc\n", []string{
+			"inconsistent use of tabs and spaces in indentation at line 4, column 1",
+			"expected an expression, got '=' at line 1, column 5",
+		}},
+	}
+
+	for _, tt := range tests {
+		p := New(lexer.New(tt.input))
+		p.ParseProgram()
+
+		if errors := p.Errors(); fmt.Sprint(errors) != fmt.Sprint(tt.expected) {
+			t.Errorf("%q: wrong errors.\nexpected=%q\ngot=%q", tt.input, tt.expected, errors)
+		}
+	}
+}
+
+func TestImplicitLineJoining(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{"x = [\n    1,\n    2,\n]", "let x = [1, 2];"},
+		{"f(a,\n\tb=2,\n)", "f(a, b=2)"},
+		{"def f():\n    return g(1,\n  2)\nh", "h"},
+		{"x = 1 + \\\n    2", "let x = (1 + 2);"},
+		{"x = (1 +  # comment\n  2)", "let x = (1 + 2);"},
+	}
+
+	for _, tt := range tests {
+		p := New(lexer.New(tt.input))
+		program := p.ParseProgram()
+		checkParserErrors(t, p)
+
+		last := program.Statements[len(program.Statements)-1]
+		if last.String() != tt.expected {
+			t.Errorf("%q: last statement wrong. expected=%q, got=%q", tt.input, tt.expected, last.String())
+		}
+	}
+}
+
+func TestErrorRecovery(t *testing.T) {
+	input := `def f(x y):
+    return x
+y = 1
+if y
+    z = 2
+a = 1 2
+class C
+b = [1, 2
+    3]
+c = 3
+`
+
+	expected := []string{
+		"expected ')', got name 'y' at line 1, column 9",
+		"expected ':', got end of line at line 4, column 5",
+		"expected end of line, got number 2 at line 6, column 7",
+		"expected ':', got end of line at line 7, column 8",
+		"expected ']', got number 3 at line 9, column 5",
+	}
+
+	p := New(lexer.New(input))
+	program := p.ParseProgram()
 
-		if p.spacing != tt.expectedCount {
-			t.Fatalf("spacing was parsed incorrectly. Expected=%d, got=%d", tt.expectedCount, p.spacing)
+	errors := p.Errors()
+	if len(errors) != len(expected) {
+		t.Fatalf("wrong number of errors. expected=%d, got=%d: %q", len(expected), len(errors), errors)
+	}
+	for i, msg := range expected {
+		if errors[i] != msg {
+			t.Errorf("errors[%d] wrong. expected=%q, got=%q", i, msg, errors[i])
 		}
+	}
 
+	// Only the statements without errors are kept
+	var statements []string
+	for _, stmt := range program.Statements {
+		statements = append(statements, stmt.String())
+	}
+	if fmt.Sprint(statements) != "[let y = 1; let c = 3;]" {
+		t.Errorf("wrong statements kept. got=%q", statements)
+	}
+}
+
+// An unclosed bracket in a block header must not join the rest of the file into
+// one line, or the errors after it would be lost
+func TestUnclosedBracketRecovery(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected []string
+	}{
+		{"def f(:\n    return 1\nx = = 2\n", []string{
+			"expected a name, got ':' at line 1, column 7",
+			"expected an expression, got '=' at line 3, column 5",
+		}},
+		{"def f(a, 2):\n    return a\nx = = 2\n", []string{
+			"expected a name, got number 2 at line 1, column 10",
+			"expected an expression, got '=' at line 3, column 5",
+		}},
+		{"if (a:\n    b = 1\nc = )\n", []string{
+			"expected ')', got ':' at line 1, column 6",
+			"expected an expression, got ')' at line 3, column 5",
+		}},
+		{"x = f(1,\ndef g():\n    return [\nclass C:\n    y = 1\n", []string{
+			"expected an expression, got end of line at line 1, column 9",
+			"expected an expression, got end of line at line 3, column 13",
+		}},
+	}
+
+	for _, tt := range tests {
+		p := New(lexer.New(tt.input))
+		p.ParseProgram()
+
+		if errors := p.Errors(); fmt.Sprint(errors) != fmt.Sprint(tt.expected) {
+			t.Errorf("%q: wrong errors.\nexpected=%q\ngot=%q", tt.input, tt.expected, errors)
+		}
 	}
 }
 
@@ -47,7 +186,7 @@ func TestLetStatements(t *testing.T) {
 		expectedIdentifier string
 		expectedValue      interface{}
 	}{
-		{"			let x = 5;", "x", 5},
+		{"\t\n  \nlet x = 5;", "x", 5},
 		{"let y = true;", "y", true},
 		{"foobar = y;", "foobar", "y"},
 	}
@@ -179,6 +318,144 @@ func TestFunctionStatementParsing(t *testing.T) {
 	testInfixExpression(t, bodyStmt.Expression, "x", "+", "y")
 }
 
+func TestClassStatementParsing(t *testing.T) {
+	input := `class Dog(Animal, Pet):
+	kind = "dog"
+	def speak(self):
+		return self.name`
+
+	l := lexer.New(input)
+	p := New(l)
+	program := p.ParseProgram()
+	checkParserErrors(t, p)
+
+	if len(program.Statements) != 1 {
+		t.Fatalf("program.Statements does not contain %d statements. got=%d\n",
+			1, len(program.Statements))
+	}
+
+	stmt, ok := program.Statements[0].(*ast.ClassStatement)
+	if !ok {
+		t.Fatalf("program.Statements[0] is not ast.ClassStatement. got=%T",
+			program.Statements[0])
+	}
+
+	if stmt.Name != "Dog" {
+		t.Fatalf("class name wrong. want Dog, got=%s", stmt.Name)
+	}
+
+	if len(stmt.Bases) != 2 {
+		t.Fatalf("class bases wrong. want 2, got=%d\n", len(stmt.Bases))
+	}
+	testIdentifier(t, stmt.Bases[0], "Animal")
+	testIdentifier(t, stmt.Bases[1], "Pet")
+
+	if len(stmt.Body.Statements) != 2 {
+		t.Fatalf("class.Body.Statements has not 2 statements. got=%d\n",
+			len(stmt.Body.Statements))
+	}
+
+	if !testLetStatement(t, stmt.Body.Statements[0], "kind") {
+		return
+	}
+
+	method, ok := stmt.Body.Statements[1].(*ast.FunctionStatement)
+	if !ok {
+		t.Fatalf("class body stmt is not ast.FunctionStatement. got=%T",
+			stmt.Body.Statements[1])
+	}
+	testIdentifier(t, method.Parameters[0], "self")
+}
+
+func TestImportStatementParsing(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{"import math", "import math"},
+		{"import math as m", "import math as m"},
+		{"from shapes import area", "from shapes import area"},
+		{"from shapes import area, Point as P", "from shapes import area, Point as P"},
+	}
+
+	for _, tt := range tests {
+		l := lexer.New(tt.input)
+		p := New(l)
+		program := p.ParseProgram()
+		checkParserErrors(t, p)
+
+		if len(program.Statements) != 1 {
+			t.Fatalf("program.Statements does not contain %d statements. got=%d\n",
+				1, len(program.Statements))
+		}
+
+		actual := program.String()
+		if actual != tt.expected {
+			t.Errorf("expected=%q, got=%q", tt.expected, actual)
+		}
+	}
+
+	l := lexer.New("from shapes import area as a, Point")
+	p := New(l)
+	program := p.ParseProgram()
+	checkParserErrors(t, p)
+
+	stmt, ok := program.Statements[0].(*ast.FromImportStatement)
+	if !ok {
+		t.Fatalf("program.Statements[0] is not ast.FromImportStatement. got=%T",
+			program.Statements[0])
+	}
+	testIdentifier(t, stmt.Module, "shapes")
+	testIdentifier(t, stmt.Names[0], "area")
+	testIdentifier(t, stmt.Aliases[0], "a")
+	testIdentifier(t, stmt.Names[1], "Point")
+	if stmt.Aliases[1] != nil {
+		t.Errorf("stmt.Aliases[1] is not nil. got=%s", stmt.Aliases[1])
+	}
+}
+
+func TestDelStatementParsing(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{"del x", "del x"},
+		{"del a[0], b.c", "del (a[0]), b.c"},
+		{"del a[1:5:2]", "del (a[1:5:2])"},
+	}
+
+	for _, tt := range tests {
+		l := lexer.New(tt.input)
+		p := New(l)
+		program := p.ParseProgram()
+		checkParserErrors(t, p)
+
+		if _, ok := program.Statements[0].(*ast.DelStatement); !ok {
+			t.Fatalf("program.Statements[0] is not ast.DelStatement. got=%T",
+				program.Statements[0])
+		}
+		if actual := program.String(); actual != tt.expected {
+			t.Errorf("expected=%q, got=%q", tt.expected, actual)
+		}
+	}
+
+	l := lexer.New("del f()")
+	p := New(l)
+	p.ParseProgram()
+
+	errors := p.Errors()
+	if len(errors) == 0 || errors[0] != "cannot delete f() at line 1, column 1" {
+		t.Errorf("wrong parser errors. got=%v", errors)
+	}
+
+	// An incomplete target is reported once, without being shown
+	p = New(lexer.New("del !"))
+	p.ParseProgram()
+	if errors := p.Errors(); len(errors) != 1 || strings.Contains(errors[0], "cannot delete") {
+		t.Errorf("wrong parser errors. got=%v", errors)
+	}
+}
+
 func TestForStatementParsing(t *testing.T) {
 	input := `x = 0
 for i in range(5):
@@ -498,6 +775,9 @@ func TestParsingDictLiteralsStringKeys(t *testing.T) {
 		expectedValue := expected[literal.String()]
 		testIntegerLiteral(t, value, expectedValue)
 	}
+	if dict.String() != "{one:1, two:2, three:3}" {
+		t.Errorf("keys not in source order. got=%s", dict.String())
+	}
 }
 
 func TestParsingEmptyDictLiteral(t *testing.T) {
@@ -519,6 +799,37 @@ func TestParsingEmptyDictLiteral(t *testing.T) {
 	}
 }
 
+func TestParsingSetLiterals(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{"{1, 2 + 3}", "{1, (2 + 3)}"},
+		{"{a,}", "{a}"},
+		{"{x * x for x in s}", "{(x * x) for x in s}"},
+		{"{x for x in s if x > 1 if x < 5}", "{x for x in s if (x > 1) if (x < 5)}"},
+		{"{x + y for x in a for y in b}", "{(x + y) for x in a for y in b}"},
+	}
+
+	for _, tt := range tests {
+		l := lexer.New(tt.input)
+		p := New(l)
+		program := p.ParseProgram()
+		checkParserErrors(t, p)
+
+		stmt := program.Statements[0].(*ast.ExpressionStatement)
+		switch stmt.Expression.(type) {
+		case *ast.SetLiteral, *ast.SetComprehension:
+		default:
+			t.Fatalf("exp is not a set literal or comprehension. got=%T", stmt.Expression)
+		}
+
+		if actual := program.String(); actual != tt.expected {
+			t.Errorf("expected=%q, got=%q", tt.expected, actual)
+		}
+	}
+}
+
 func TestParsingDictLiteralsWithExpressions(t *testing.T) {
 	input := `{"one": 0 + 1, "two": 10 - 8, "three": 15 / 5}`
 
@@ -692,6 +1003,18 @@ func TestOperatorPrecedenceParsing(t *testing.T) {
 			"a * b / c",
 			"((a * b) / c)",
 		},
+		{
+			"a + b % c",
+			"(a + (b % c))",
+		},
+		{
+			"(a, b + 1)",
+			"(a, (b + 1))",
+		},
+		{
+			"(a,)",
+			"(a,)",
+		},
 		{
 			"a + b / c",
 			"(a + (b / c))",
@@ -776,6 +1099,22 @@ func TestOperatorPrecedenceParsing(t *testing.T) {
 			"add(a * b[2], b[1], 2 * [1, 2][1])",
 			"add((a * (b[2])), (b[1]), (2 * ([1, 2][1])))",
 		},
+		{
+			"a < b | c + d",
+			"(a < (b | (c + d)))",
+		},
+		{
+			"a | b ^ c & d",
+			"(a | (b ^ (c & d)))",
+		},
+		{
+			"a & b <= c ^ d",
+			"((a & b) <= (c ^ d))",
+		},
+		{
+			"a >= b == true",
+			"((a >= b) == true)",
+		},
 	}
 
 	for _, tt := range tests {
@@ -972,6 +1311,126 @@ func TestCallExpressionParsing(t *testing.T) {
 	testInfixExpression(t, exp.Arguments[2], 4, "+", 5)
 }
 
+func TestCallExpressionKeywordParsing(t *testing.T) {
+	input := "isclose(a, b, rel_tol=0.1, abs_tol=x * 2)"
+
+	l := lexer.New(input)
+	p := New(l)
+	program := p.ParseProgram()
+	checkParserErrors(t, p)
+
+	stmt := program.Statements[0].(*ast.ExpressionStatement)
+	exp, ok := stmt.Expression.(*ast.CallExpression)
+	if !ok {
+		t.Fatalf("stmt.Expression is not ast.CallExpression. got=%T",
+			stmt.Expression)
+	}
+
+	if len(exp.Arguments) != 2 {
+		t.Fatalf("wrong length of arguments. got=%d", len(exp.Arguments))
+	}
+	if len(exp.Keywords) != 2 {
+		t.Fatalf("wrong length of keywords. got=%d", len(exp.Keywords))
+	}
+
+	if exp.Keywords[0].Name != "rel_tol" {
+		t.Errorf("keyword name is not %q. got=%q", "rel_tol", exp.Keywords[0].Name)
+	}
+	testInfixExpression(t, exp.Keywords[1].Value, "x", "*", 2)
+
+	if exp.String() != "isclose(a, b, rel_tol=0.1, abs_tol=(x * 2))" {
+		t.Errorf("exp.String() wrong. got=%q", exp.String())
+	}
+}
+
+func TestCallExpressionKeywordErrors(t *testing.T) {
+	l := lexer.New("f(x=1, 2)")
+	p := New(l)
+	p.ParseProgram()
+
+	errors := p.Errors()
+	if len(errors) == 0 || errors[0] != "positional argument follows keyword argument at line 1, column 8" {
+		t.Errorf("wrong parser errors. got=%v", errors)
+	}
+}
+
+func TestLexerErrorsAreReported(t *testing.T) {
+	l := lexer.New("x = 'abc")
+	p := New(l)
+	p.ParseProgram()
+
+	errors := p.Errors()
+	if len(errors) == 0 || errors[0] != "unterminated string literal at line 1, column 5" {
+		t.Errorf("wrong parser errors. got=%v", errors)
+	}
+}
+
+func TestFormattedStringParsing(t *testing.T) {
+	input := `f"total: {price * 2!r:>{width}.2f} {{done}}"`
+
+	l := lexer.New(input)
+	p := New(l)
+	program := p.ParseProgram()
+	checkParserErrors(t, p)
+
+	stmt := program.Statements[0].(*ast.ExpressionStatement)
+	fs, ok := stmt.Expression.(*ast.FormattedString)
+	if !ok {
+		t.Fatalf("exp is not ast.FormattedString. got=%T", stmt.Expression)
+	}
+
+	if len(fs.Parts) != 3 {
+		t.Fatalf("wrong number of parts. got=%d", len(fs.Parts))
+	}
+
+	if text, ok := fs.Parts[0].(*ast.StringLiteral); !ok || text.Value != "total: " {
+		t.Errorf("parts[0] is not literal %q. got=%s", "total: ", fs.Parts[0])
+	}
+
+	field, ok := fs.Parts[1].(*ast.FormattedValue)
+	if !ok {
+		t.Fatalf("parts[1] is not ast.FormattedValue. got=%T", fs.Parts[1])
+	}
+	testInfixExpression(t, field.Value, "price", "*", 2)
+	if field.Conversion != 'r' {
+		t.Errorf("conversion is not 'r'. got=%q", field.Conversion)
+	}
+	if field.String() != "{(price * 2)!r:>{width}.2f}" {
+		t.Errorf("field.String() wrong. got=%q", field.String())
+	}
+
+	if text, ok := fs.Parts[2].(*ast.StringLiteral); !ok || text.Value != " {done}" {
+		t.Errorf("parts[2] is not literal %q. got=%s", " {done}", fs.Parts[2])
+	}
+}
+
+func TestFormattedStringErrors(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{`f"{x"`, "f-string: expecting '}' at line 1, column 1"},
+		{`f"}"`, "f-string: single '}' is not allowed at line 1, column 1"},
+		{`f"{}"`, "f-string: empty expression not allowed at line 1, column 1"},
+		{`f"{x!z}"`, "f-string: invalid conversion character: expected 's' or 'r' at line 1, column 1"},
+		{`f"{x y}"`, "f-string: expecting '}' at line 1, column 1"},
+		{`x = 1
+y = f"{x!z}"`, "f-string: invalid conversion character: expected 's' or 'r' at line 2, column 5"},
+		{`f"{ x + }"`, "f-string: expected an expression, got end of input at line 1, column 1"},
+	}
+
+	for _, tt := range tests {
+		l := lexer.New(tt.input)
+		p := New(l)
+		p.ParseProgram()
+
+		errors := p.Errors()
+		if len(errors) == 0 || errors[0] != tt.expected {
+			t.Errorf("%s: wrong parser errors. expected=%q, got=%v", tt.input, tt.expected, errors)
+		}
+	}
+}
+
 func TestParsingIndexExpressions(t *testing.T) {
 	input := "myArray[0:1 + 1]"
 	l := lexer.New(input)
@@ -1006,6 +1465,50 @@ func TestParsingIndexExpressions(t *testing.T) {
 	}
 }
 
+func TestParsingSliceStep(t *testing.T) {
+	l := lexer.New("a[0:6:1 + 1] = b")
+	p := New(l)
+	program := p.ParseProgram()
+	checkParserErrors(t, p)
+
+	stmt := program.Statements[0].(*ast.ExpressionStatement)
+	assign, ok := stmt.Expression.(*ast.IndexAssignExpression)
+	if !ok {
+		t.Fatalf("exp not *ast.IndexAssignExpression. got=%T", stmt.Expression)
+	}
+
+	testIntegerLiteral(t, assign.EndIndex, 6)
+	testInfixExpression(t, assign.Step, 1, "+", 1)
+	testIdentifier(t, assign.Value, "b")
+}
+
+func TestParsingOmittedSliceBounds(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{"a[:3]", "(a[:3])"},
+		{"a[1:]", "(a[1:])"},
+		{"a[:]", "(a[:])"},
+		{"a[::-1]", "(a[::(-1)])"},
+		{"a[-3:]", "(a[(-3):])"},
+		{"a[1::2]", "(a[1::2])"},
+		{"a[:2:]", "(a[:2])"},
+		{"a[::2] = b", "a[::2] = b"},
+	}
+
+	for _, tt := range tests {
+		l := lexer.New(tt.input)
+		p := New(l)
+		program := p.ParseProgram()
+		checkParserErrors(t, p)
+
+		if actual := program.String(); actual != tt.expected {
+			t.Errorf("expected=%q, got=%q", tt.expected, actual)
+		}
+	}
+}
+
 func TestParsingIndexAssignExpressions(t *testing.T) {
 	input := "myArray[0] = 1"
 	l := lexer.New(input)
@@ -1070,6 +1573,34 @@ func TestParsingObjectMethodExpression(t *testing.T) {
 	}
 }
 
+func TestParsingAttributeExpressions(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{"a.b", "a.b"},
+		{"a.b.c", "a.b.c"},
+		{"a.b + 1", "(a.b + 1)"},
+		{"-a.b", "(-a.b)"},
+		{"a.b(1).c(2)", "a.b(1).c(2)"},
+		{"a.b(1) * 2", "(a.b(1) * 2)"},
+		{"super().f(x)", "super().f(x)"},
+		{"self.x = y + 1", "self.x = (y + 1)"},
+	}
+
+	for _, tt := range tests {
+		l := lexer.New(tt.input)
+		p := New(l)
+		program := p.ParseProgram()
+		checkParserErrors(t, p)
+
+		actual := program.String()
+		if actual != tt.expected {
+			t.Errorf("expected=%q, got=%q", tt.expected, actual)
+		}
+	}
+}
+
 func TestParsingInExpression(t *testing.T) {
 	input := "1 in list"
 	l := lexer.New(input)
@@ -1219,3 +1750,32 @@ func checkParserErrors(t *testing.T, p *Parser) {
 	}
 	t.FailNow()
 }
+
+// FuzzParseProgram checks that the parser never panics, whether or not the input
+// is valid, and that the program it returns can be printed
+func FuzzParseProgram(f *testing.F) {
+	seeds := []string{
+		"let x = 5;\nx + 10\n",
+		"def f(a, b=1, *args):\n    return a * b\n",
+		"class A(B):\n    def m(self):\n        pass\n",
+		"for i, j in zip(a, b):\n    if i: break\n    else: continue\n",
+		"while x < 10:\n    x = x + 1\n",
+		"s = f\"{x!r:>{w}}\" + b'\\x00'\n",
+		"xs = [x for x in range(10) if x % 2]\n",
+		"d = {1: 2, **e}[1:2:3]\n",
+		"from a.b import c as d\n",
+		"del a[1], b.c\n",
+		"lambda: (yield)",
+		"def f(x:\n    return\n",
+		"if (",
+	}
+	for _, seed := range seeds {
+		f.Add(seed)
+	}
+
+	f.Fuzz(func(t *testing.T, input string) {
+		p := New(lexer.New(input))
+		program := p.ParseProgram()
+		_ = program.String()
+	})
+}
@@ -0,0 +1,88 @@
+package repl
+
+import (
+	"simpyl/evaluator"
+	"simpyl/lexer"
+	"simpyl/object"
+	"simpyl/parser"
+	"sort"
+	"strings"
+	"unicode"
+)
+
+// currentWord returns the dotted name that ends line, such as "foo.ba" in
+// "x = foo.ba"
+func currentWord(line string) string {
+	runes := []rune(line)
+	start := len(runes)
+	for start > 0 {
+		r := runes[start-1]
+		if r != '.' && r != '_' && !unicode.IsLetter(r) && !unicode.IsDigit(r) {
+			break
+		}
+		start--
+	}
+	return string(runes[start:])
+}
+
+// completer returns a completion function for names in env. A bare word completes
+// against variables and builtins; a dotted word completes the attributes of the
+// object named before the last dot
+func completer(env *object.Environment) func(string) []string {
+	return func(line string) []string {
+		word := currentWord(line)
+
+		dot := strings.LastIndex(word, ".")
+		if dot < 0 {
+			names := append(env.Names(), evaluator.BuiltinNames()...)
+			return matching(names, "", word)
+		}
+
+		obj := lookupDotted(word[:dot], env)
+		if obj == nil {
+			return nil
+		}
+		return matching(evaluator.Attributes(obj), word[:dot+1], word[dot+1:])
+	}
+}
+
+// lookupDotted evaluates a name such as "a.b.c". Anything that is not a plain
+// chain of identifiers is refused, so completion never calls functions
+func lookupDotted(name string, env *object.Environment) object.Object {
+	for _, part := range strings.Split(name, ".") {
+		if part == "" || unicode.IsDigit([]rune(part)[0]) {
+			return nil
+		}
+	}
+
+	p := parser.New(lexer.New(name))
+	program := p.ParseProgram()
+	if len(p.Errors()) != 0 {
+		return nil
+	}
+
+	obj := evaluator.Eval(program, env)
+	if obj == nil || obj.Type() == object.ERROR_OBJ {
+		return nil
+	}
+	return obj
+}
+
+// matching returns the sorted, distinct names starting with prefix, each joined
+// to base. Private names are only offered once an underscore has been typed
+func matching(names []string, base, prefix string) []string {
+	seen := map[string]bool{}
+	result := []string{}
+	for _, name := range names {
+		if !strings.HasPrefix(name, prefix) || seen[name] {
+			continue
+		}
+		if strings.HasPrefix(name, "_") && !strings.HasPrefix(prefix, "_") {
+			continue
+		}
+		seen[name] = true
+		result = append(result, base+name)
+	}
+	sort.Strings(result)
+	return result
+}
@@ -0,0 +1,362 @@
+package repl
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"unicode"
+)
+
+// MAX_HISTORY is the number of lines kept in the history file
+const MAX_HISTORY = 1000
+
+// errInterrupted is returned by ReadLine when the user presses Ctrl-C
+var errInterrupted = errors.New("KeyboardInterrupt")
+
+// lineReader reads one line of input after showing a prompt
+type lineReader interface {
+	ReadLine(prompt string) (string, error)
+}
+
+// scanReader reads lines from a non-interactive input such as a pipe
+type scanReader struct {
+	scanner *bufio.Scanner
+	out     io.Writer
+}
+
+func (r *scanReader) ReadLine(prompt string) (string, error) {
+	fmt.Fprint(r.out, prompt)
+	if !r.scanner.Scan() {
+		if err := r.scanner.Err(); err != nil {
+			return "", err
+		}
+		return "", io.EOF
+	}
+	return r.scanner.Text(), nil
+}
+
+// lineInput streams the lines of a lineReader, so that a script reading its
+// input gets the lines that follow it
+type lineInput struct {
+	reader lineReader
+	buf    []byte
+}
+
+func (r *lineInput) Read(p []byte) (int, error) {
+	if len(r.buf) == 0 {
+		line, err := r.reader.ReadLine("")
+		if err != nil {
+			return 0, err
+		}
+		r.buf = []byte(line + "\n")
+	}
+	n := copy(p, r.buf)
+	r.buf = r.buf[n:]
+	return n, nil
+}
+
+// editor is a line editor for terminals. It supports cursor movement, the usual
+// Emacs-style control keys, history recall and tab completion
+type editor struct {
+	fd       uintptr
+	in       *bufio.Reader
+	out      io.Writer
+	history  []string
+	histFile string
+	complete func(line string) []string // Candidates for the word ending the line
+
+	line   []rune
+	cursor int
+	prompt string
+}
+
+func newEditor(in *os.File, out io.Writer, histFile string, complete func(string) []string) *editor {
+	e := &editor{
+		fd:       in.Fd(),
+		in:       bufio.NewReader(in),
+		out:      out,
+		histFile: histFile,
+		complete: complete,
+	}
+	e.loadHistory()
+	return e
+}
+
+// historyPath returns the file history is saved to, taken from SIMPYL_HISTORY
+// or else ~/.simpyl_history. An empty path disables saving
+func historyPath() string {
+	if path, ok := os.LookupEnv("SIMPYL_HISTORY"); ok {
+		return path
+	}
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return ""
+	}
+	return filepath.Join(home, ".simpyl_history")
+}
+
+func (e *editor) loadHistory() {
+	if e.histFile == "" {
+		return
+	}
+	data, err := os.ReadFile(e.histFile)
+	if err != nil {
+		return
+	}
+
+	lines := strings.Split(strings.TrimRight(string(data), "\n"), "\n")
+	if len(lines) > MAX_HISTORY {
+		lines = lines[len(lines)-MAX_HISTORY:]
+	}
+	for _, line := range lines {
+		if line != "" {
+			e.history = append(e.history, line)
+		}
+	}
+}
+
+// addHistory records a line, appending it to the history file straight away so
+// that history survives the process being killed
+func (e *editor) addHistory(line string) {
+	if strings.TrimSpace(line) == "" {
+		return
+	}
+	if len(e.history) > 0 && e.history[len(e.history)-1] == line {
+		return
+	}
+	e.history = append(e.history, line)
+	if len(e.history) > MAX_HISTORY {
+		e.history = e.history[len(e.history)-MAX_HISTORY:]
+	}
+
+	if e.histFile == "" {
+		return
+	}
+	f, err := os.OpenFile(e.histFile, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0600)
+	if err != nil {
+		return
+	}
+	defer f.Close()
+	fmt.Fprintln(f, line)
+}
+
+func (e *editor) ReadLine(prompt string) (string, error) {
+	restore, err := makeRaw(e.fd)
+	if err != nil {
+		return "", err
+	}
+	defer restore()
+
+	e.line, e.cursor, e.prompt = nil, 0, prompt
+	histIdx := len(e.history)
+	saved := ""
+
+	e.refresh()
+	for {
+		r, _, err := e.in.ReadRune()
+		if err != nil {
+			return "", err
+		}
+
+		switch r {
+		case '\r', '\n':
+			fmt.Fprint(e.out, "\n")
+			line := string(e.line)
+			e.addHistory(line)
+			return line, nil
+		case 3: // Ctrl-C
+			fmt.Fprint(e.out, "^C\n")
+			return "", errInterrupted
+		case 4: // Ctrl-D
+			if len(e.line) == 0 {
+				fmt.Fprint(e.out, "\n")
+				return "", io.EOF
+			}
+			e.deleteAt(e.cursor)
+		case 127, 8: // Backspace
+			if e.cursor > 0 {
+				e.cursor--
+				e.deleteAt(e.cursor)
+			}
+		case 1: // Ctrl-A
+			e.cursor = 0
+		case 5: // Ctrl-E
+			e.cursor = len(e.line)
+		case 2: // Ctrl-B
+			e.moveCursor(-1)
+		case 6: // Ctrl-F
+			e.moveCursor(1)
+		case 11: // Ctrl-K
+			e.line = e.line[:e.cursor]
+		case 21: // Ctrl-U
+			e.line = e.line[e.cursor:]
+			e.cursor = 0
+		case 23: // Ctrl-W
+			e.deleteWord()
+		case 16: // Ctrl-P
+			histIdx, saved = e.recall(histIdx-1, histIdx, saved)
+		case 14: // Ctrl-N
+			histIdx, saved = e.recall(histIdx+1, histIdx, saved)
+		case '\t':
+			e.tab()
+		case 27: // Escape sequence
+			switch e.readEscape() {
+			case 'A':
+				histIdx, saved = e.recall(histIdx-1, histIdx, saved)
+			case 'B':
+				histIdx, saved = e.recall(histIdx+1, histIdx, saved)
+			case 'C':
+				e.moveCursor(1)
+			case 'D':
+				e.moveCursor(-1)
+			case 'H':
+				e.cursor = 0
+			case 'F':
+				e.cursor = len(e.line)
+			case '~':
+				e.deleteAt(e.cursor)
+			}
+		default:
+			if unicode.IsPrint(r) {
+				e.insert([]rune{r})
+			}
+		}
+		e.refresh()
+	}
+}
+
+// readEscape consumes an ANSI escape sequence and returns the key it encodes:
+// arrows as 'A' to 'D', Home as 'H', End as 'F' and Delete as '~'
+func (e *editor) readEscape() rune {
+	next, _, err := e.in.ReadRune()
+	if err != nil || next != '[' && next != 'O' {
+		return 0
+	}
+
+	key, _, err := e.in.ReadRune()
+	if err != nil {
+		return 0
+	}
+	if key < '0' || key > '9' {
+		return key
+	}
+
+	// Numbered keys such as Delete arrive as ESC [ 3 ~
+	if tilde, _, err := e.in.ReadRune(); err != nil || tilde != '~' {
+		return 0
+	}
+	switch key {
+	case '1', '7':
+		return 'H'
+	case '4', '8':
+		return 'F'
+	case '3':
+		return '~'
+	}
+	return 0
+}
+
+// recall replaces the line with history entry idx, where len(history) stands for
+// the line being typed before browsing started
+func (e *editor) recall(idx, current int, saved string) (int, string) {
+	if idx < 0 || idx > len(e.history) {
+		return current, saved
+	}
+	if current == len(e.history) {
+		saved = string(e.line)
+	}
+
+	if idx == len(e.history) {
+		e.line = []rune(saved)
+	} else {
+		e.line = []rune(e.history[idx])
+	}
+	e.cursor = len(e.line)
+	return idx, saved
+}
+
+func (e *editor) insert(text []rune) {
+	line := make([]rune, 0, len(e.line)+len(text))
+	line = append(line, e.line[:e.cursor]...)
+	line = append(line, text...)
+	e.line = append(line, e.line[e.cursor:]...)
+	e.cursor += len(text)
+}
+
+func (e *editor) deleteAt(pos int) {
+	if pos < len(e.line) {
+		e.line = append(e.line[:pos], e.line[pos+1:]...)
+	}
+}
+
+func (e *editor) deleteWord() {
+	start := e.cursor
+	for start > 0 && unicode.IsSpace(e.line[start-1]) {
+		start--
+	}
+	for start > 0 && !unicode.IsSpace(e.line[start-1]) {
+		start--
+	}
+	e.line = append(e.line[:start], e.line[e.cursor:]...)
+	e.cursor = start
+}
+
+func (e *editor) moveCursor(delta int) {
+	e.cursor = min(max(e.cursor+delta, 0), len(e.line))
+}
+
+// tab indents when the cursor follows only whitespace, and otherwise completes
+// the word before the cursor. A unique candidate is inserted in full; several
+// candidates are narrowed to their common prefix, or listed when there is none
+func (e *editor) tab() {
+	before := string(e.line[:e.cursor])
+	if strings.TrimSpace(before) == "" {
+		e.insert([]rune(INDENT))
+		return
+	}
+	if e.complete == nil {
+		return
+	}
+
+	word := []rune(currentWord(before))
+	candidates := e.complete(before)
+	switch len(candidates) {
+	case 0:
+		return
+	case 1:
+		e.insert([]rune(candidates[0])[len(word):])
+		return
+	}
+
+	prefix := []rune(commonPrefix(candidates))
+	if len(prefix) > len(word) {
+		e.insert(prefix[len(word):])
+		return
+	}
+	fmt.Fprint(e.out, "\n"+strings.Join(candidates, "  ")+"\n")
+}
+
+// refresh redraws the prompt and line and places the cursor
+func (e *editor) refresh() {
+	fmt.Fprintf(e.out, "\r%s%s\x1b[K", e.prompt, string(e.line))
+	if back := len(e.line) - e.cursor; back > 0 {
+		fmt.Fprintf(e.out, "\x1b[%dD", back)
+	}
+}
+
+func commonPrefix(words []string) string {
+	prefix := []rune(words[0])
+	for _, word := range words[1:] {
+		runes := []rune(word)
+		n := 0
+		for n < len(prefix) && n < len(runes) && prefix[n] == runes[n] {
+			n++
+		}
+		prefix = prefix[:n]
+	}
+	return string(prefix)
+}
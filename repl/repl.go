@@ -2,31 +2,74 @@ package repl
 
 import (
 	"bufio"
-	"fmt"
+	"errors"
 	"io"
 	"os"
+	"path/filepath"
 	"simpyl/evaluator"
 	"simpyl/lexer"
 	"simpyl/object"
 	"simpyl/parser"
+	"simpyl/token"
 	"strings"
 )
 
 const PROMPT = ">> "
 
+// CONTINUE_PROMPT is shown while a statement spans several lines
+const CONTINUE_PROMPT = "... "
+
+// INDENT is inserted by the Tab key at the start of a line
+const INDENT = "    "
+
+// StartInteractive runs a read-eval-print loop. On a terminal, lines can be
+// edited, recalled from history and tab completed; other inputs are read line
+// by line. Statements that open an indented block continue until a blank line,
+// and the result of the last expression is kept in _
 func StartInteractive(in io.Reader, out io.Writer) {
-	scanner := bufio.NewScanner(in)
 	env := object.NewEnvironment()
+	env.Set("__name__", &object.String{Value: "__main__"})
+
+	// input() in a script reads the terminal directly, since the editor only
+	// reads while a prompt is shown, but piped input is buffered by the scanner
+	var reader lineReader = &scanReader{scanner: bufio.NewScanner(in), out: out}
+	stdin := in
+	if f, ok := in.(*os.File); ok && isTerminal(f.Fd()) {
+		reader = newEditor(f, out, historyPath(), completer(env))
+	} else {
+		stdin = &lineInput{reader: reader}
+	}
+	runtime := evaluator.NewRuntime(stdin, out, out)
+	env.SetRuntime(runtime)
 
+	lines := []string{}
 	for {
-		fmt.Fprint(out, PROMPT)
-		scanned := scanner.Scan()
-		if !scanned {
+		prompt := PROMPT
+		if len(lines) > 0 {
+			prompt = CONTINUE_PROMPT
+		}
+
+		line, err := reader.ReadLine(prompt)
+		if err == errInterrupted {
+			io.WriteString(out, "KeyboardInterrupt\n")
+			lines = lines[:0]
+			continue
+		}
+		if err != nil {
 			return
 		}
+		if len(lines) == 0 && strings.TrimSpace(line) == "" {
+			continue
+		}
 
-		line := scanner.Text()
-		l := lexer.New(line)
+		lines = append(lines, line)
+		source := strings.Join(lines, "\n")
+		if !complete(source, line) {
+			continue
+		}
+		lines = lines[:0]
+
+		l := lexer.New(source)
 		p := parser.New(l)
 		program := p.ParseProgram()
 
@@ -36,38 +79,93 @@ func StartInteractive(in io.Reader, out io.Writer) {
 		}
 
 		evaluated := evaluator.Eval(program, env)
-		if evaluated != nil {
+		evaluator.WaitThreads(runtime)
+		if evaluated != nil && evaluated != evaluator.NULL {
 			io.WriteString(out, evaluated.Inspect())
 			io.WriteString(out, "\n")
+
+			if evaluated.Type() != object.ERROR_OBJ {
+				env.Set("_", evaluated)
+			}
 		}
 	}
 }
 
+// complete reports whether source, whose last line is line, is a whole statement.
+// Open brackets, triple-quoted strings and a trailing backslash need more lines,
+// and a statement that opens an indented block runs once a blank line ends it
+func complete(source, line string) bool {
+	l := lexer.New(source)
+	depth := 0
+	header, headerDone := token.Token{}, false
+	for tok := l.NextToken(); tok.Type != token.EOF; tok = l.NextToken() {
+		switch tok.Type {
+		case token.LPAREN, token.LBRACKET, token.LBRACE:
+			depth++
+		case token.RPAREN, token.RBRACKET, token.RBRACE:
+			depth--
+		case token.NEWLINE:
+			headerDone = true
+		}
+		// The first logical line decides whether a block follows
+		if !headerDone {
+			header = tok
+		}
+	}
+
+	for _, msg := range l.Errors() {
+		if strings.Contains(msg, "unterminated triple-quoted") {
+			return false
+		}
+	}
+	if depth > 0 || strings.HasSuffix(strings.TrimRight(line, " \t\r"), "\\") {
+		return false
+	}
+
+	if header.Type != token.COLON {
+		return true
+	}
+	return strings.Contains(source, "\n") && strings.TrimSpace(line) == ""
+}
+
 func printParserErrors(out io.Writer, errors []string) {
 	for _, msg := range errors {
 		io.WriteString(out, "\t"+msg+"\n")
 	}
 }
 
-func StartInterpreter(file string) {
+// StartInterpreter runs a script file. A file that cannot be read or parsed, or a
+// script that fails with an error, is reported as an error instead of being run
+// or printed
+func StartInterpreter(file string) error {
 	f, err := os.ReadFile(file)
 	if err != nil {
-		fmt.Print(err)
+		return err
 	}
 
-	str := string(f)
-	str = strings.Replace(str, "    ", "\t", -1)
-	l := lexer.New(str)
+	l := lexer.New(string(f))
 	p := parser.New(l)
 	program := p.ParseProgram()
 
 	if len(p.Errors()) != 0 {
-		panic(strings.Join(p.Errors(), "\n"))
+		return errors.New(strings.Join(p.Errors(), "\n"))
 	}
 
+	runtime := evaluator.NewRuntime(os.Stdin, os.Stdout, os.Stderr)
 	env := object.NewEnvironment()
+	env.SetRuntime(runtime)
+	env.Set("__name__", &object.String{Value: "__main__"})
+	if path, err := filepath.Abs(file); err == nil {
+		env.Set("__file__", &object.String{Value: path})
+	}
+
 	evaluated := evaluator.Eval(program, env)
+	evaluator.WaitThreads(runtime)
+	if evaluated, ok := evaluated.(*object.Error); ok {
+		return errors.New(evaluated.Message)
+	}
 	if evaluated != nil && evaluated != evaluator.NULL {
 		println(evaluated.Inspect())
 	}
+	return nil
 }
@@ -0,0 +1,130 @@
+package repl
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"simpyl/object"
+	"strings"
+	"testing"
+)
+
+func TestStartInteractive(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected []string
+	}{
+		{"1 + 2\n", []string{"3"}},
+		{"def f(x):\n    return x * 2\n\nf(21)\n", []string{"42"}},
+		{"def f(x):\n\treturn x * 2\n\nf(1)\n", []string{"2"}},
+		{"for i in range(2):\n    x = i\n\nx\n", []string{"1"}},
+		{"4\n_ + 1\n_ * 2\n", []string{"4", "5", "10"}},
+		{"x = 1\n", []string{}},
+		{"s = '''a\nb'''\nlen(s)\n", []string{"3"}},
+		{"[1,\n 2]\n", []string{"[1, 2]"}},
+		{"1 + \\\n2\n", []string{"3"}},
+		{"print('a', 1)\n", []string{"a", "1"}},
+		{"x = input('? ')\nhello\nx + '!'\n", []string{"? >> hello!"}},
+	}
+
+	for _, tt := range tests {
+		var out bytes.Buffer
+		StartInteractive(strings.NewReader(tt.input), &out)
+
+		results := []string{}
+		for _, line := range strings.Split(out.String(), "\n") {
+			line = strings.TrimLeft(line, PROMPT+CONTINUE_PROMPT)
+			if line != "" {
+				results = append(results, line)
+			}
+		}
+		if strings.Join(results, "|") != strings.Join(tt.expected, "|") {
+			t.Errorf("input %q printed wrong results. got=%q, want=%q", tt.input, results, tt.expected)
+		}
+	}
+}
+
+func TestStartInterpreter(t *testing.T) {
+	tests := []struct {
+		source   string
+		expected string
+	}{
+		{"x = 1\n", ""},
+		{"x = (\n", "expected an expression, got end of input at line 2, column 1"},
+		{"x = 1 / 0\n", "integer division or modulo by zero"},
+		{"[1, 2][5]\n", "list index out of range"},
+	}
+
+	dir := t.TempDir()
+	for _, tt := range tests {
+		file := filepath.Join(dir, "main.py")
+		if err := os.WriteFile(file, []byte(tt.source), 0644); err != nil {
+			t.Fatal(err)
+		}
+
+		err := StartInterpreter(file)
+		switch {
+		case tt.expected == "" && err != nil:
+			t.Errorf("%q: unexpected error: %v", tt.source, err)
+		case tt.expected != "" && (err == nil || err.Error() != tt.expected):
+			t.Errorf("%q: wrong error. expected=%q, got=%v", tt.source, tt.expected, err)
+		}
+	}
+
+	if err := StartInterpreter(filepath.Join(dir, "missing.py")); err == nil {
+		t.Errorf("expected an error for a missing file")
+	}
+}
+
+func TestComplete(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected bool
+	}{
+		{"1 + 2", true},
+		{"f(1,", false},
+		{"x = {'a': [1,", false},
+		{"s = '''abc", false},
+		{"x = 1 + \\", false},
+		{"d = {'a':\n1}", true},
+		{"while a: b", true},
+		{"def f():", false},
+		{"def f():\n\treturn 1", false},
+		{"def f():\n\treturn 1\n", true},
+	}
+
+	for _, tt := range tests {
+		lines := strings.Split(tt.input, "\n")
+		if got := complete(tt.input, lines[len(lines)-1]); got != tt.expected {
+			t.Errorf("complete(%q) wrong. got=%t, want=%t", tt.input, got, tt.expected)
+		}
+	}
+}
+
+func TestCompleter(t *testing.T) {
+	env := object.NewEnvironment()
+	env.Set("items", &object.List{})
+	env.Set("item_count", &object.Integer{Value: 1})
+	env.Set("_hidden", &object.Integer{Value: 1})
+	complete := completer(env)
+
+	tests := []struct {
+		line     string
+		expected []string
+	}{
+		{"ite", []string{"item_count", "items"}},
+		{"x = le", []string{"len"}},
+		{"items.cl", []string{"items.clear"}},
+		{"items.po", []string{"items.pop"}},
+		{"_hi", []string{"_hidden"}},
+		{"missing.a", []string{}},
+		{"items().a", []string{}},
+	}
+
+	for _, tt := range tests {
+		got := complete(tt.line)
+		if strings.Join(got, ",") != strings.Join(tt.expected, ",") {
+			t.Errorf("completions for %q wrong. got=%q, want=%q", tt.line, got, tt.expected)
+		}
+	}
+}
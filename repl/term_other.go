@@ -0,0 +1,15 @@
+//go:build !linux
+
+package repl
+
+import "errors"
+
+// isTerminal reports false on platforms without raw mode support, so the REPL
+// falls back to reading whole lines
+func isTerminal(fd uintptr) bool {
+	return false
+}
+
+func makeRaw(fd uintptr) (func(), error) {
+	return nil, errors.New("raw terminal mode is not supported on this platform")
+}
@@ -5,6 +5,8 @@ type TokenType string
 type Token struct {
 	Type    TokenType
 	Literal string
+	Line    int // Position of the first character, counted from 1
+	Column  int
 }
 
 const (
@@ -12,14 +14,17 @@ const (
 	EOF     = "EOF"
 
 	// Whitespace Tokens
-	TAB     = "\t"
 	NEWLINE = "\n"
+	INDENT  = "INDENT" // Start of a more deeply indented block
+	DEDENT  = "DEDENT" // End of an indented block
 
 	// Identifiers + literals
-	IDENT  = "IDENT" // add, foobar, x, y, ...
-	INT    = "INT"   // 1343456
-	FLOAT  = "FLOAT" // 1.2345
-	STRING = "STRING"
+	IDENT   = "IDENT" // add, foobar, x, y, ...
+	INT     = "INT"   // 1343456
+	FLOAT   = "FLOAT" // 1.2345
+	STRING  = "STRING"
+	BYTES   = "BYTES"
+	FSTRING = "FSTRING"
 
 	// Operators
 	ASSIGN   = "="
@@ -28,9 +33,15 @@ const (
 	BANG     = "!"
 	ASTERISK = "*"
 	SLASH    = "/"
+	PERCENT  = "%"
+	PIPE     = "|"
+	AMP      = "&"
+	CARET    = "^"
 
-	LT = "<"
-	GT = ">"
+	LT    = "<"
+	GT    = ">"
+	LT_EQ = "<="
+	GT_EQ = ">="
 
 	EQ     = "=="
 	NOT_EQ = "!="
@@ -59,6 +70,11 @@ const (
 	FOR      = "FOR"
 	IN       = "IN"
 	WHILE    = "WHILE"
+	CLASS    = "CLASS"
+	IMPORT   = "IMPORT"
+	FROM     = "FROM"
+	AS       = "AS"
+	DEL      = "DEL"
 )
 
 var keywords = map[string]TokenType{
@@ -72,6 +88,11 @@ var keywords = map[string]TokenType{
 	"for":    FOR,
 	"in":     IN,
 	"while":  WHILE,
+	"class":  CLASS,
+	"import": IMPORT,
+	"from":   FROM,
+	"as":     AS,
+	"del":    DEL,
 }
 
 func LookupIdent(ident string) TokenType {